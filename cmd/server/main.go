@@ -14,6 +14,7 @@ import (
 	"vidproc-go/internal/api"
 	"vidproc-go/internal/config"
 	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
 )
 
 func main() {
@@ -32,7 +33,40 @@ func main() {
 	}
 	defer db.Close()
 
-	srv := setupServer(&cfg, db)
+	if err := api.CheckDefaultPreset(context.Background(), cfg, storage.NewPresetStorage(db)); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	processor, err := video.NewProcessor(cfg.Processor, video.SchedulerOptions{
+		MaxConcurrent: cfg.MaxConcurrentEncodes,
+		MaxQueued:     cfg.MaxQueuedEncodes,
+		Threads:       cfg.EncodeThreads,
+		Nice:          cfg.EncodeNice,
+		MemoryLimit:   cfg.EncodeMemoryLimit,
+		MemoryCap:     cfg.EncodeMemoryCap,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create video processor: %v", err)
+	}
+
+	blobs, err := storage.NewBlobStore(cfg.BlobStore, storage.BlobOptions{
+		Dir:         cfg.VideoStoragePath,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Region:    cfg.S3Region,
+		S3Bucket:    cfg.S3Bucket,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+		S3PathStyle: cfg.S3PathStyle,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create blob store: %v", err)
+	}
+
+	if cfg.BlobStore != storage.BlobStoreLocal && cfg.WorkingCopyTTL > 0 {
+		go evictWorkingCopies(cfg, blobs)
+	}
+
+	srv := setupServer(&cfg, db, processor, blobs)
 
 	go func() {
 		log.Printf("Starting server on port %s in %s mode", cfg.Port, cfg.Environment)
@@ -44,8 +78,8 @@ func main() {
 	gracefulShutdown(srv)
 }
 
-func setupServer(cfg *config.Config, db *sql.DB) *http.Server {
-	apiRouter := api.NewRouter(db, *cfg)
+func setupServer(cfg *config.Config, db *sql.DB, processor video.Processor, blobs storage.BlobStore) *http.Server {
+	apiRouter := api.NewRouter(db, *cfg, processor, blobs)
 	handler := apiRouter.SetupRoutes()
 
 	mux := http.NewServeMux()
@@ -66,6 +100,21 @@ func setupServer(cfg *config.Config, db *sql.DB) *http.Server {
 	}
 }
 
+// evictWorkingCopies periodically removes the working copies of blobs that
+// have gone unused for cfg.WorkingCopyTTL minutes. It checks four times per
+// TTL, so a copy outlives its TTL by at most a quarter of it.
+func evictWorkingCopies(cfg config.Config, blobs storage.BlobStore) {
+	ttl := time.Duration(cfg.WorkingCopyTTL) * time.Minute
+	ticker := time.NewTicker(ttl / 4)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := api.EvictWorkingCopies(context.Background(), cfg, blobs, ttl); err != nil {
+			log.Printf("Failed to evict working copies: %v", err)
+		}
+	}
+}
+
 func ensureDirectories(cfg config.Config) error {
 	dirs := []string{
 		filepath.Dir(cfg.DBPath),
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	"vidproc-go/internal/storage"
+)
+
+// artifactKinds lists every kind of artifact a video can have.
+var artifactKinds = []storage.ArtifactKind{
+	storage.ArtifactPreview,
+	storage.ArtifactStoryboard,
+	storage.ArtifactStoryboardVTT,
+	storage.ArtifactSubtitles,
+	storage.ArtifactFrame,
+	storage.ArtifactWaveform,
+	storage.ArtifactWaveformPeaks,
+}
+
+// renderArtifact reserves a file for a new artifact of a video, runs render to
+// produce it, stores it as a blob and records the result. The file is
+// removed if any step fails.
+func (h *VideoHandler) renderArtifact(ctx context.Context, videoID string, kind storage.ArtifactKind, ext, contentType string,
+	params interface{}, render func(path string) error) (*storage.Artifact, error) {
+
+	id, err := generateID()
+	if err != nil {
+		return nil, err
+	}
+
+	filename := fmt.Sprintf("%s_%s%s", id, kind, ext)
+	path := filepath.Join(h.config.VideoStoragePath, filename)
+
+	if err := render(path); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	var rawParams json.RawMessage
+	if params != nil {
+		if rawParams, err = json.Marshal(params); err != nil {
+			os.Remove(path)
+			return nil, err
+		}
+	}
+
+	if err := h.storeBlob(ctx, filename); err != nil {
+		h.removeBlob(ctx, filename)
+		return nil, err
+	}
+
+	artifact := &storage.Artifact{
+		ID:          id,
+		VideoID:     videoID,
+		Kind:        kind,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        stat.Size(),
+		Params:      rawParams,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := h.artifacts.SaveArtifact(ctx, artifact); err != nil {
+		h.removeBlob(ctx, filename)
+		return nil, err
+	}
+
+	return artifact, nil
+}
+
+// findArtifact loads an artifact by ID, writing a 404 unless it belongs to the
+// given video and is of the expected kind.
+func findArtifact(w http.ResponseWriter, r *http.Request, store storage.ArtifactStorage, videoID, artifactID string, kind storage.ArtifactKind) *storage.Artifact {
+	artifact, err := store.GetArtifact(r.Context(), artifactID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get artifact")
+		return nil
+	}
+	if artifact == nil || artifact.VideoID != videoID || artifact.Kind != kind {
+		SendError(w, http.StatusNotFound, "artifact not found")
+		return nil
+	}
+	return artifact
+}
+
+func serveArtifact(w http.ResponseWriter, r *http.Request, blobs storage.BlobStore, artifact *storage.Artifact) {
+	content, _, err := openBlob(r.Context(), blobs, artifact.Filename)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		SendError(w, http.StatusNotFound, "artifact file not found")
+		return
+	}
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to read artifact file")
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", artifact.ContentType)
+	http.ServeContent(w, r, artifact.Filename, artifact.CreatedAt, content)
+}
+
+// latestArtifact returns the most recently created artifact of a kind for a
+// video, or nil if there is none.
+func latestArtifact(ctx context.Context, store storage.ArtifactStorage, videoID string, kind storage.ArtifactKind) (*storage.Artifact, error) {
+	artifacts, err := store.ListArtifacts(ctx, videoID, kind)
+	if err != nil || len(artifacts) == 0 {
+		return nil, err
+	}
+	return artifacts[0], nil
+}
+
+// serveLatestArtifact serves the newest artifact of a kind for a video.
+func serveLatestArtifact(w http.ResponseWriter, r *http.Request, store storage.ArtifactStorage, blobs storage.BlobStore, videoID string, kind storage.ArtifactKind) {
+	artifact, err := latestArtifact(r.Context(), store, videoID, kind)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get artifact")
+		return
+	}
+	if artifact == nil {
+		SendError(w, http.StatusNotFound, "artifact not found")
+		return
+	}
+
+	serveArtifact(w, r, blobs, artifact)
+}
+
+// pruneArtifacts removes every artifact of a kind for a video except keepID,
+// along with its blob.
+func (h *VideoHandler) pruneArtifacts(ctx context.Context, videoID string, kind storage.ArtifactKind, keepID string) error {
+	artifacts, err := h.artifacts.ListArtifacts(ctx, videoID, kind)
+	if err != nil {
+		return err
+	}
+	for _, artifact := range artifacts {
+		if artifact.ID == keepID {
+			continue
+		}
+		if err := h.artifacts.DeleteArtifact(ctx, artifact.ID); err != nil {
+			return err
+		}
+		h.removeBlob(ctx, artifact.Filename)
+	}
+	return nil
+}
+
+// deleteArtifacts removes artifacts along with their blobs. It is used to
+// undo a batch that failed part way through, so errors are ignored.
+func (h *VideoHandler) deleteArtifacts(ctx context.Context, artifacts []*storage.Artifact) {
+	for _, artifact := range artifacts {
+		h.artifacts.DeleteArtifact(ctx, artifact.ID)
+		h.removeBlob(ctx, artifact.Filename)
+	}
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"vidproc-go/internal/config"
+	"vidproc-go/internal/storage"
+)
+
+// blobStore returns the blob store of stores, defaulting to the local store
+// in cfg.VideoStoragePath.
+func blobStore(cfg config.Config, stores storage.Stores) storage.BlobStore {
+	if stores.Blobs != nil {
+		return stores.Blobs
+	}
+	return storage.NewLocalBlobStore(cfg.VideoStoragePath)
+}
+
+// workingCopyLeases counts, by path, the requests using each working copy.
+// EvictWorkingCopies leaves leased copies alone however old they are, since
+// a render can outlast the TTL.
+var workingCopyLeases = struct {
+	sync.Mutex
+	count map[string]int
+}{count: make(map[string]int)}
+
+// leaseWorkingCopy holds the working copy at path until ctx is done, which
+// for a request is when its handler returns, and then touches it so that
+// its age counts from the end of its last use.
+func leaseWorkingCopy(ctx context.Context, path string) {
+	workingCopyLeases.Lock()
+	workingCopyLeases.count[path]++
+	workingCopyLeases.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		workingCopyLeases.Lock()
+		defer workingCopyLeases.Unlock()
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		if workingCopyLeases.count[path]--; workingCopyLeases.count[path] == 0 {
+			delete(workingCopyLeases.count, path)
+		}
+	})
+}
+
+// localPath returns a path the processor can read a stored video from. With
+// the local blob store that is the blob itself. Otherwise the working copy
+// in VideoStoragePath is used if there is one, or the blob is fetched into
+// it; blobs are never rewritten under the same key, so a working copy
+// cannot go stale. The working copy is leased for the rest of ctx, so that
+// EvictWorkingCopies leaves it alone while it is read.
+func (h *VideoHandler) localPath(ctx context.Context, key string) (string, error) {
+	path := filepath.Join(h.config.VideoStoragePath, key)
+	if h.blobIsWorkingCopy(key) {
+		return path, nil
+	}
+	leaseWorkingCopy(ctx, path)
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return path, nil
+	}
+
+	blob, err := h.blobs.Get(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", key, err)
+	}
+	defer blob.Close()
+
+	tmp, err := os.CreateTemp(h.config.VideoStoragePath, ".fetch-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, blob)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", key, err)
+	}
+
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// storeBlob saves a video written to the working directory as the blob for
+// its filename. With the local blob store the file already is the blob.
+func (h *VideoHandler) storeBlob(ctx context.Context, key string) error {
+	if h.blobIsWorkingCopy(key) {
+		return nil
+	}
+
+	file, err := os.Open(filepath.Join(h.config.VideoStoragePath, key))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return h.blobs.Put(ctx, key, file, stat.Size())
+}
+
+// blobIsWorkingCopy reports whether the blob for key is the file in the
+// working directory, as it is when videos are kept in VideoStoragePath.
+func (h *VideoHandler) blobIsWorkingCopy(key string) bool {
+	return isWorkingCopy(h.blobs, h.config.VideoStoragePath, key)
+}
+
+func isWorkingCopy(blobs storage.BlobStore, dir, key string) bool {
+	local, ok := blobs.(*storage.LocalBlobStore)
+	return ok && local.Path(key) == filepath.Join(dir, key)
+}
+
+// EvictWorkingCopies removes the files in cfg.VideoStoragePath that are
+// copies of blobs kept elsewhere and have not been used for maxAge,
+// returning how many it removed. Files that are not stored yet, such as
+// outputs still being rendered, are kept, as are copies leased by a request
+// in progress and everything when the blobs are the files themselves.
+func EvictWorkingCopies(ctx context.Context, cfg config.Config, blobs storage.BlobStore, maxAge time.Duration) (int, error) {
+	stored, err := blobs.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	keys := make(map[string]bool, len(stored))
+	for _, blob := range stored {
+		keys[blob.Key] = true
+	}
+
+	entries, err := os.ReadDir(cfg.VideoStoragePath)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	evicted := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !keys[name] || isWorkingCopy(blobs, cfg.VideoStoragePath, name) {
+			continue
+		}
+		if evictWorkingCopy(filepath.Join(cfg.VideoStoragePath, name), cutoff) {
+			evicted++
+		}
+	}
+	return evicted, nil
+}
+
+// evictWorkingCopy removes the working copy at path unless it is leased or
+// was used after cutoff. The lease table stays locked until the copy is
+// gone, so that localPath either leases it first or fetches it again.
+func evictWorkingCopy(path string, cutoff time.Time) bool {
+	workingCopyLeases.Lock()
+	defer workingCopyLeases.Unlock()
+
+	if workingCopyLeases.count[path] > 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().After(cutoff) {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+// removeBlob deletes a stored video along with its working copy.
+func (h *VideoHandler) removeBlob(ctx context.Context, key string) error {
+	err := h.blobs.Delete(ctx, key)
+	os.Remove(filepath.Join(h.config.VideoStoragePath, key))
+	return err
+}
+
+// serveVideo sends the blob of a video as an attachment named after the
+// video and its version, honouring range requests.
+func serveVideo(w http.ResponseWriter, r *http.Request, blobs storage.BlobStore, video *storage.Video) {
+	content, info, err := openBlob(r.Context(), blobs, video.Filename)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		SendError(w, http.StatusNotFound, "video file not found")
+		return
+	}
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to read video file")
+		return
+	}
+	defer content.Close()
+
+	name := fmt.Sprintf("%s_v%d%s", video.ID, video.Version, filepath.Ext(video.Filename))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeContent(w, r, video.Filename, info.ModTime, content)
+}
+
+// openBlob opens a blob for http.ServeContent, which seeks and reads only
+// the ranges it serves.
+func openBlob(ctx context.Context, blobs storage.BlobStore, key string) (*blobReader, *storage.BlobInfo, error) {
+	info, err := blobs.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &blobReader{ctx: ctx, blobs: blobs, key: key, size: info.Size}, info, nil
+}
+
+// blobReader reads a blob as an io.ReadSeeker, opening a range of it from
+// the current offset on the first read after each seek.
+type blobReader struct {
+	ctx    context.Context
+	blobs  storage.BlobStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (b *blobReader) Read(p []byte) (int, error) {
+	if b.offset >= b.size {
+		return 0, io.EOF
+	}
+	if b.body == nil {
+		body, err := b.blobs.Range(b.ctx, b.key, b.offset, b.size-b.offset)
+		if err != nil {
+			return 0, err
+		}
+		b.body = body
+	}
+
+	n, err := b.body.Read(p)
+	b.offset += int64(n)
+	return n, err
+}
+
+func (b *blobReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += b.offset
+	case io.SeekEnd:
+		offset += b.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != b.offset {
+		b.Close()
+		b.offset = offset
+	}
+	return offset, nil
+}
+
+func (b *blobReader) Close() error {
+	if b.body == nil {
+		return nil
+	}
+	err := b.body.Close()
+	b.body = nil
+	return err
+}
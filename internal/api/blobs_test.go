@@ -0,0 +1,271 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"vidproc-go/internal/storage"
+)
+
+// TestBlobStoreRoundTrip keeps blobs apart from the working directory, as a
+// remote store would, and clears the working directory between steps so
+// every input has to be fetched back from the blob store.
+func TestBlobStoreRoundTrip(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	blobs := storage.NewLocalBlobStore(t.TempDir())
+	mockStorage := NewMockStorage()
+	stores := mockStorage.Stores()
+	stores.Blobs = blobs
+
+	concat := func(inputs []string, output string) error {
+		var data []byte
+		for _, input := range inputs {
+			content, err := os.ReadFile(input)
+			if err != nil {
+				return err
+			}
+			data = append(data, content...)
+		}
+		return os.WriteFile(output, data, 0644)
+	}
+	handler := NewVideoHandler(cfg, stores, &MockProcessor{
+		trimFunc: func(ctx context.Context, input, output string, start, end float64) error {
+			return concat([]string{input}, output)
+		},
+		mergeFunc: func(ctx context.Context, inputs []string, output string) error {
+			return concat(inputs, output)
+		},
+	})
+
+	serve := func(req *http.Request, h http.HandlerFunc) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+	upload := func(path, content string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("video", "test.mp4")
+		part.Write([]byte(content))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, path, body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		if path == "/api/videos" {
+			return serve(req, handler.HandleVideos)
+		}
+		return serve(req, handler.HandleVideoOperations)
+	}
+	clearWorkingDir := func() {
+		entries, _ := os.ReadDir(tmpDir)
+		for _, entry := range entries {
+			os.RemoveAll(filepath.Join(tmpDir, entry.Name()))
+		}
+	}
+	blobContent := func(key string) string {
+		t.Helper()
+		r, err := blobs.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Expected blob %s to be stored: %v", key, err)
+		}
+		defer r.Close()
+		data, _ := io.ReadAll(r)
+		return string(data)
+	}
+
+	rr := upload("/api/videos", "uploaded video")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Upload returned wrong status code: got %v want %v\nResponse body: %v",
+			rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var uploaded UploadResponse
+	json.NewDecoder(rr.Body).Decode(&Response{Data: &uploaded})
+	original := uploaded.Video
+	if got := blobContent(original.Filename); got != "uploaded video" {
+		t.Errorf("Expected the upload to be stored as a blob, got %q", got)
+	}
+	clearWorkingDir()
+
+	var trimmed storage.Video
+	t.Run("trim", func(t *testing.T) {
+		body, _ := json.Marshal(TrimRequest{Start: 0, End: 5})
+		rr := serve(httptest.NewRequest(http.MethodPost, "/api/videos/trim/"+original.ID, bytes.NewReader(body)), handler.HandleTrim)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Trim returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusOK, rr.Body.String())
+		}
+		json.NewDecoder(rr.Body).Decode(&Response{Data: &trimmed})
+		if got := blobContent(trimmed.Filename); got != "uploaded video" {
+			t.Errorf("Expected the trim to read the fetched blob and store its output, got %q", got)
+		}
+	})
+	clearWorkingDir()
+
+	t.Run("merge", func(t *testing.T) {
+		body, _ := json.Marshal(MergeRequest{VideoIDs: []string{original.ID, trimmed.ID}})
+		rr := serve(httptest.NewRequest(http.MethodPost, "/api/videos/merge", bytes.NewReader(body)), handler.HandleMerge)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Merge returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var merged storage.Video
+		json.NewDecoder(rr.Body).Decode(&Response{Data: &merged})
+		if got := blobContent(merged.Filename); got != "uploaded videouploaded video" {
+			t.Errorf("Expected the merged output to be stored, got %q", got)
+		}
+	})
+	clearWorkingDir()
+
+	t.Run("download range", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/videos/"+original.ID+"/download", nil)
+		req.Header.Set("Range", "bytes=9-13")
+		rr := serve(req, handler.HandleVideoOperations)
+		if rr.Code != http.StatusPartialContent {
+			t.Fatalf("Download returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusPartialContent, rr.Body.String())
+		}
+		if rr.Body.String() != "video" {
+			t.Errorf("Expected the requested range of the blob, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("subtitles", func(t *testing.T) {
+		track := uploadTestSubtitles(t, handler, original.ID)
+		if got := blobContent(track.Filename); !strings.HasPrefix(got, "WEBVTT") {
+			t.Errorf("Expected the track to be stored as a blob, got %q", got)
+		}
+		clearWorkingDir()
+
+		path := "/api/videos/" + original.ID + "/subtitles/" + track.ID
+		rr := serve(httptest.NewRequest(http.MethodGet, path, nil), handler.HandleVideoOperations)
+		if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Body.String(), "WEBVTT") {
+			t.Errorf("Expected the track to be served from the blob, got %v %q", rr.Code, rr.Body.String())
+		}
+		rr = serve(httptest.NewRequest(http.MethodGet, path+"?format=srt", nil), handler.HandleVideoOperations)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "First") {
+			t.Errorf("Expected the track to be fetched and converted, got %v %q", rr.Code, rr.Body.String())
+		}
+
+		rr = serve(httptest.NewRequest(http.MethodDelete, path, nil), handler.HandleVideoOperations)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Delete returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if _, err := blobs.Stat(context.Background(), track.Filename); !errors.Is(err, storage.ErrBlobNotFound) {
+			t.Errorf("Expected the deleted track's blob to be removed, got %v", err)
+		}
+	})
+
+	t.Run("prune version", func(t *testing.T) {
+		rr := upload("/api/videos/"+original.ID+"/versions", "replacement")
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Create version returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusCreated, rr.Body.String())
+		}
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/videos/"+original.ID+"/versions/1", nil)
+		rr = serve(req, handler.HandleVideoOperations)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Prune returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusOK, rr.Body.String())
+		}
+		if _, err := blobs.Stat(context.Background(), original.Filename); !errors.Is(err, storage.ErrBlobNotFound) {
+			t.Errorf("Expected the pruned version's blob to be deleted, got %v", err)
+		}
+	})
+}
+
+func TestEvictWorkingCopies(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	blobs := storage.NewLocalBlobStore(t.TempDir())
+	old := time.Now().Add(-2 * time.Hour)
+
+	write := func(name string, stored bool, modTime time.Time) {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if stored {
+			if err := blobs.Put(ctx, name, bytes.NewReader([]byte(name)), int64(len(name))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+	write("unused.mp4", true, old)
+	write("recent.mp4", true, time.Now())
+	write("rendering.mp4", false, old)
+
+	evicted, err := EvictWorkingCopies(ctx, cfg, blobs, time.Hour)
+	if err != nil {
+		t.Fatalf("EvictWorkingCopies failed: %v", err)
+	}
+	if evicted != 1 {
+		t.Errorf("Expected 1 working copy to be evicted, got %d", evicted)
+	}
+	for name, want := range map[string]bool{"unused.mp4": false, "recent.mp4": true, "rendering.mp4": true} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); (err == nil) != want {
+			t.Errorf("%s: expected kept=%v, got %v", name, want, err)
+		}
+	}
+	if _, err := blobs.Stat(ctx, "unused.mp4"); err != nil {
+		t.Errorf("Expected the blob of an evicted copy to be kept, got %v", err)
+	}
+
+	t.Run("leased copy", func(t *testing.T) {
+		write("leased.mp4", true, old)
+		path := filepath.Join(tmpDir, "leased.mp4")
+		leaseCtx, cancel := context.WithCancel(ctx)
+		leaseWorkingCopy(leaseCtx, path)
+
+		if evicted, err := EvictWorkingCopies(ctx, cfg, blobs, time.Hour); err != nil || evicted != 0 {
+			t.Errorf("Expected a leased copy to be kept, got %d, %v", evicted, err)
+		}
+
+		cancel()
+		deadline := time.Now().Add(time.Second)
+		for {
+			workingCopyLeases.Lock()
+			leased := workingCopyLeases.count[path] > 0
+			workingCopyLeases.Unlock()
+			if !leased || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Expected the copy to be kept after its lease ended, got %v", err)
+		}
+		if !info.ModTime().After(old) {
+			t.Errorf("Expected the copy to be touched when its lease ended, got %v", info.ModTime())
+		}
+	})
+
+	t.Run("local store", func(t *testing.T) {
+		write("local.mp4", false, old)
+		local := storage.NewLocalBlobStore(tmpDir)
+		if evicted, err := EvictWorkingCopies(ctx, cfg, local, time.Hour); err != nil || evicted != 0 {
+			t.Errorf("Expected nothing to be evicted from the local store, got %d, %v", evicted, err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "local.mp4")); err != nil {
+			t.Errorf("Expected the blob to be kept, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// ComposeRequest combines the video named in the path, the base, with up to
+// three overlay videos.
+type ComposeRequest struct {
+	OverlayIDs []string `json:"overlay_ids"`
+	video.ComposeOptions
+}
+
+func (h *VideoHandler) handleCompose(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	base := h.loadVideo(w, r, videoID)
+	if base == nil {
+		return
+	}
+
+	var req ComposeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	opts := req.ComposeOptions
+	if err := opts.Validate(1 + len(req.OverlayIDs)); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var inputs []video.ComposeInput
+	for _, id := range append([]string{base.ID}, req.OverlayIDs...) {
+		source := h.loadVideo(w, r, id)
+		if source == nil {
+			return
+		}
+
+		input, err := h.composeInput(r.Context(), source)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to get video info")
+			return
+		}
+		inputs = append(inputs, input)
+	}
+
+	if !h.checkDerivedDuration(w, opts.OutputDuration(inputs)) {
+		return
+	}
+
+	id, filename, outputPath, err := h.newOutput("composed", filepath.Ext(base.Filename))
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
+		return
+	}
+
+	op := h.startOperation(r.Context(), storage.OperationCompose, append([]string{base.ID}, req.OverlayIDs...), opts)
+	if err := h.processor.Compose(r.Context(), inputs, outputPath, opts); err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to compose videos")
+		return
+	}
+
+	composed, err := h.saveDerivedVideo(r.Context(), id, filename)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save composed video")
+		return
+	}
+	h.finishOperation(r.Context(), op, nil, composed)
+
+	SendSuccess(w, http.StatusOK, composed, "videos composed successfully")
+}
+
+// composeInput describes a stored video as an input to a composition.
+func (h *VideoHandler) composeInput(ctx context.Context, source *storage.Video) (video.ComposeInput, error) {
+	path, err := h.localPath(ctx, source.Filename)
+	if err != nil {
+		return video.ComposeInput{}, err
+	}
+	info, err := h.processor.GetVideoInfo(ctx, path)
+	if err != nil {
+		return video.ComposeInput{}, err
+	}
+
+	width, height := info.DisplaySize()
+	return video.ComposeInput{
+		Path:     path,
+		Width:    width,
+		Height:   height,
+		Duration: info.Duration,
+		HasAudio: info.AudioCodec != "",
+	}, nil
+}
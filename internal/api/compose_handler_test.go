@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleCompose(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var gotInputs []video.ComposeInput
+	var gotOpts video.ComposeOptions
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			if strings.HasSuffix(filepath, "long.mp4") {
+				return &video.VideoInfo{Duration: 40, Size: 1024, Width: 640, Height: 360}, nil
+			}
+			return &video.VideoInfo{Duration: 10, Size: 1024, Width: 1280, Height: 720, AudioCodec: "aac"}, nil
+		},
+		composeFunc: func(ctx context.Context, inputs []video.ComposeInput, output string, opts video.ComposeOptions) error {
+			gotInputs, gotOpts = inputs, opts
+			return nil
+		},
+	})
+
+	for _, v := range []*storage.Video{
+		{ID: "base", Filename: "base.mp4"},
+		{ID: "overlay", Filename: "overlay.mp4"},
+		{ID: "long", Filename: "long.mp4"},
+	} {
+		v.Size, v.Duration, v.Status = 1000, 10, storage.StatusCompleted
+		mockStorage.SaveVideo(context.Background(), v)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantInputs int
+	}{
+		{"pip", `{"overlay_ids": ["overlay"], "layout": "pip", "corner": "top-left"}`, http.StatusOK, 2},
+		{"grid", `{"overlay_ids": ["overlay", "long", "overlay"], "layout": "grid", "audio": "mix"}`, http.StatusOK, 4},
+		{"shortest with long overlay", `{"overlay_ids": ["long"], "layout": "hstack"}`, http.StatusOK, 2},
+		{"longest beyond max duration", `{"overlay_ids": ["long"], "layout": "hstack", "duration": "longest"}`, http.StatusBadRequest, 0},
+		{"missing overlay", `{"overlay_ids": ["missing"], "layout": "vstack"}`, http.StatusNotFound, 0},
+		{"no overlays", `{"layout": "hstack"}`, http.StatusBadRequest, 0},
+		{"too many overlays", `{"overlay_ids": ["overlay", "overlay", "overlay", "overlay"], "layout": "grid"}`, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotInputs = nil
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/base/compose", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if len(gotInputs) != tt.wantInputs {
+				t.Errorf("Expected %d inputs, got %d", tt.wantInputs, len(gotInputs))
+			}
+		})
+	}
+
+	if gotOpts.Audio != video.AudioBase || gotOpts.Duration != video.DurationShortest {
+		t.Errorf("Expected default audio and duration, got %+v", gotOpts)
+	}
+}
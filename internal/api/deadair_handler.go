@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// IntervalsResponse lists the silent and black intervals of a video together
+// with the range an automatic trim would keep.
+type IntervalsResponse struct {
+	Intervals    []*storage.Interval `json:"intervals"`
+	ContentStart *float64            `json:"content_start,omitempty"`
+	ContentEnd   *float64            `json:"content_end,omitempty"`
+}
+
+func (h *VideoHandler) handleIntervals(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	var intervals []*storage.Interval
+	var duration float64
+	var err error
+
+	if r.Method == http.MethodPost {
+		opts := video.DefaultDeadAirOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+			SendError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if err := opts.Validate(); err != nil {
+			SendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if intervals, duration, err = h.detectDeadAir(r.Context(), source, opts); err != nil {
+			sendProcessingError(w, err, "failed to detect silence and black frames")
+			return
+		}
+	} else {
+		if intervals, err = h.intervals.ListIntervals(r.Context(), source.ID); err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to list intervals")
+			return
+		}
+		_, info, err := h.probeVideo(r.Context(), source)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to get video info")
+			return
+		}
+		duration = info.Duration
+	}
+
+	response := IntervalsResponse{Intervals: intervals}
+	if start, end, err := contentBounds(intervals, duration); err == nil {
+		response.ContentStart = &start
+		response.ContentEnd = &end
+	}
+
+	SendSuccess(w, http.StatusOK, response, "")
+}
+
+// detectDeadAir analyses a stored video and records the intervals it finds,
+// replacing those of any earlier analysis. It also returns the exact
+// duration of the video.
+func (h *VideoHandler) detectDeadAir(ctx context.Context, source *storage.Video, opts video.DeadAirOptions) ([]*storage.Interval, float64, error) {
+	sourcePath, info, err := h.probeVideo(ctx, source)
+	if err != nil {
+		return nil, 0, err
+	}
+	dead, err := h.processor.DetectDeadAir(ctx, sourcePath, info.Duration, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	// A video without an audio track is silent throughout.
+	if info.AudioCodec == "" {
+		dead.Silence = []video.Interval{{Start: 0, End: info.Duration}}
+	}
+
+	intervals := make([]*storage.Interval, 0, len(dead.Silence)+len(dead.Black))
+	for _, in := range dead.Silence {
+		intervals = append(intervals, &storage.Interval{VideoID: source.ID, Kind: storage.IntervalSilence, Start: in.Start, End: in.End})
+	}
+	for _, in := range dead.Black {
+		intervals = append(intervals, &storage.Interval{VideoID: source.ID, Kind: storage.IntervalBlack, Start: in.Start, End: in.End})
+	}
+
+	if err := h.intervals.ReplaceIntervals(ctx, source.ID, intervals); err != nil {
+		return nil, 0, err
+	}
+	return intervals, info.Duration, nil
+}
+
+// autoTrimBounds analyses a video and returns the range left after removing
+// leading and trailing dead air, where it is both silent and black.
+func (h *VideoHandler) autoTrimBounds(w http.ResponseWriter, r *http.Request, source *storage.Video) (float64, float64, bool) {
+	intervals, duration, err := h.detectDeadAir(r.Context(), source, video.DefaultDeadAirOptions)
+	if err != nil {
+		sendProcessingError(w, err, "failed to detect silence and black frames")
+		return 0, 0, false
+	}
+
+	start, end, err := contentBounds(intervals, duration)
+	if err != nil {
+		SendError(w, http.StatusUnprocessableEntity, err.Error())
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+func contentBounds(intervals []*storage.Interval, duration float64) (float64, float64, error) {
+	var silence, black []video.Interval
+	for _, in := range intervals {
+		switch in.Kind {
+		case storage.IntervalSilence:
+			silence = append(silence, video.Interval{Start: in.Start, End: in.End})
+		case storage.IntervalBlack:
+			black = append(black, video.Interval{Start: in.Start, End: in.End})
+		}
+	}
+	return video.ContentBounds(silence, black, duration)
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func (m *MockVideoStorage) ReplaceIntervals(ctx context.Context, videoID string, intervals []*storage.Interval) error {
+	m.intervals[videoID] = intervals
+	return nil
+}
+
+func (m *MockVideoStorage) ListIntervals(ctx context.Context, videoID string) ([]*storage.Interval, error) {
+	return m.intervals[videoID], nil
+}
+
+func TestHandleIntervals(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			return &video.VideoInfo{Duration: 10.4, Format: "mp4", AudioCodec: "aac"}, nil
+		},
+		detectDeadAirFunc: func(ctx context.Context, input string, duration float64, opts video.DeadAirOptions) (*video.DeadAir, error) {
+			return &video.DeadAir{
+				Silence: []video.Interval{{Start: 0, End: 2}, {Start: 8, End: 10.4}},
+				Black:   []video.Interval{{Start: 0, End: 1}},
+			}, nil
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+	}{
+		{"detect", http.MethodPost, "", http.StatusOK},
+		{"list", http.MethodGet, "", http.StatusOK},
+		{"invalid options", http.MethodPost, `{"noise_db":10}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/videos/test-video/intervals", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data IntervalsResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Data.Intervals) != 3 {
+				t.Errorf("Expected 3 intervals, got %d", len(response.Data.Intervals))
+			}
+			// Only the start is both silent and black, and the end is the
+			// exact duration rather than the stored whole seconds.
+			if response.Data.ContentStart == nil || *response.Data.ContentStart != 1 ||
+				response.Data.ContentEnd == nil || *response.Data.ContentEnd != 10.4 {
+				t.Errorf("Expected content range 1-10.4, got %v-%v", response.Data.ContentStart, response.Data.ContentEnd)
+			}
+		})
+	}
+}
+
+func TestHandleAutoTrim(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	dead := &video.DeadAir{
+		Silence: []video.Interval{{Start: 0, End: 2}, {Start: 9, End: 10.4}},
+		Black:   []video.Interval{{Start: 0, End: 1.5}, {Start: 9.5, End: 10.4}},
+	}
+	audio := "aac"
+	var trimmed [2]float64
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			return &video.VideoInfo{Duration: 10.4, Format: "mp4", AudioCodec: audio}, nil
+		},
+		detectDeadAirFunc: func(ctx context.Context, input string, duration float64, opts video.DeadAirOptions) (*video.DeadAir, error) {
+			return dead, nil
+		},
+		trimFunc: func(ctx context.Context, input, output string, start, end float64) error {
+			trimmed = [2]float64{start, end}
+			return nil
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	trim := func() *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(TrimRequest{Auto: true})
+		req := httptest.NewRequest(http.MethodPost, "/api/videos/trim/test-video", bytes.NewBuffer(reqBody))
+		rr := httptest.NewRecorder()
+		handler.HandleTrim(rr, req)
+		return rr
+	}
+
+	if rr := trim(); rr.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+			rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if trimmed != [2]float64{1.5, 9.5} {
+		t.Errorf("Expected trim to 1.5-9.5, got %v", trimmed)
+	}
+
+	// Silence over a picture, as in a screen recording, is content.
+	dead.Silence = []video.Interval{{Start: 0, End: 10.4}}
+	dead.Black = nil
+	if rr := trim(); rr.Code != http.StatusOK || trimmed != [2]float64{0, 10.4} {
+		t.Errorf("Expected a silent video to be kept whole, got %v %v", rr.Code, trimmed)
+	}
+
+	// Without an audio track the video is silent throughout, so black
+	// frames alone are dead air.
+	audio = ""
+	dead.Silence = nil
+	dead.Black = []video.Interval{{Start: 0, End: 3}}
+	if rr := trim(); rr.Code != http.StatusOK || trimmed != [2]float64{3, 10.4} {
+		t.Errorf("Expected trim to 3-10.4 without audio, got %v %v", rr.Code, trimmed)
+	}
+
+	dead.Black = []video.Interval{{Start: 0, End: 10.4}}
+	if rr := trim(); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a video without content, got %v", rr.Code)
+	}
+}
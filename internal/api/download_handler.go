@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// handleDownload serves the file of a video, or of an earlier version of it
+// selected with ?version=N, with support for range requests.
+func (h *VideoHandler) handleDownload(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodGet {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	if param := r.URL.Query().Get("version"); param != "" {
+		number, err := strconv.Atoi(param)
+		if err != nil || number < 1 {
+			SendError(w, http.StatusBadRequest, "invalid version")
+			return
+		}
+		if number != source.Version {
+			version := h.loadVersion(w, r, source.ID, number)
+			if version == nil {
+				return
+			}
+			source = asVersion(source, version)
+		}
+	}
+
+	serveVideo(w, r, h.blobs, source)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// handleEnhance stabilises, denoises or colour corrects a video. The filters
+// used are recorded on the new video.
+func (h *VideoHandler) handleEnhance(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var opts video.EnhanceOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	id, filename, outputPath, err := h.newOutput("enhanced", filepath.Ext(source.Filename))
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	op := h.startOperation(r.Context(), storage.OperationEnhance, []string{source.ID}, opts)
+	if err := h.processor.Enhance(r.Context(), sourcePath, outputPath, opts); err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to enhance video")
+		return
+	}
+
+	enhanced, err := h.saveDerived(r.Context(), &storage.Video{
+		ID:       id,
+		Filename: filename,
+		Filters:  opts.Filters(),
+	})
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save enhanced video")
+		return
+	}
+	h.finishOperation(r.Context(), op, nil, enhanced)
+
+	SendSuccess(w, http.StatusOK, enhanced, "video enhanced successfully")
+}
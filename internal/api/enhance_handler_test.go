@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleEnhance(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantFilters []string
+	}{
+		{"deshake and denoise", `{"deshake": true, "denoise": "nlmeans"}`, http.StatusOK, []string{"deshake", "nlmeans=s=3"}},
+		{"colour", `{"brightness": 0.05, "contrast": 1.1}`, http.StatusOK, []string{"eq=brightness=0.05:contrast=1.1"}},
+		{"nothing requested", `{}`, http.StatusBadRequest, nil},
+		{"gamma out of range", `{"gamma": 20}`, http.StatusBadRequest, nil},
+		{"unknown denoise", `{"denoise": "median"}`, http.StatusBadRequest, nil},
+		{"invalid body", `{`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := NewMockStorage()
+			called := false
+			handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+				enhanceFunc: func(ctx context.Context, input, output string, opts video.EnhanceOptions) error {
+					called = true
+					return os.WriteFile(output, []byte("enhanced"), 0644)
+				},
+			})
+
+			mockStorage.SaveVideo(context.Background(), &storage.Video{
+				ID:       "test-video",
+				Filename: "test.mp4",
+				Size:     1000,
+				Duration: 10,
+				Status:   storage.StatusCompleted,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/enhance", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if called {
+					t.Error("Expected no render for an invalid request")
+				}
+				return
+			}
+
+			var response struct {
+				Data storage.Video `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(response.Data.Filters, tt.wantFilters) {
+				t.Errorf("Expected filters %v, got %v", tt.wantFilters, response.Data.Filters)
+			}
+			if saved := mockStorage.videos[response.Data.ID]; saved == nil || !reflect.DeepEqual(saved.Filters, tt.wantFilters) {
+				t.Errorf("Expected the filters to be saved, got %+v", saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+const (
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 100
+)
+
+// SimilarVideo is a stored video whose fingerprint is within the requested
+// distance of another. Distance is the mean number of differing bits per
+// frame hash, from 0 for identical frames to 64.
+type SimilarVideo struct {
+	VideoID  string  `json:"video_id"`
+	Distance float64 `json:"distance"`
+}
+
+// UploadResponse is the uploaded video together with the near-duplicates
+// found when the duplicate policy is "warn".
+type UploadResponse struct {
+	*storage.Video
+	Duplicates []SimilarVideo `json:"duplicates,omitempty"`
+}
+
+func (h *VideoHandler) handleSimilar(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodGet {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	maxDistance := h.config.DuplicateThreshold
+	if value := r.URL.Query().Get("max_distance"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > video.MaxHashDistance {
+			SendError(w, http.StatusBadRequest, "max_distance must be between 0 and 64")
+			return
+		}
+		maxDistance = parsed
+	}
+
+	limit := defaultSimilarLimit
+	if err := queryInt(r.URL.Query().Get("limit"), &limit); err != nil || limit < 1 || limit > maxSimilarLimit {
+		SendError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+		return
+	}
+
+	v := h.loadVideo(w, r, videoID)
+	if v == nil {
+		return
+	}
+
+	fingerprint, err := h.fingerprints.GetFingerprint(r.Context(), v.ID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get fingerprint")
+		return
+	}
+	if fingerprint == nil {
+		if fingerprint, err = h.fingerprintVideo(r.Context(), v); err != nil {
+			sendProcessingError(w, err, "failed to fingerprint video")
+			return
+		}
+	}
+
+	similar, err := h.findSimilar(r.Context(), v.ID, fingerprint.Hashes, maxDistance)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to compare fingerprints")
+		return
+	}
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	SendSuccess(w, http.StatusOK, similar, "")
+}
+
+// fingerprintVideo computes and stores the fingerprint of a video that has
+// none, such as one uploaded before fingerprinting or derived from another.
+func (h *VideoHandler) fingerprintVideo(ctx context.Context, v *storage.Video) (*storage.Fingerprint, error) {
+	path, err := h.localPath(ctx, v.Filename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := h.processor.GetVideoInfo(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, err := h.processor.Fingerprint(ctx, path, info.Duration)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := &storage.Fingerprint{VideoID: v.ID, Hashes: hashes}
+	if err := h.fingerprints.SaveFingerprint(ctx, fingerprint); err != nil {
+		return nil, err
+	}
+	return fingerprint, nil
+}
+
+// findSimilar returns the stored videos, other than videoID, within
+// maxDistance of hashes, closest first.
+func (h *VideoHandler) findSimilar(ctx context.Context, videoID string, hashes []uint64, maxDistance float64) ([]SimilarVideo, error) {
+	fingerprints, err := h.fingerprints.ListFingerprints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	similar := []SimilarVideo{}
+	for _, fingerprint := range fingerprints {
+		if fingerprint.VideoID == videoID {
+			continue
+		}
+		if distance := video.FingerprintDistance(hashes, fingerprint.Hashes); distance <= maxDistance {
+			similar = append(similar, SimilarVideo{VideoID: fingerprint.VideoID, Distance: distance})
+		}
+	}
+
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Distance != similar[j].Distance {
+			return similar[i].Distance < similar[j].Distance
+		}
+		return similar[i].VideoID < similar[j].VideoID
+	})
+	return similar, nil
+}
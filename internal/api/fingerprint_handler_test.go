@@ -0,0 +1,199 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vidproc-go/internal/config"
+	"vidproc-go/internal/storage"
+)
+
+func (m *MockVideoStorage) SaveFingerprint(ctx context.Context, fingerprint *storage.Fingerprint) error {
+	m.fingerprints[fingerprint.VideoID] = fingerprint
+	return nil
+}
+
+func (m *MockVideoStorage) GetFingerprint(ctx context.Context, videoID string) (*storage.Fingerprint, error) {
+	return m.fingerprints[videoID], nil
+}
+
+func (m *MockVideoStorage) ListFingerprints(ctx context.Context) ([]*storage.Fingerprint, error) {
+	fingerprints := make([]*storage.Fingerprint, 0, len(m.fingerprints))
+	for _, fingerprint := range m.fingerprints {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	return fingerprints, nil
+}
+
+func newVideoUpload(t *testing.T) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("video", "test.mp4")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write([]byte("fake video content"))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/videos", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestUploadDuplicatePolicy(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	tests := []struct {
+		name           string
+		policy         string
+		hashes         []uint64
+		wantStatus     int
+		wantDuplicates int
+	}{
+		{"warn on near-duplicate", config.DuplicateWarn, []uint64{0x1, 0x0}, http.StatusCreated, 1},
+		{"reject near-duplicate", config.DuplicateReject, []uint64{0x1, 0x0}, http.StatusConflict, 0},
+		{"reject allows distinct", config.DuplicateReject, []uint64{0xffffffff, 0xffffffff}, http.StatusCreated, 0},
+		{"off ignores duplicate", config.DuplicateOff, []uint64{0x0, 0x0}, http.StatusCreated, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cfg
+			cfg.DuplicatePolicy = tt.policy
+			cfg.DuplicateThreshold = 4
+
+			mockStorage := NewMockStorage()
+			mockStorage.SaveVideo(context.Background(), &storage.Video{ID: "existing", Filename: "existing.mp4", Duration: 10, Status: storage.StatusCompleted})
+			mockStorage.SaveFingerprint(context.Background(), &storage.Fingerprint{VideoID: "existing", Hashes: []uint64{0x0, 0x0}})
+
+			handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+				fingerprintFunc: func(ctx context.Context, input string, duration float64) ([]uint64, error) {
+					return tt.hashes, nil
+				},
+			})
+
+			rr := httptest.NewRecorder()
+			handler.HandleVideos(rr, newVideoUpload(t))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				if len(mockStorage.videos) != 1 {
+					t.Errorf("Expected the rejected upload not to be saved")
+				}
+				return
+			}
+
+			var response struct {
+				Data struct {
+					ID         string         `json:"id"`
+					Duplicates []SimilarVideo `json:"duplicates"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Data.ID == "" {
+				t.Error("Expected the uploaded video in the response")
+			}
+			if len(response.Data.Duplicates) != tt.wantDuplicates {
+				t.Errorf("Expected %d duplicates, got %v", tt.wantDuplicates, response.Data.Duplicates)
+			}
+			if mockStorage.fingerprints[response.Data.ID] == nil {
+				t.Error("Expected the fingerprint to be stored")
+			}
+		})
+	}
+}
+
+func TestHandleSimilar(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	cfg.DuplicateThreshold = 10
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	fingerprinted := 0
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		fingerprintFunc: func(ctx context.Context, input string, duration float64) ([]uint64, error) {
+			fingerprinted++
+			return []uint64{0x0, 0x0}, nil
+		},
+	})
+
+	for id, hashes := range map[string][]uint64{
+		"near":     {0x1, 0x0},
+		"nearer":   {0x0, 0x0},
+		"far":      {0xffff, 0xffff},
+		"distinct": {0xffffffffffffffff, 0xffffffffffffffff},
+	} {
+		mockStorage.SaveVideo(context.Background(), &storage.Video{ID: id, Filename: id + ".mp4", Duration: 10, Status: storage.StatusCompleted})
+		mockStorage.SaveFingerprint(context.Background(), &storage.Fingerprint{VideoID: id, Hashes: hashes})
+	}
+	mockStorage.SaveVideo(context.Background(), &storage.Video{ID: "test-video", Filename: "test.mp4", Duration: 10, Status: storage.StatusCompleted})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{"default threshold", "", http.StatusOK, []string{"nearer", "near"}},
+		{"wider", "?max_distance=20", http.StatusOK, []string{"nearer", "near", "far"}},
+		{"limited", "?max_distance=64&limit=1", http.StatusOK, []string{"nearer"}},
+		{"bad distance", "?max_distance=65", http.StatusBadRequest, nil},
+		{"bad limit", "?limit=0", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/videos/test-video/similar"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data []SimilarVideo `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			var got []string
+			for _, similar := range response.Data {
+				got = append(got, similar.VideoID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+
+	if fingerprinted != 1 {
+		t.Errorf("Expected the missing fingerprint to be computed once, got %d", fingerprinted)
+	}
+	if mockStorage.fingerprints["test-video"] == nil {
+		t.Error("Expected the computed fingerprint to be stored")
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// FrameRequest selects frames to extract. With Zip set the images are
+// returned together in a zip archive instead of being stored as artifacts.
+type FrameRequest struct {
+	video.FrameOptions
+	Zip bool `json:"zip"`
+}
+
+// FrameParams are recorded with every stored frame.
+type FrameParams struct {
+	Timestamp float64 `json:"timestamp"`
+	Format    string  `json:"format"`
+}
+
+func (h *VideoHandler) handleFrames(w http.ResponseWriter, r *http.Request, videoID, frameID string) {
+	switch {
+	case frameID == "" && r.Method == http.MethodPost:
+		h.handleExtractFrames(w, r, videoID)
+	case frameID == "" && r.Method == http.MethodGet:
+		h.handleListFrames(w, r, videoID)
+	case frameID != "" && r.Method == http.MethodGet:
+		h.handleGetFrame(w, r, videoID, frameID)
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *VideoHandler) handleExtractFrames(w http.ResponseWriter, r *http.Request, videoID string) {
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	req := FrameRequest{FrameOptions: video.FrameOptions{Format: video.FramePNG}}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	// Timestamps are checked against the exact duration of the video; the
+	// stored one is truncated to whole seconds.
+	sourcePath, info, err := h.probeVideo(r.Context(), source)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+
+	timestamps, err := req.Resolve(info.Duration)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Zip {
+		h.sendFrameArchive(w, r, source, sourcePath, timestamps, req.FrameOptions)
+		return
+	}
+
+	var frames []*storage.Artifact
+	for _, t := range timestamps {
+		frame, err := h.renderArtifact(r.Context(), source.ID, storage.ArtifactFrame, req.Ext(), req.ContentType(),
+			FrameParams{Timestamp: t, Format: req.Format},
+			func(path string) error {
+				return h.processor.ExtractFrame(r.Context(), sourcePath, path, t)
+			})
+		if err != nil {
+			h.deleteArtifacts(r.Context(), frames)
+			sendProcessingError(w, err, "failed to extract frames")
+			return
+		}
+		frames = append(frames, frame)
+	}
+
+	SendSuccess(w, http.StatusCreated, frames, "frames extracted successfully")
+}
+
+// sendFrameArchive extracts the frames into a temporary directory and streams
+// them back as a zip archive. Nothing is stored.
+func (h *VideoHandler) sendFrameArchive(w http.ResponseWriter, r *http.Request, source *storage.Video, sourcePath string,
+	timestamps []float64, opts video.FrameOptions) {
+
+	tmpDir, err := os.MkdirTemp(h.config.VideoStoragePath, "frames-")
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to extract frames")
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var names []string
+	for i, t := range timestamps {
+		name := fmt.Sprintf("frame_%03d_%s%s", i+1, strings.ReplaceAll(video.FormatVTTTimestamp(t), ":", "-"), opts.Ext())
+		if err := h.processor.ExtractFrame(r.Context(), sourcePath, filepath.Join(tmpDir, name), t); err != nil {
+			sendProcessingError(w, err, "failed to extract frames")
+			return
+		}
+		names = append(names, name)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_frames.zip"`, source.ID))
+
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		if err := addFileToZip(archive, filepath.Join(tmpDir, name), name); err != nil {
+			return
+		}
+	}
+	archive.Close()
+}
+
+func (h *VideoHandler) handleListFrames(w http.ResponseWriter, r *http.Request, videoID string) {
+	if h.loadVideo(w, r, videoID) == nil {
+		return
+	}
+
+	frames, err := h.artifacts.ListArtifacts(r.Context(), videoID, storage.ArtifactFrame)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list frames")
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, frames, "")
+}
+
+func (h *VideoHandler) handleGetFrame(w http.ResponseWriter, r *http.Request, videoID, frameID string) {
+	frame := findArtifact(w, r, h.artifacts, videoID, frameID, storage.ArtifactFrame)
+	if frame == nil {
+		return
+	}
+
+	serveArtifact(w, r, h.blobs, frame)
+}
+
+func addFileToZip(archive *zip.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, file)
+	return err
+}
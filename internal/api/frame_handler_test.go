@@ -0,0 +1,204 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleExtractFrames(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFrames int
+	}{
+		{"timestamps", `{"timestamps": [1.5, 3]}`, http.StatusCreated, 2},
+		{"interval jpeg", `{"interval": 2.5, "format": "jpeg"}`, http.StatusCreated, 4},
+		{"past duration", `{"timestamps": [12]}`, http.StatusBadRequest, 0},
+		{"no selection", `{}`, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/frames", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var response struct {
+				Data []*storage.Artifact `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Data) != tt.wantFrames {
+				t.Fatalf("Expected %d frames, got %d", tt.wantFrames, len(response.Data))
+			}
+
+			req = httptest.NewRequest(http.MethodGet, "/api/videos/test-video/frames/"+response.Data[0].ID, nil)
+			rr = httptest.NewRecorder()
+			handler.HandleVideoOperations(rr, req)
+			if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != response.Data[0].ContentType {
+				t.Errorf("Expected frame to be served as %s, got %v %s",
+					response.Data[0].ContentType, rr.Code, rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestHandleExtractFramesZip(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/frames",
+		bytes.NewBufferString(`{"timestamps": [0, 61.5], "zip": true}`))
+	rr := httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected out of range timestamp to be rejected, got %v", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/videos/test-video/frames",
+		bytes.NewBufferString(`{"timestamps": [0, 1.5], "zip": true}`))
+	rr = httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("Expected zip archive, got %v %s", rr.Code, rr.Body.String())
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 2 || names[1] != "frame_002_00-00-01.500.png" {
+		t.Errorf("Unexpected archive entries: %v", names)
+	}
+
+	frames, _ := mockStorage.ListArtifacts(context.Background(), "test-video", storage.ArtifactFrame)
+	if len(frames) != 0 {
+		t.Errorf("Expected zipped frames not to be stored, got %d", len(frames))
+	}
+}
+
+func TestHandleExtractFramesExactDuration(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			return &video.VideoInfo{Duration: 10.5, Format: "mp4", Size: 1024}, nil
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"within fractional second", `{"timestamps": [10.2]}`, http.StatusCreated},
+		{"past exact duration", `{"timestamps": [10.6]}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/frames", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleExtractFramesFailure(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	calls := 0
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		extractFrameFunc: func(ctx context.Context, input, output string, timestamp float64) error {
+			if calls++; calls == 2 {
+				return errors.New("decode error")
+			}
+			return (&MockProcessor{}).ExtractFrame(ctx, input, output, timestamp)
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/frames", bytes.NewBufferString(`{"timestamps": [1, 2, 3]}`))
+	rr := httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %v", rr.Code)
+	}
+	if frames, _ := mockStorage.ListArtifacts(context.Background(), "test-video", storage.ArtifactFrame); len(frames) != 0 {
+		t.Errorf("Expected partial frames to be removed, got %d", len(frames))
+	}
+}
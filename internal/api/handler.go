@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
 	"os"
@@ -17,16 +20,34 @@ import (
 )
 
 type VideoHandler struct {
-	config    config.Config
-	storage   storage.VideoStorage
-	processor video.Processor
+	config       config.Config
+	storage      storage.VideoStorage
+	artifacts    storage.ArtifactStorage
+	scenes       storage.SceneStorage
+	intervals    storage.IntervalStorage
+	fingerprints storage.FingerprintStorage
+	presets      storage.PresetStorage
+	operations   storage.OperationStorage
+	versions     storage.VersionStorage
+	shares       storage.ShareLinkStorage
+	blobs        storage.BlobStore
+	processor    video.Processor
 }
 
-func NewVideoHandler(cfg config.Config, store storage.VideoStorage) *VideoHandler {
+func NewVideoHandler(cfg config.Config, stores storage.Stores, processor video.Processor) *VideoHandler {
 	return &VideoHandler{
-		config:    cfg,
-		storage:   store,
-		processor: video.NewFFmpegProcessor(),
+		config:       cfg,
+		storage:      stores.Videos,
+		artifacts:    stores.Artifacts,
+		scenes:       stores.Scenes,
+		intervals:    stores.Intervals,
+		fingerprints: stores.Fingerprints,
+		presets:      stores.Presets,
+		operations:   stores.Operations,
+		versions:     stores.Versions,
+		shares:       stores.Shares,
+		blobs:        blobStore(cfg, stores),
+		processor:    processor,
 	}
 }
 
@@ -42,49 +63,39 @@ func (h *VideoHandler) HandleVideos(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *VideoHandler) handleUpload(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(h.config.MaxVideoSize); err != nil {
-		SendError(w, http.StatusBadRequest, "file too large")
-		return
-	}
-
-	file, header, err := r.FormFile("video")
-	if err != nil {
-		SendError(w, http.StatusBadRequest, "failed to get video file")
-		return
-	}
-	defer file.Close()
-
-	if !isValidVideoType(header.Filename) {
-		SendError(w, http.StatusBadRequest, "invalid video format")
-		return
-	}
-
 	id, err := generateID()
 	if err != nil {
 		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
 		return
 	}
 
-	filename := fmt.Sprintf("%s_%s", id, filepath.Base(header.Filename))
-	filepath := filepath.Join(h.config.VideoStoragePath, filename)
-
-	size, err := h.saveUploadedFile(file, filepath)
-	if err != nil {
-		SendError(w, http.StatusInternalServerError, "failed to save video")
+	filename, size, info := h.receiveUpload(w, r, id)
+	if info == nil {
 		return
 	}
+	filepath := filepath.Join(h.config.VideoStoragePath, filename)
 
-	info, err := h.processor.GetVideoInfo(r.Context(), filepath)
-	if err != nil {
+	// Processors that cannot fingerprint, such as native-probe, still store
+	// uploads; they are just not checked for near-duplicates.
+	hashes, err := h.processor.Fingerprint(r.Context(), filepath, info.Duration)
+	if err != nil && !errors.Is(err, video.ErrUnsupportedOperation) {
 		os.Remove(filepath)
-		SendError(w, http.StatusBadRequest, "invalid video file")
+		sendProcessingError(w, err, "failed to fingerprint video")
 		return
 	}
 
-	if info.Duration < float64(h.config.MinDuration) || info.Duration > float64(h.config.MaxDuration) {
+	var duplicates []SimilarVideo
+	if hashes != nil && (h.config.DuplicatePolicy == config.DuplicateWarn || h.config.DuplicatePolicy == config.DuplicateReject) {
+		duplicates, err = h.findSimilar(r.Context(), id, hashes, h.config.DuplicateThreshold)
+		if err != nil {
+			os.Remove(filepath)
+			SendError(w, http.StatusInternalServerError, "failed to compare fingerprints")
+			return
+		}
+	}
+	if len(duplicates) > 0 && h.config.DuplicatePolicy == config.DuplicateReject {
 		os.Remove(filepath)
-		SendError(w, http.StatusBadRequest, fmt.Sprintf("video duration must be between %d and %d seconds",
-			h.config.MinDuration, h.config.MaxDuration))
+		SendError(w, http.StatusConflict, fmt.Sprintf("video is a near-duplicate of %s", duplicates[0].VideoID))
 		return
 	}
 
@@ -96,13 +107,73 @@ func (h *VideoHandler) handleUpload(w http.ResponseWriter, r *http.Request) {
 		Status:   storage.StatusCompleted,
 	}
 
+	if err := h.storeBlob(r.Context(), filename); err != nil {
+		h.removeBlob(r.Context(), filename)
+		SendError(w, http.StatusInternalServerError, "failed to save video")
+		return
+	}
+
 	if err := h.storage.SaveVideo(r.Context(), video); err != nil {
-		os.Remove(filepath)
+		h.removeBlob(r.Context(), filename)
 		SendError(w, http.StatusInternalServerError, "failed to save video metadata")
 		return
 	}
 
-	SendSuccess(w, http.StatusCreated, video, "video uploaded successfully")
+	// A missing fingerprint is recomputed when similar videos are requested,
+	// so failing to store it does not fail the upload.
+	if hashes != nil {
+		h.fingerprints.SaveFingerprint(r.Context(), &storage.Fingerprint{VideoID: id, Hashes: hashes})
+	}
+
+	SendSuccess(w, http.StatusCreated, UploadResponse{Video: video, Duplicates: duplicates}, "video uploaded successfully")
+}
+
+// receiveUpload saves the "video" file of a multipart request to the working
+// directory as "{prefix}_{name}" and checks that it is a video within the configured
+// duration limits. If the upload is rejected it removes the file, writes the
+// error response and returns a nil info.
+func (h *VideoHandler) receiveUpload(w http.ResponseWriter, r *http.Request, prefix string) (filename string, size int64, info *video.VideoInfo) {
+	if err := r.ParseMultipartForm(h.config.MaxVideoSize); err != nil {
+		SendError(w, http.StatusBadRequest, "file too large")
+		return "", 0, nil
+	}
+
+	file, header, err := r.FormFile("video")
+	if err != nil {
+		SendError(w, http.StatusBadRequest, "failed to get video file")
+		return "", 0, nil
+	}
+	defer file.Close()
+
+	if !isValidVideoType(header.Filename) {
+		SendError(w, http.StatusBadRequest, "invalid video format")
+		return "", 0, nil
+	}
+
+	filename = fmt.Sprintf("%s_%s", prefix, filepath.Base(header.Filename))
+	path := filepath.Join(h.config.VideoStoragePath, filename)
+
+	size, err = h.saveUploadedFile(file, path)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to save video")
+		return "", 0, nil
+	}
+
+	info, err = h.processor.GetVideoInfo(r.Context(), path)
+	if err != nil {
+		os.Remove(path)
+		SendError(w, http.StatusBadRequest, "invalid video file")
+		return "", 0, nil
+	}
+
+	if info.Duration < float64(h.config.MinDuration) || info.Duration > float64(h.config.MaxDuration) {
+		os.Remove(path)
+		SendError(w, http.StatusBadRequest, fmt.Sprintf("video duration must be between %d and %d seconds",
+			h.config.MinDuration, h.config.MaxDuration))
+		return "", 0, nil
+	}
+
+	return filename, size, info
 }
 
 func (h *VideoHandler) handleList(w http.ResponseWriter, r *http.Request) {
@@ -115,9 +186,194 @@ func (h *VideoHandler) handleList(w http.ResponseWriter, r *http.Request) {
 	SendSuccess(w, http.StatusOK, videos, "")
 }
 
+// HandleVideoOperations dispatches requests of the form
+// /api/videos/{id}/{operation}[/{resource}] to the handler for that operation.
+func (h *VideoHandler) HandleVideoOperations(w http.ResponseWriter, r *http.Request) {
+	videoID, operation, resource := splitPath(strings.TrimPrefix(r.URL.Path, "/api/videos/"))
+	if videoID == "" {
+		SendError(w, http.StatusBadRequest, "video ID required")
+		return
+	}
+
+	if r = h.withEncoding(w, r); r == nil {
+		return
+	}
+
+	switch operation {
+	case "loudness":
+		h.handleLoudness(w, r, videoID)
+	case "loudnorm":
+		h.handleLoudnorm(w, r, videoID)
+	case "previews":
+		h.handlePreviews(w, r, videoID, resource)
+	case "storyboard":
+		h.handleCreateStoryboard(w, r, videoID)
+	case storyboardVTTName:
+		h.handleGetStoryboard(w, r, videoID, storage.ArtifactStoryboardVTT)
+	case storyboardSpriteName:
+		h.handleGetStoryboard(w, r, videoID, storage.ArtifactStoryboard)
+	case "scenes":
+		h.handleScenes(w, r, videoID)
+	case "intervals":
+		h.handleIntervals(w, r, videoID)
+	case "subtitles":
+		h.handleSubtitles(w, r, videoID, resource)
+	case "captions":
+		h.handleCaptions(w, r, videoID)
+	case "lineage":
+		h.handleLineage(w, r, videoID)
+	case "rerender":
+		h.handleRerender(w, r, videoID)
+	case "versions":
+		h.handleVersions(w, r, videoID, resource)
+	case "download":
+		h.handleDownload(w, r, videoID)
+	case "transform":
+		h.handleTransform(w, r, videoID)
+	case "speed":
+		h.handleSpeed(w, r, videoID)
+	case "reverse":
+		h.handleReverse(w, r, videoID)
+	case "loop":
+		h.handleLoop(w, r, videoID)
+	case "compose":
+		h.handleCompose(w, r, videoID)
+	case "frames":
+		h.handleFrames(w, r, videoID, resource)
+	case waveformImageName:
+		h.handleWaveformImage(w, r, videoID)
+	case waveformPeaksName:
+		h.handleWaveformPeaks(w, r, videoID)
+	case "quality":
+		h.handleQuality(w, r, videoID)
+	case "similar":
+		h.handleSimilar(w, r, videoID)
+	case "metadata":
+		h.handleMetadata(w, r, videoID)
+	case "split":
+		h.handleSplit(w, r, videoID)
+	case "enhance":
+		h.handleEnhance(w, r, videoID)
+	case "redact":
+		h.handleRedact(w, r, videoID)
+	default:
+		SendError(w, http.StatusNotFound, "unknown video operation")
+	}
+}
+
+// splitPath splits "{id}/{operation}/{resource}" into its parts, leaving
+// missing trailing parts empty.
+func splitPath(path string) (id, operation, resource string) {
+	parts := strings.SplitN(path, "/", 3)
+	id = parts[0]
+	if len(parts) > 1 {
+		operation = parts[1]
+	}
+	if len(parts) > 2 {
+		resource = parts[2]
+	}
+	return id, operation, resource
+}
+
+// loadVideo fetches a video by ID, writing the error response and returning
+// nil if it cannot be found.
+func (h *VideoHandler) loadVideo(w http.ResponseWriter, r *http.Request, videoID string) *storage.Video {
+	video, err := h.storage.GetVideo(r.Context(), videoID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video")
+		return nil
+	}
+	if video == nil {
+		SendError(w, http.StatusNotFound, "video not found")
+		return nil
+	}
+	return video
+}
+
+// probeVideo returns the path of a stored video's working copy and what the
+// processor reads from it. Unlike the stored duration, which is truncated to
+// whole seconds, info.Duration is the exact length of the file.
+func (h *VideoHandler) probeVideo(ctx context.Context, source *storage.Video) (string, *video.VideoInfo, error) {
+	path, err := h.localPath(ctx, source.Filename)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := h.processor.GetVideoInfo(ctx, path)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, info, nil
+}
+
+// newOutput reserves an ID and a storage path for a video produced by an
+// operation on an existing video.
+func (h *VideoHandler) newOutput(suffix, ext string) (id, filename, path string, err error) {
+	id, err = generateID()
+	if err != nil {
+		return "", "", "", err
+	}
+	filename = fmt.Sprintf("%s_%s%s", id, suffix, ext)
+	return id, filename, filepath.Join(h.config.VideoStoragePath, filename), nil
+}
+
+// saveDerivedVideo probes a rendered output, stores it in the blob store and
+// records it as a completed video. The output is removed if it cannot be
+// recorded.
+func (h *VideoHandler) saveDerivedVideo(ctx context.Context, id, filename string) (*storage.Video, error) {
+	return h.saveDerived(ctx, &storage.Video{ID: id, Filename: filename})
+}
+
+// saveDerived is saveDerivedVideo for outputs that carry more than an ID and
+// a filename; the size, duration and status of derived are filled in.
+func (h *VideoHandler) saveDerived(ctx context.Context, derived *storage.Video) (*storage.Video, error) {
+	path := filepath.Join(h.config.VideoStoragePath, derived.Filename)
+
+	info, err := h.processor.GetVideoInfo(ctx, path)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	derived.Size = info.Size
+	derived.Duration = int(info.Duration)
+	derived.Status = storage.StatusCompleted
+
+	if err := h.storeBlob(ctx, derived.Filename); err != nil {
+		h.removeBlob(ctx, derived.Filename)
+		return nil, err
+	}
+
+	if err := h.storage.SaveVideo(ctx, derived); err != nil {
+		h.removeBlob(ctx, derived.Filename)
+		return nil, err
+	}
+
+	return derived, nil
+}
+
+// discardVideos removes outputs that were saved by an operation which then
+// failed: their artifacts, records and blobs. Like deleteArtifacts it is
+// undoing a failure, so errors are ignored.
+func (h *VideoHandler) discardVideos(ctx context.Context, videos []*storage.Video) {
+	for _, derived := range videos {
+		for _, kind := range artifactKinds {
+			artifacts, _ := h.artifacts.ListArtifacts(ctx, derived.ID, kind)
+			h.deleteArtifacts(ctx, artifacts)
+		}
+		h.storage.DeleteVideo(ctx, derived.ID)
+		h.removeBlob(ctx, derived.Filename)
+	}
+}
+
+// TrimRequest selects the range to keep. With Auto set, Start and End are
+// ignored and the range is found by removing leading and trailing dead air,
+// where the video is both silent and black. Metadata, if given, is written to
+// the trimmed video.
 type TrimRequest struct {
-	Start float64 `json:"start"`
-	End   float64 `json:"end"`
+	Start    float64         `json:"start"`
+	End      float64         `json:"end"`
+	Auto     bool            `json:"auto,omitempty"`
+	Metadata *video.Metadata `json:"metadata,omitempty"`
 }
 
 func (h *VideoHandler) HandleTrim(w http.ResponseWriter, r *http.Request) {
@@ -132,6 +388,10 @@ func (h *VideoHandler) HandleTrim(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r = h.withEncoding(w, r); r == nil {
+		return
+	}
+
 	video, err := h.storage.GetVideo(r.Context(), videoID)
 	if err != nil {
 		SendError(w, http.StatusInternalServerError, "failed to get video")
@@ -147,8 +407,22 @@ func (h *VideoHandler) HandleTrim(w http.ResponseWriter, r *http.Request) {
 		SendError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if req.Metadata != nil {
+		if err := req.Metadata.Validate(); err != nil {
+			SendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
-	if req.Start < 0 || req.End > float64(video.Duration) || req.Start >= req.End {
+	// Automatic bounds are measured on the file itself, so they may end in
+	// the fraction of a second the stored duration leaves out.
+	if req.Auto {
+		start, end, ok := h.autoTrimBounds(w, r, video)
+		if !ok {
+			return
+		}
+		req.Start, req.End = start, end
+	} else if req.Start < 0 || req.End > float64(video.Duration) || req.Start >= req.End {
 		SendError(w, http.StatusBadRequest, "invalid trim parameters")
 		return
 	}
@@ -158,15 +432,32 @@ func (h *VideoHandler) HandleTrim(w http.ResponseWriter, r *http.Request) {
 	trimmedFilename := fmt.Sprintf("%s_trimmed%s", trimmedID, originalExt)
 	trimmedPath := filepath.Join(h.config.VideoStoragePath, trimmedFilename)
 
-	originalPath := filepath.Join(h.config.VideoStoragePath, video.Filename)
+	op := h.startOperation(r.Context(), storage.OperationTrim, []string{video.ID},
+		TrimRequest{Start: req.Start, End: req.End, Metadata: req.Metadata})
+
+	originalPath, err := h.localPath(r.Context(), video.Filename)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
 	if err := h.processor.Trim(r.Context(), originalPath, trimmedPath, req.Start, req.End); err != nil {
-		SendError(w, http.StatusInternalServerError, "failed to trim video")
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to trim video")
+		return
+	}
+
+	if err := h.applyMetadata(r.Context(), trimmedPath, req.Metadata); err != nil {
+		os.Remove(trimmedPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to set metadata")
 		return
 	}
 
 	info, err := h.processor.GetVideoInfo(r.Context(), trimmedPath)
 	if err != nil {
 		os.Remove(trimmedPath)
+		h.finishOperation(r.Context(), op, err)
 		SendError(w, http.StatusInternalServerError, "failed to process trimmed video")
 		return
 	}
@@ -179,17 +470,35 @@ func (h *VideoHandler) HandleTrim(w http.ResponseWriter, r *http.Request) {
 		Status:   storage.StatusCompleted,
 	}
 
+	if err := h.storeBlob(r.Context(), trimmedFilename); err != nil {
+		h.removeBlob(r.Context(), trimmedFilename)
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save trimmed video")
+		return
+	}
+
 	if err := h.storage.SaveVideo(r.Context(), trimmedVideo); err != nil {
-		os.Remove(trimmedPath)
+		h.removeBlob(r.Context(), trimmedFilename)
+		h.finishOperation(r.Context(), op, err)
 		SendError(w, http.StatusInternalServerError, "failed to save trimmed video metadata")
 		return
 	}
+	h.finishOperation(r.Context(), op, nil, trimmedVideo)
+
+	// The trimmed video is already saved, so failing here would only invite
+	// a retry that trims it again. Its subtitles can be uploaded separately.
+	if err := h.shiftSubtitles(r.Context(), video.ID, trimmedVideo.ID, req.Start, req.End); err != nil {
+		log.Printf("Failed to copy subtitles of %s to trimmed video %s: %v", video.ID, trimmedVideo.ID, err)
+	}
 
 	SendSuccess(w, http.StatusOK, trimmedVideo, "video trimmed successfully")
 }
 
 type MergeRequest struct {
-	VideoIDs []string `json:"video_ids"`
+	VideoIDs          []string        `json:"video_ids"`
+	NormalizeLoudness bool            `json:"normalize_loudness,omitempty"`
+	LoudnessTarget    *float64        `json:"loudness_target,omitempty"`
+	Metadata          *video.Metadata `json:"metadata,omitempty"`
 }
 
 func (h *VideoHandler) HandleMerge(w http.ResponseWriter, r *http.Request) {
@@ -198,6 +507,10 @@ func (h *VideoHandler) HandleMerge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r = h.withEncoding(w, r); r == nil {
+		return
+	}
+
 	var req MergeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		SendError(w, http.StatusBadRequest, "invalid request body")
@@ -208,6 +521,20 @@ func (h *VideoHandler) HandleMerge(w http.ResponseWriter, r *http.Request) {
 		SendError(w, http.StatusBadRequest, "at least two videos required for merging")
 		return
 	}
+	if req.Metadata != nil {
+		if err := req.Metadata.Validate(); err != nil {
+			SendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	target := h.loudnessTarget(req.LoudnessTarget, nil, nil)
+	if req.NormalizeLoudness {
+		if err := target.Validate(); err != nil {
+			SendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	var videoPaths []string
 	var totalDuration int
@@ -222,7 +549,12 @@ func (h *VideoHandler) HandleMerge(w http.ResponseWriter, r *http.Request) {
 			SendError(w, http.StatusNotFound, fmt.Sprintf("video %s not found", id))
 			return
 		}
-		videoPaths = append(videoPaths, filepath.Join(h.config.VideoStoragePath, video.Filename))
+		path, err := h.localPath(r.Context(), video.Filename)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to fetch video")
+			return
+		}
+		videoPaths = append(videoPaths, path)
 		totalDuration += video.Duration
 	}
 
@@ -230,14 +562,40 @@ func (h *VideoHandler) HandleMerge(w http.ResponseWriter, r *http.Request) {
 	mergedFilename := fmt.Sprintf("%s_merged.mp4", mergedID)
 	mergedPath := filepath.Join(h.config.VideoStoragePath, mergedFilename)
 
+	params := MergeRequest{VideoIDs: req.VideoIDs, NormalizeLoudness: req.NormalizeLoudness, Metadata: req.Metadata}
+	if req.NormalizeLoudness {
+		params.LoudnessTarget = &target.Integrated
+	}
+	op := h.startOperation(r.Context(), storage.OperationMerge, req.VideoIDs, params)
+
+	if req.NormalizeLoudness {
+		normalized, err := h.normalizeSegments(r.Context(), videoPaths, mergedID, target)
+		defer removeFiles(normalized)
+		if err != nil {
+			h.finishOperation(r.Context(), op, err)
+			sendProcessingError(w, err, "failed to normalize loudness")
+			return
+		}
+		videoPaths = normalized
+	}
+
 	if err := h.processor.Merge(r.Context(), videoPaths, mergedPath); err != nil {
-		SendError(w, http.StatusInternalServerError, "failed to merge videos")
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to merge videos")
+		return
+	}
+
+	if err := h.applyMetadata(r.Context(), mergedPath, req.Metadata); err != nil {
+		os.Remove(mergedPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to set metadata")
 		return
 	}
 
 	info, err := h.processor.GetVideoInfo(r.Context(), mergedPath)
 	if err != nil {
 		os.Remove(mergedPath)
+		h.finishOperation(r.Context(), op, err)
 		SendError(w, http.StatusInternalServerError, "failed to process merged video")
 		return
 	}
@@ -250,11 +608,20 @@ func (h *VideoHandler) HandleMerge(w http.ResponseWriter, r *http.Request) {
 		Status:   storage.StatusCompleted,
 	}
 
+	if err := h.storeBlob(r.Context(), mergedFilename); err != nil {
+		h.removeBlob(r.Context(), mergedFilename)
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save merged video")
+		return
+	}
+
 	if err := h.storage.SaveVideo(r.Context(), mergedVideo); err != nil {
-		os.Remove(mergedPath)
+		h.removeBlob(r.Context(), mergedFilename)
+		h.finishOperation(r.Context(), op, err)
 		SendError(w, http.StatusInternalServerError, "failed to save merged video metadata")
 		return
 	}
+	h.finishOperation(r.Context(), op, nil, mergedVideo)
 
 	SendSuccess(w, http.StatusOK, mergedVideo, "videos merged successfully")
 }
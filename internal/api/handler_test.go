@@ -4,19 +4,29 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 	"vidproc-go/internal/config"
 	"vidproc-go/internal/storage"
 	"vidproc-go/internal/video"
 )
 
 func (m *MockVideoStorage) SaveVideo(ctx context.Context, video *storage.Video) error {
+	video.Version = 1
 	m.videos[video.ID] = video
+	m.versions[video.ID] = []*storage.VideoVersion{{
+		VideoID:  video.ID,
+		Version:  1,
+		Filename: video.Filename,
+		Size:     video.Size,
+		Duration: video.Duration,
+	}}
 	return nil
 }
 
@@ -44,10 +54,69 @@ func (m *MockVideoStorage) UpdateVideoStatus(ctx context.Context, id string, sta
 	return nil
 }
 
+func (m *MockVideoStorage) UpdateVideoLoudness(ctx context.Context, id string, loudness *storage.Loudness) error {
+	if video, exists := m.videos[id]; exists {
+		video.Loudness = loudness
+	}
+	return nil
+}
+
+func (m *MockVideoStorage) UpdateVideoQuality(ctx context.Context, id string, quality *storage.Quality) error {
+	if video, exists := m.videos[id]; exists {
+		video.Quality = quality
+	}
+	return nil
+}
+
+func (m *MockVideoStorage) UpdateVideoRedaction(ctx context.Context, id, redactedID string) error {
+	if video, exists := m.videos[id]; exists {
+		video.RedactedID = redactedID
+	}
+	return nil
+}
+
+func (m *MockVideoStorage) UpdateVideoSupersededBy(ctx context.Context, id, supersededBy string) error {
+	if video, exists := m.videos[id]; exists {
+		video.SupersededBy = supersededBy
+	}
+	return nil
+}
+
+func (m *MockVideoStorage) DeleteVideo(ctx context.Context, id string) error {
+	delete(m.videos, id)
+	return nil
+}
+
 type MockProcessor struct {
-	getVideoInfoFunc func(ctx context.Context, filepath string) (*video.VideoInfo, error)
-	trimFunc         func(ctx context.Context, input, output string, start, end float64) error
-	mergeFunc        func(ctx context.Context, inputs []string, output string) error
+	getVideoInfoFunc      func(ctx context.Context, filepath string) (*video.VideoInfo, error)
+	trimFunc              func(ctx context.Context, input, output string, start, end float64) error
+	mergeFunc             func(ctx context.Context, inputs []string, output string) error
+	measureLoudnessFunc   func(ctx context.Context, input string, target video.LoudnessTarget) (*video.LoudnessStats, error)
+	normalizeLoudnessFunc func(ctx context.Context, input, output string, target video.LoudnessTarget, measured *video.LoudnessStats) error
+	renderAnimationFunc   func(ctx context.Context, input, output string, opts video.AnimationOptions) error
+	storyboardFunc        func(ctx context.Context, input, output string, duration float64, opts video.StoryboardOptions) error
+	detectScenesFunc      func(ctx context.Context, input string, threshold float64) ([]video.SceneCut, error)
+	detectDeadAirFunc     func(ctx context.Context, input string, duration float64, opts video.DeadAirOptions) (*video.DeadAir, error)
+	muxSubtitlesFunc      func(ctx context.Context, input, subtitles, output, language string) error
+	burnSubtitlesFunc     func(ctx context.Context, input, subtitles, output string) error
+	transformFunc         func(ctx context.Context, input, output string, opts video.TransformOptions) error
+	changeSpeedFunc       func(ctx context.Context, input, output string, factor float64) error
+	reverseFunc           func(ctx context.Context, input, output string) error
+	loopFunc              func(ctx context.Context, input, output string, opts video.LoopOptions) error
+	composeFunc           func(ctx context.Context, inputs []video.ComposeInput, output string, opts video.ComposeOptions) error
+	extractFrameFunc      func(ctx context.Context, input, output string, timestamp float64) error
+	renderWaveformFunc    func(ctx context.Context, input, output string, opts video.WaveformOptions) error
+	computePeaksFunc      func(ctx context.Context, input, scratch string, opts video.PeaksOptions) (*video.Peaks, error)
+	measureQualityFunc    func(ctx context.Context, input string, ref video.QualityReference) (*video.QualityStats, error)
+	fingerprintFunc       func(ctx context.Context, input string, duration float64) ([]uint64, error)
+	setMetadataFunc       func(ctx context.Context, input, output string, meta video.Metadata) error
+	splitFunc             func(ctx context.Context, input, outputDir string, segmentTime float64) ([]string, error)
+	enhanceFunc           func(ctx context.Context, input, output string, opts video.EnhanceOptions) error
+	redactFunc            func(ctx context.Context, input, output string, opts video.RedactOptions) error
+}
+
+func (m *MockProcessor) Version(ctx context.Context) (string, error) {
+	return "mock", nil
 }
 
 func (m *MockProcessor) GetVideoInfo(ctx context.Context, filepath string) (*video.VideoInfo, error) {
@@ -75,8 +144,172 @@ func (m *MockProcessor) Merge(ctx context.Context, inputs []string, output strin
 	return nil
 }
 
-func (h *VideoHandler) SetProcessor(p video.Processor) {
-	h.processor = p
+func (m *MockProcessor) MeasureLoudness(ctx context.Context, input string, target video.LoudnessTarget) (*video.LoudnessStats, error) {
+	if m.measureLoudnessFunc != nil {
+		return m.measureLoudnessFunc(ctx, input, target)
+	}
+	return &video.LoudnessStats{
+		Integrated:   -24,
+		TruePeak:     -3,
+		LRA:          8,
+		Threshold:    -34,
+		TargetOffset: 0.2,
+	}, nil
+}
+
+func (m *MockProcessor) NormalizeLoudness(ctx context.Context, input, output string, target video.LoudnessTarget, measured *video.LoudnessStats) error {
+	if m.normalizeLoudnessFunc != nil {
+		return m.normalizeLoudnessFunc(ctx, input, output, target, measured)
+	}
+	return nil
+}
+
+func (m *MockProcessor) RenderAnimation(ctx context.Context, input, output string, opts video.AnimationOptions) error {
+	if m.renderAnimationFunc != nil {
+		return m.renderAnimationFunc(ctx, input, output, opts)
+	}
+	return os.WriteFile(output, []byte("GIF89a"), 0644)
+}
+
+func (m *MockProcessor) GenerateStoryboard(ctx context.Context, input, output string, duration float64, opts video.StoryboardOptions) error {
+	if m.storyboardFunc != nil {
+		return m.storyboardFunc(ctx, input, output, duration, opts)
+	}
+	return os.WriteFile(output, []byte{0xFF, 0xD8, 0xFF}, 0644)
+}
+
+func (m *MockProcessor) DetectScenes(ctx context.Context, input string, threshold float64) ([]video.SceneCut, error) {
+	if m.detectScenesFunc != nil {
+		return m.detectScenesFunc(ctx, input, threshold)
+	}
+	return nil, nil
+}
+
+func (m *MockProcessor) DetectDeadAir(ctx context.Context, input string, duration float64, opts video.DeadAirOptions) (*video.DeadAir, error) {
+	if m.detectDeadAirFunc != nil {
+		return m.detectDeadAirFunc(ctx, input, duration, opts)
+	}
+	return &video.DeadAir{}, nil
+}
+
+func (m *MockProcessor) MuxSubtitles(ctx context.Context, input, subtitles, output, language string) error {
+	if m.muxSubtitlesFunc != nil {
+		return m.muxSubtitlesFunc(ctx, input, subtitles, output, language)
+	}
+	return nil
+}
+
+func (m *MockProcessor) BurnSubtitles(ctx context.Context, input, subtitles, output string) error {
+	if m.burnSubtitlesFunc != nil {
+		return m.burnSubtitlesFunc(ctx, input, subtitles, output)
+	}
+	return nil
+}
+
+func (m *MockProcessor) Transform(ctx context.Context, input, output string, opts video.TransformOptions) error {
+	if m.transformFunc != nil {
+		return m.transformFunc(ctx, input, output, opts)
+	}
+	return nil
+}
+
+func (m *MockProcessor) ChangeSpeed(ctx context.Context, input, output string, factor float64) error {
+	if m.changeSpeedFunc != nil {
+		return m.changeSpeedFunc(ctx, input, output, factor)
+	}
+	return nil
+}
+
+func (m *MockProcessor) Reverse(ctx context.Context, input, output string) error {
+	if m.reverseFunc != nil {
+		return m.reverseFunc(ctx, input, output)
+	}
+	return nil
+}
+
+func (m *MockProcessor) Loop(ctx context.Context, input, output string, opts video.LoopOptions) error {
+	if m.loopFunc != nil {
+		return m.loopFunc(ctx, input, output, opts)
+	}
+	return nil
+}
+
+func (m *MockProcessor) Compose(ctx context.Context, inputs []video.ComposeInput, output string, opts video.ComposeOptions) error {
+	if m.composeFunc != nil {
+		return m.composeFunc(ctx, inputs, output, opts)
+	}
+	return nil
+}
+
+func (m *MockProcessor) ExtractFrame(ctx context.Context, input, output string, timestamp float64) error {
+	if m.extractFrameFunc != nil {
+		return m.extractFrameFunc(ctx, input, output, timestamp)
+	}
+	return os.WriteFile(output, []byte("\x89PNG"), 0644)
+}
+
+func (m *MockProcessor) RenderWaveform(ctx context.Context, input, output string, opts video.WaveformOptions) error {
+	if m.renderWaveformFunc != nil {
+		return m.renderWaveformFunc(ctx, input, output, opts)
+	}
+	return os.WriteFile(output, []byte("\x89PNG"), 0644)
+}
+
+func (m *MockProcessor) ComputePeaks(ctx context.Context, input, scratch string, opts video.PeaksOptions) (*video.Peaks, error) {
+	if m.computePeaksFunc != nil {
+		return m.computePeaksFunc(ctx, input, scratch, opts)
+	}
+	return &video.Peaks{Version: 2, Channels: 1, SampleRate: video.PeaksSampleRate, SamplesPerPixel: opts.SamplesPerPixel, Bits: opts.Bits, Length: 1, Data: []int{-1, 1}}, nil
+}
+
+func (m *MockProcessor) MeasureQuality(ctx context.Context, input string, ref video.QualityReference) (*video.QualityStats, error) {
+	if m.measureQualityFunc != nil {
+		return m.measureQualityFunc(ctx, input, ref)
+	}
+	return &video.QualityStats{Frames: 250, PSNRAverage: 42.5, PSNRMin: 36.1, SSIMAverage: 0.985, SSIMMin: 0.962}, nil
+}
+
+func (m *MockProcessor) Fingerprint(ctx context.Context, input string, duration float64) ([]uint64, error) {
+	if m.fingerprintFunc != nil {
+		return m.fingerprintFunc(ctx, input, duration)
+	}
+	return []uint64{0x0f0f0f0f0f0f0f0f}, nil
+}
+
+func (m *MockProcessor) SetMetadata(ctx context.Context, input, output string, meta video.Metadata) error {
+	if m.setMetadataFunc != nil {
+		return m.setMetadataFunc(ctx, input, output, meta)
+	}
+	return os.WriteFile(output, []byte("remuxed"), 0644)
+}
+
+func (m *MockProcessor) Split(ctx context.Context, input, outputDir string, segmentTime float64) ([]string, error) {
+	if m.splitFunc != nil {
+		return m.splitFunc(ctx, input, outputDir, segmentTime)
+	}
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(outputDir, fmt.Sprintf("segment%03d%s", i, filepath.Ext(input)))
+		if err := os.WriteFile(path, []byte("segment"), 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (m *MockProcessor) Enhance(ctx context.Context, input, output string, opts video.EnhanceOptions) error {
+	if m.enhanceFunc != nil {
+		return m.enhanceFunc(ctx, input, output, opts)
+	}
+	return os.WriteFile(output, []byte("enhanced"), 0644)
+}
+
+func (m *MockProcessor) Redact(ctx context.Context, input, output string, opts video.RedactOptions) error {
+	if m.redactFunc != nil {
+		return m.redactFunc(ctx, input, output, opts)
+	}
+	return os.WriteFile(output, []byte("redacted"), 0644)
 }
 
 func setupTestEnvironment(t *testing.T) (config.Config, string, func()) {
@@ -91,6 +324,7 @@ func setupTestEnvironment(t *testing.T) (config.Config, string, func()) {
 		MaxVideoSize:     10 * 1024 * 1024,
 		MaxDuration:      30,
 		MinDuration:      1,
+		LoudnessTarget:   -16,
 	}
 
 	cleanup := func() {
@@ -120,8 +354,7 @@ func TestHandleUpload(t *testing.T) {
 		},
 	}
 
-	handler := NewVideoHandler(cfg, mockStorage)
-	handler.processor = mockProcessor
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), mockProcessor)
 
 	tests := []struct {
 		name         string
@@ -206,8 +439,7 @@ func TestHandleTrim(t *testing.T) {
 		},
 	}
 
-	handler := NewVideoHandler(cfg, mockStorage)
-	handler.SetProcessor(mockProcessor)
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), mockProcessor)
 
 	testVideoPath := filepath.Join(tmpDir, "test.mp4")
 	err = os.WriteFile(testVideoPath, []byte("dummy video content"), 0644)
@@ -303,6 +535,100 @@ func TestHandleTrim(t *testing.T) {
 	}
 }
 
+func TestProcessingQueueFull(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		trimFunc: func(ctx context.Context, input, output string, start, end float64) error {
+			return fmt.Errorf("failed to trim video: %w", &video.QueueFullError{RetryAfter: 2500 * time.Millisecond})
+		},
+		reverseFunc: func(ctx context.Context, input, output string) error {
+			return &video.QueueFullError{RetryAfter: 40 * time.Second}
+		},
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		serve          func(w http.ResponseWriter, r *http.Request)
+		wantRetryAfter string
+	}{
+		{"trim", "/api/videos/trim/test-video", `{"start": 1, "end": 4}`, handler.HandleTrim, "3"},
+		{"reverse", "/api/videos/test-video/reverse", "", handler.HandleVideoOperations, "40"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			tt.serve(rr, req)
+
+			if rr.Code != http.StatusServiceUnavailable {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, http.StatusServiceUnavailable, rr.Body.String())
+			}
+			if got := rr.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+// TestNativeProbeProcessor checks that a metadata-only backend still stores
+// uploads and reports the processing it cannot do as not implemented.
+func TestNativeProbeProcessor(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	cfg.DuplicatePolicy = config.DuplicateReject
+	cfg.DuplicateThreshold = video.MaxHashDistance
+
+	// Any fingerprint is within the threshold, so the upload is only
+	// accepted if it is not compared at all.
+	mockStorage := NewMockStorage()
+	mockStorage.SaveFingerprint(context.Background(), &storage.Fingerprint{VideoID: "existing", Hashes: []uint64{0}})
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), video.NewNativeProbeProcessor())
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("video", "clip.mp4")
+	part.Write(video.FakeMP4(video.VideoInfo{Duration: 10, Width: 640, Height: 360}))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/videos", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+	handler.HandleVideos(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Upload returned wrong status code: got %v want %v\nResponse body: %v",
+			rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var uploaded UploadResponse
+	json.NewDecoder(rr.Body).Decode(&Response{Data: &uploaded})
+	if uploaded.Video == nil || uploaded.Video.Duration != 10 {
+		t.Fatalf("Expected the probed video to be stored, got %+v", uploaded.Video)
+	}
+
+	reqBody, _ := json.Marshal(TrimRequest{Start: 1, End: 4})
+	req = httptest.NewRequest(http.MethodPost, "/api/videos/trim/"+uploaded.Video.ID, bytes.NewBuffer(reqBody))
+	rr = httptest.NewRecorder()
+	handler.HandleTrim(rr, req)
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("Trim returned wrong status code: got %v want %v\nResponse body: %v",
+			rr.Code, http.StatusNotImplemented, rr.Body.String())
+	}
+}
+
 func TestHandleMerge(t *testing.T) {
 	cfg, tmpDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -326,8 +652,7 @@ func TestHandleMerge(t *testing.T) {
 		},
 	}
 
-	handler := NewVideoHandler(cfg, mockStorage)
-	handler.SetProcessor(mockProcessor)
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), mockProcessor)
 
 	testVideos := []*storage.Video{
 		{
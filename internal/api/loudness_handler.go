@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// LoudnessRequest overrides the configured loudness target. Omitted fields
+// fall back to the configured integrated loudness and the EBU R128 defaults
+// for true peak and loudness range.
+type LoudnessRequest struct {
+	Target   *float64 `json:"target,omitempty"`
+	TruePeak *float64 `json:"true_peak,omitempty"`
+	LRA      *float64 `json:"lra,omitempty"`
+}
+
+func (h *VideoHandler) handleLoudness(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	target, ok := h.decodeLoudnessRequest(w, r)
+	if !ok {
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	if _, err := h.measureLoudness(w, r, source, sourcePath, target); err != nil {
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, source, "loudness measured successfully")
+}
+
+func (h *VideoHandler) handleLoudnorm(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	target, ok := h.decodeLoudnessRequest(w, r)
+	if !ok {
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	outputID, outputFilename, outputPath, err := h.newOutput("loudnorm", filepath.Ext(source.Filename))
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
+		return
+	}
+
+	op := h.startOperation(r.Context(), storage.OperationLoudnorm, []string{source.ID},
+		LoudnessRequest{Target: &target.Integrated, TruePeak: &target.TruePeak, LRA: &target.LRA})
+	stats, err := h.measureLoudness(w, r, source, sourcePath, target)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		return
+	}
+
+	if err := h.processor.NormalizeLoudness(r.Context(), sourcePath, outputPath, target, stats); err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to normalize loudness")
+		return
+	}
+
+	normalized, err := h.saveDerivedVideo(r.Context(), outputID, outputFilename)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save normalized video")
+		return
+	}
+	h.finishOperation(r.Context(), op, nil, normalized)
+
+	SendSuccess(w, http.StatusOK, normalized, "loudness normalized successfully")
+}
+
+// decodeLoudnessRequest reads an optional LoudnessRequest body and resolves
+// it against the configured target.
+func (h *VideoHandler) decodeLoudnessRequest(w http.ResponseWriter, r *http.Request) (video.LoudnessTarget, bool) {
+	var req LoudnessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return video.LoudnessTarget{}, false
+	}
+
+	target := h.loudnessTarget(req.Target, req.TruePeak, req.LRA)
+	if err := target.Validate(); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return target, false
+	}
+
+	return target, true
+}
+
+// measureLoudness runs the analysis pass on a stored video and records the
+// result on its row. If it fails it writes the error response and returns
+// the error, so that callers can record it.
+func (h *VideoHandler) measureLoudness(w http.ResponseWriter, r *http.Request, v *storage.Video, path string, target video.LoudnessTarget) (*video.LoudnessStats, error) {
+	stats, err := h.processor.MeasureLoudness(r.Context(), path, target)
+	if errors.Is(err, video.ErrSilentAudio) {
+		SendError(w, http.StatusUnprocessableEntity, "video has no audible audio")
+		return nil, err
+	}
+	if err != nil {
+		sendProcessingError(w, err, "failed to measure loudness")
+		return nil, err
+	}
+
+	loudness := &storage.Loudness{
+		Integrated: stats.Integrated,
+		TruePeak:   stats.TruePeak,
+		LRA:        stats.LRA,
+	}
+	if err := h.storage.UpdateVideoLoudness(r.Context(), v.ID, loudness); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to save loudness")
+		return nil, err
+	}
+	v.Loudness = loudness
+
+	return stats, nil
+}
+
+func (h *VideoHandler) loudnessTarget(integrated, truePeak, lra *float64) video.LoudnessTarget {
+	target := video.NewLoudnessTarget(h.config.LoudnessTarget)
+	if integrated != nil {
+		target.Integrated = *integrated
+	}
+	if truePeak != nil {
+		target.TruePeak = *truePeak
+	}
+	if lra != nil {
+		target.LRA = *lra
+	}
+	return target
+}
+
+// normalizeSegments renders a loudness-normalized copy of each input so that
+// merged segments play back at the same level. The returned paths must be
+// removed by the caller, including on error.
+func (h *VideoHandler) normalizeSegments(ctx context.Context, paths []string, prefix string, target video.LoudnessTarget) ([]string, error) {
+	var normalized []string
+	for i, path := range paths {
+		stats, err := h.processor.MeasureLoudness(ctx, path, target)
+		if err != nil && !errors.Is(err, video.ErrSilentAudio) {
+			return normalized, err
+		}
+
+		segmentPath := filepath.Join(h.config.VideoStoragePath,
+			fmt.Sprintf("%s_segment%d%s", prefix, i, filepath.Ext(path)))
+		normalized = append(normalized, segmentPath)
+
+		if err := h.processor.NormalizeLoudness(ctx, path, segmentPath, target, stats); err != nil {
+			return normalized, err
+		}
+	}
+	return normalized, nil
+}
+
+func removeFiles(paths []string) {
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleLoudnorm(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var gotTarget video.LoudnessTarget
+	mockProcessor := &MockProcessor{
+		normalizeLoudnessFunc: func(ctx context.Context, input, output string, target video.LoudnessTarget, measured *video.LoudnessStats) error {
+			gotTarget = target
+			if measured == nil {
+				t.Error("expected measured stats to be passed to the second pass")
+			}
+			return nil
+		},
+	}
+
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), mockProcessor)
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	broadcast := -23.0
+	tooLoud := 0.0
+
+	tests := []struct {
+		name       string
+		videoID    string
+		body       interface{}
+		wantStatus int
+		wantErrMsg string
+		wantTarget float64
+	}{
+		{
+			name:       "configured target",
+			videoID:    "test-video",
+			body:       LoudnessRequest{},
+			wantStatus: http.StatusOK,
+			wantTarget: -16,
+		},
+		{
+			name:       "broadcast target",
+			videoID:    "test-video",
+			body:       LoudnessRequest{Target: &broadcast},
+			wantStatus: http.StatusOK,
+			wantTarget: -23,
+		},
+		{
+			name:       "target out of range",
+			videoID:    "test-video",
+			body:       LoudnessRequest{Target: &tooLoud},
+			wantStatus: http.StatusBadRequest,
+			wantErrMsg: "invalid loudness target: integrated loudness must be between -70 and -5 LUFS",
+		},
+		{
+			name:       "video not found",
+			videoID:    "nonexistent",
+			body:       LoudnessRequest{},
+			wantStatus: http.StatusNotFound,
+			wantErrMsg: "video not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatalf("Failed to marshal request body: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/"+tt.videoID+"/loudnorm", bytes.NewBuffer(reqBody))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if status := rr.Code; status != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					status, tt.wantStatus, rr.Body.String())
+			}
+
+			var response Response
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if tt.wantErrMsg != "" && response.Error != tt.wantErrMsg {
+				t.Errorf("Handler returned wrong error message: got %v want %v",
+					response.Error, tt.wantErrMsg)
+			}
+			if tt.wantStatus == http.StatusOK && gotTarget.Integrated != tt.wantTarget {
+				t.Errorf("Expected target %v, got %v", tt.wantTarget, gotTarget.Integrated)
+			}
+		})
+	}
+
+	source, _ := mockStorage.GetVideo(context.Background(), "test-video")
+	if source.Loudness == nil || source.Loudness.Integrated != -24 {
+		t.Errorf("Expected source loudness to be recorded, got %+v", source.Loudness)
+	}
+}
+
+func TestHandleLoudnessSilent(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		measureLoudnessFunc: func(ctx context.Context, input string, target video.LoudnessTarget) (*video.LoudnessStats, error) {
+			return nil, video.ErrSilentAudio
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "silent-video",
+		Filename: "silent.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/silent-video/loudness", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleVideoOperations(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/videos/silent-video/loudnorm", nil)
+	rr = httptest.NewRecorder()
+
+	handler.HandleVideoOperations(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+	}
+	if len(mockStorage.operations) != 1 || mockStorage.operations[0].Type != storage.OperationLoudnorm || mockStorage.operations[0].Error == "" {
+		t.Errorf("Expected the failed loudnorm to be recorded, got %+v", mockStorage.operations)
+	}
+}
+
+func TestHandleMergeNormalized(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var normalized []string
+	var merged []string
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		normalizeLoudnessFunc: func(ctx context.Context, input, output string, target video.LoudnessTarget, measured *video.LoudnessStats) error {
+			normalized = append(normalized, input)
+			return nil
+		},
+		mergeFunc: func(ctx context.Context, inputs []string, output string) error {
+			merged = inputs
+			return nil
+		},
+	})
+
+	for _, id := range []string{"video1", "video2"} {
+		mockStorage.SaveVideo(context.Background(), &storage.Video{
+			ID:       id,
+			Filename: id + ".mp4",
+			Size:     1000,
+			Duration: 10,
+			Status:   storage.StatusCompleted,
+		})
+	}
+
+	reqBody, _ := json.Marshal(MergeRequest{
+		VideoIDs:          []string{"video1", "video2"},
+		NormalizeLoudness: true,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/merge", bytes.NewBuffer(reqBody))
+	rr := httptest.NewRecorder()
+
+	handler.HandleMerge(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+			rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if len(normalized) != 2 {
+		t.Errorf("Expected both segments to be normalized, got %d", len(normalized))
+	}
+	for i, path := range merged {
+		if path == normalized[i] {
+			t.Errorf("Expected merge input %d to be the normalized segment, got source %s", i, path)
+		}
+	}
+}
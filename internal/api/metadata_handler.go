@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// handleMetadata creates a copy of a video with new container metadata. The
+// streams are remuxed rather than re-encoded, so it is quick and lossless.
+func (h *VideoHandler) handleMetadata(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var meta video.Metadata
+	if err := json.NewDecoder(r.Body).Decode(&meta); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if meta.IsZero() {
+		SendError(w, http.StatusBadRequest, "no metadata to set")
+		return
+	}
+	if err := meta.Validate(); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	id, filename, outputPath, err := h.newOutput("metadata", filepath.Ext(source.Filename))
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	op := h.startOperation(r.Context(), storage.OperationMetadata, []string{source.ID}, meta)
+	if err := h.processor.SetMetadata(r.Context(), sourcePath, outputPath, meta); err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to set metadata")
+		return
+	}
+
+	tagged, err := h.saveDerivedVideo(r.Context(), id, filename)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save video")
+		return
+	}
+	h.finishOperation(r.Context(), op, nil, tagged)
+
+	SendSuccess(w, http.StatusOK, tagged, "metadata set successfully")
+}
+
+// applyMetadata rewrites a freshly rendered output in place with the given
+// metadata. It does nothing when there is no metadata to set.
+func (h *VideoHandler) applyMetadata(ctx context.Context, path string, meta *video.Metadata) error {
+	if meta.IsZero() {
+		return nil
+	}
+
+	tagged := filepath.Join(filepath.Dir(path), "tagged_"+filepath.Base(path))
+	if err := h.processor.SetMetadata(ctx, path, tagged, *meta); err != nil {
+		os.Remove(tagged)
+		return err
+	}
+	return os.Rename(tagged, path)
+}
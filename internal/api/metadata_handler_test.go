@@ -0,0 +1,121 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleMetadata(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantTitle  string
+	}{
+		{"title and tags", `{"title": "Launch", "creation_time": "2024-03-09T14:30:00Z", "tags": {"project": "apollo"}}`, http.StatusOK, "Launch"},
+		{"nothing to set", `{}`, http.StatusBadRequest, ""},
+		{"reserved tag", `{"tags": {"title": "Launch"}}`, http.StatusBadRequest, ""},
+		{"bad creation time", `{"creation_time": "yesterday"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := NewMockStorage()
+			var got *video.Metadata
+			handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+				setMetadataFunc: func(ctx context.Context, input, output string, meta video.Metadata) error {
+					got = &meta
+					return nil
+				},
+			})
+
+			mockStorage.SaveVideo(context.Background(), &storage.Video{
+				ID:       "test-video",
+				Filename: "test.mp4",
+				Size:     1000,
+				Duration: 10,
+				Status:   storage.StatusCompleted,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/metadata", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if got != nil {
+					t.Error("Expected no remux for an invalid request")
+				}
+				return
+			}
+			if got == nil || got.Title != tt.wantTitle || got.Tags["project"] != "apollo" || got.CreationTime == nil {
+				t.Errorf("Unexpected metadata %+v", got)
+			}
+			if len(mockStorage.videos) != 2 {
+				t.Errorf("Expected a new video to be saved, got %d videos", len(mockStorage.videos))
+			}
+		})
+	}
+}
+
+func TestTrimWithMetadata(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var trimmedPath, taggedInput string
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		trimFunc: func(ctx context.Context, input, output string, start, end float64) error {
+			trimmedPath = output
+			return os.WriteFile(output, []byte("trimmed"), 0644)
+		},
+		setMetadataFunc: func(ctx context.Context, input, output string, meta video.Metadata) error {
+			taggedInput = input
+			return os.WriteFile(output, []byte("tagged "+meta.Title), 0644)
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	body := `{"start": 1, "end": 4, "metadata": {"title": "Intro"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/trim/test-video", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+
+	handler.HandleTrim(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+			rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if taggedInput != trimmedPath {
+		t.Errorf("Expected metadata to be applied to %s, got %s", trimmedPath, taggedInput)
+	}
+
+	content, err := os.ReadFile(trimmedPath)
+	if err != nil || string(content) != "tagged Intro" {
+		t.Errorf("Expected the trimmed file to be replaced by the tagged one, got %q, %v", content, err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(tmpDir, "tagged_*")); len(matches) != 0 {
+		t.Errorf("Expected no leftover files, got %v", matches)
+	}
+}
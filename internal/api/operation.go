@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// Lineage is the part of the operation graph connected to a video. Ancestors
+// are the videos it was derived from and Descendants the videos derived from
+// it, nearest first. Videos holds those still stored, including the video
+// itself, and Operations every operation linking them, oldest first.
+type Lineage struct {
+	VideoID     string               `json:"video_id"`
+	Ancestors   []string             `json:"ancestors"`
+	Descendants []string             `json:"descendants"`
+	Videos      []*storage.Video     `json:"videos"`
+	Operations  []*storage.Operation `json:"operations"`
+}
+
+type encodingPresetKey struct{}
+
+func withEncodingPreset(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, encodingPresetKey{}, name)
+}
+
+func encodingPresetFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(encodingPresetKey{}).(string)
+	return name
+}
+
+// startOperation begins the record of a processing request that reads the
+// given videos. params must hold everything needed to run it again, with
+// defaults and presets already resolved.
+func (h *VideoHandler) startOperation(ctx context.Context, opType string, inputIDs []string, params interface{}) *storage.Operation {
+	id, _ := generateID()
+	op := &storage.Operation{
+		ID:             id,
+		Type:           opType,
+		EncodingPreset: encodingPresetFromContext(ctx),
+		InputIDs:       inputIDs,
+		OutputIDs:      []string{},
+		StartedAt:      time.Now(),
+	}
+	op.Params, _ = json.Marshal(params)
+	op.Encoding, _ = json.Marshal(video.EncodingFromContext(ctx))
+	return op
+}
+
+// finishOperation records the outcome of an operation: the videos it
+// produced, or the error it failed with. Like fingerprints, the record is
+// best effort, so a request that produced its outputs is not failed because
+// its history could not be saved. It is saved even if the client has gone.
+func (h *VideoHandler) finishOperation(ctx context.Context, op *storage.Operation, err error, outputs ...*storage.Video) {
+	ctx = context.WithoutCancel(ctx)
+
+	op.Duration = time.Since(op.StartedAt).Seconds()
+	if err != nil {
+		op.Error = err.Error()
+	}
+	for _, output := range outputs {
+		op.OutputIDs = append(op.OutputIDs, output.ID)
+	}
+	op.ProcessorVersion, _ = h.processor.Version(ctx)
+
+	h.operations.SaveOperation(ctx, op)
+}
+
+func (h *VideoHandler) handleLineage(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodGet {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	ops := make(map[string]*storage.Operation)
+	ancestors, err := h.traceLineage(r.Context(), source.ID, storage.RoleOutput, ops)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get lineage")
+		return
+	}
+	descendants, err := h.traceLineage(r.Context(), source.ID, storage.RoleInput, ops)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get lineage")
+		return
+	}
+
+	lineage := &Lineage{
+		VideoID:     source.ID,
+		Ancestors:   ancestors,
+		Descendants: descendants,
+		Videos:      []*storage.Video{source},
+		Operations:  make([]*storage.Operation, 0, len(ops)),
+	}
+
+	for _, id := range append(slices.Clone(ancestors), descendants...) {
+		v, err := h.storage.GetVideo(r.Context(), id)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to get video")
+			return
+		}
+		if v != nil {
+			lineage.Videos = append(lineage.Videos, v)
+		}
+	}
+
+	for _, op := range ops {
+		lineage.Operations = append(lineage.Operations, op)
+	}
+	slices.SortFunc(lineage.Operations, func(a, b *storage.Operation) int {
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	SendSuccess(w, http.StatusOK, lineage, "")
+}
+
+// traceLineage walks the operation graph breadth first from videoID. With
+// RoleOutput it follows the operations that produced each video back to
+// their inputs; with RoleInput it follows the operations that read each
+// video on to their outputs. The operations visited are added to ops, and
+// the videos reached are returned nearest first.
+func (h *VideoHandler) traceLineage(ctx context.Context, videoID string, role storage.OperationRole, ops map[string]*storage.Operation) ([]string, error) {
+	seen := map[string]bool{videoID: true}
+	queue := []string{videoID}
+	reached := []string{}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		found, err := h.operations.ListOperations(ctx, id, role)
+		if err != nil {
+			return nil, err
+		}
+		for _, op := range found {
+			ops[op.ID] = op
+			next := op.InputIDs
+			if role == storage.RoleInput {
+				next = op.OutputIDs
+			}
+			for _, nextID := range next {
+				if !seen[nextID] {
+					seen[nextID] = true
+					reached = append(reached, nextID)
+					queue = append(queue, nextID)
+				}
+			}
+		}
+	}
+	return reached, nil
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+	"vidproc-go/internal/storage"
+)
+
+func (m *MockVideoStorage) SaveOperation(ctx context.Context, op *storage.Operation) error {
+	m.operations = append(m.operations, op)
+	return nil
+}
+
+func (m *MockVideoStorage) GetOperation(ctx context.Context, id string) (*storage.Operation, error) {
+	for _, op := range m.operations {
+		if op.ID == id {
+			return op, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockVideoStorage) ListOperations(ctx context.Context, videoID string, role storage.OperationRole) ([]*storage.Operation, error) {
+	var ops []*storage.Operation
+	for _, op := range m.operations {
+		ids := op.InputIDs
+		if role == storage.RoleOutput {
+			ids = op.OutputIDs
+		}
+		if slices.Contains(ids, videoID) {
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+func TestOperationLineage(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	for _, id := range []string{"a", "b"} {
+		mockStorage.SaveVideo(context.Background(), &storage.Video{
+			ID:       id,
+			Filename: id + ".mp4",
+			Duration: 10,
+			Status:   storage.StatusCompleted,
+		})
+	}
+
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		trimFunc: func(ctx context.Context, input, output string, start, end float64) error {
+			return os.WriteFile(output, []byte("trimmed"), 0644)
+		},
+		reverseFunc: func(ctx context.Context, input, output string) error {
+			return errors.New("failed to reverse video: exit status 1, output: broken pipe")
+		},
+	})
+
+	post := func(path, body string, serve http.HandlerFunc) *storage.Video {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		serve(rr, req)
+
+		var resp struct {
+			Data *storage.Video `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	trimmed := post("/api/videos/trim/a", `{"start": 1, "end": 4}`, handler.HandleTrim)
+	if trimmed == nil {
+		t.Fatal("Expected the trim to succeed")
+	}
+	merged := post("/api/videos/merge", fmt.Sprintf(`{"video_ids": [%q, "b"]}`, trimmed.ID), handler.HandleMerge)
+	if merged == nil {
+		t.Fatal("Expected the merge to succeed")
+	}
+	if post("/api/videos/"+merged.ID+"/reverse", "", handler.HandleVideoOperations) != nil {
+		t.Fatal("Expected the reverse to fail")
+	}
+
+	t.Run("recorded operations", func(t *testing.T) {
+		if len(mockStorage.operations) != 3 {
+			t.Fatalf("Expected 3 operations, got %d", len(mockStorage.operations))
+		}
+
+		trim, merge, reverse := mockStorage.operations[0], mockStorage.operations[1], mockStorage.operations[2]
+		if trim.Type != storage.OperationTrim || !reflect.DeepEqual(trim.InputIDs, []string{"a"}) || !reflect.DeepEqual(trim.OutputIDs, []string{trimmed.ID}) {
+			t.Errorf("Unexpected trim operation %+v", trim)
+		}
+		if string(trim.Params) != `{"start":1,"end":4}` {
+			t.Errorf("Expected resolved trim params, got %s", trim.Params)
+		}
+		if string(trim.Encoding) != `{"video_codec":"libx264"}` {
+			t.Errorf("Expected the default encoding, got %s", trim.Encoding)
+		}
+		if trim.ProcessorVersion != "mock" || trim.Error != "" {
+			t.Errorf("Unexpected trim operation %+v", trim)
+		}
+		if merge.Type != storage.OperationMerge || !reflect.DeepEqual(merge.InputIDs, []string{trimmed.ID, "b"}) || !reflect.DeepEqual(merge.OutputIDs, []string{merged.ID}) {
+			t.Errorf("Unexpected merge operation %+v", merge)
+		}
+		if reverse.Type != storage.OperationReverse || len(reverse.OutputIDs) != 0 || reverse.Error == "" {
+			t.Errorf("Expected a failed reverse with its error output, got %+v", reverse)
+		}
+	})
+
+	t.Run("lineage", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/videos/"+trimmed.ID+"/lineage", nil)
+		rr := httptest.NewRecorder()
+		handler.HandleVideoOperations(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Lineage returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusOK, rr.Body.String())
+		}
+
+		var resp struct {
+			Data Lineage `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		lineage := resp.Data
+		if !reflect.DeepEqual(lineage.Ancestors, []string{"a"}) {
+			t.Errorf("Expected ancestors [a], got %v", lineage.Ancestors)
+		}
+		if !reflect.DeepEqual(lineage.Descendants, []string{merged.ID}) {
+			t.Errorf("Expected descendants [%s], got %v", merged.ID, lineage.Descendants)
+		}
+		if len(lineage.Videos) != 3 || len(lineage.Operations) != 3 {
+			t.Errorf("Expected 3 videos and 3 operations, got %d and %d", len(lineage.Videos), len(lineage.Operations))
+		}
+	})
+
+	t.Run("unknown video", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/videos/missing/lineage", nil)
+		rr := httptest.NewRecorder()
+		handler.HandleVideoOperations(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %v", rr.Code)
+		}
+	})
+}
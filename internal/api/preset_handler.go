@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"vidproc-go/internal/config"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+var presetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type PresetHandler struct {
+	config  config.Config
+	presets storage.PresetStorage
+}
+
+func NewPresetHandler(cfg config.Config, presets storage.PresetStorage) *PresetHandler {
+	if presets == nil {
+		panic("preset storage cannot be nil")
+	}
+	return &PresetHandler{
+		config:  cfg,
+		presets: presets,
+	}
+}
+
+// PresetRequest creates or replaces an encoding preset. Name is ignored when
+// replacing, since it is taken from the path.
+type PresetRequest struct {
+	Name string `json:"name"`
+	video.Encoding
+}
+
+func (h *PresetHandler) HandlePresets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handleCreatePreset(w, r)
+	case http.MethodGet:
+		h.handleListPresets(w, r)
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *PresetHandler) HandlePresetOperations(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/presets/")
+	if name == "" {
+		SendError(w, http.StatusBadRequest, "preset name required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.handleGetPreset(w, r, name)
+	case http.MethodPut:
+		h.handleUpdatePreset(w, r, name)
+	case http.MethodDelete:
+		h.handleDeletePreset(w, r, name)
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *PresetHandler) handleCreatePreset(w http.ResponseWriter, r *http.Request) {
+	var req PresetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !presetNamePattern.MatchString(req.Name) {
+		SendError(w, http.StatusBadRequest, "name must be 1 to 64 lowercase letters, digits, hyphens or underscores")
+		return
+	}
+	if err := req.Encoding.Validate(); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := h.presets.GetPreset(r.Context(), req.Name)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get preset")
+		return
+	}
+	if existing != nil {
+		SendError(w, http.StatusConflict, fmt.Sprintf("preset %s already exists", req.Name))
+		return
+	}
+
+	now := time.Now()
+	preset := newEncodingPreset(req.Name, req.Encoding, now, now)
+	if err := h.presets.SavePreset(r.Context(), preset); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to save preset")
+		return
+	}
+
+	SendSuccess(w, http.StatusCreated, preset, "preset created successfully")
+}
+
+func (h *PresetHandler) handleListPresets(w http.ResponseWriter, r *http.Request) {
+	presets, err := h.presets.ListPresets(r.Context())
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list presets")
+		return
+	}
+	if presets == nil {
+		presets = []*storage.EncodingPreset{}
+	}
+
+	SendSuccess(w, http.StatusOK, presets, "")
+}
+
+func (h *PresetHandler) handleGetPreset(w http.ResponseWriter, r *http.Request, name string) {
+	preset := h.loadPreset(w, r, name)
+	if preset == nil {
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, preset, "")
+}
+
+func (h *PresetHandler) handleUpdatePreset(w http.ResponseWriter, r *http.Request, name string) {
+	var req PresetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.Encoding.Validate(); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing := h.loadPreset(w, r, name)
+	if existing == nil {
+		return
+	}
+
+	preset := newEncodingPreset(name, req.Encoding, existing.CreatedAt, time.Now())
+	if err := h.presets.UpdatePreset(r.Context(), preset); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to update preset")
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, preset, "preset updated successfully")
+}
+
+func (h *PresetHandler) handleDeletePreset(w http.ResponseWriter, r *http.Request, name string) {
+	if name == h.config.DefaultEncodingPreset {
+		SendError(w, http.StatusConflict, "preset is the configured default")
+		return
+	}
+
+	if h.loadPreset(w, r, name) == nil {
+		return
+	}
+
+	if err := h.presets.DeletePreset(r.Context(), name); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to delete preset")
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, nil, "preset deleted successfully")
+}
+
+// loadPreset fetches a preset by name, writing a 404 or 500 response and
+// returning nil if it cannot be loaded.
+func (h *PresetHandler) loadPreset(w http.ResponseWriter, r *http.Request, name string) *storage.EncodingPreset {
+	preset, err := h.presets.GetPreset(r.Context(), name)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get preset")
+		return nil
+	}
+	if preset == nil {
+		SendError(w, http.StatusNotFound, "preset not found")
+		return nil
+	}
+	return preset
+}
+
+func newEncodingPreset(name string, enc video.Encoding, createdAt, updatedAt time.Time) *storage.EncodingPreset {
+	return &storage.EncodingPreset{
+		Name:         name,
+		VideoCodec:   enc.VideoCodec,
+		Preset:       enc.Preset,
+		CRF:          enc.CRF,
+		MaxBitrate:   enc.MaxBitrate,
+		AudioBitrate: enc.AudioBitrate,
+		PixelFormat:  enc.PixelFormat,
+		GOPSize:      enc.GOPSize,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+	}
+}
+
+func presetEncoding(preset *storage.EncodingPreset) video.Encoding {
+	return video.Encoding{
+		VideoCodec:   preset.VideoCodec,
+		Preset:       preset.Preset,
+		CRF:          preset.CRF,
+		MaxBitrate:   preset.MaxBitrate,
+		AudioBitrate: preset.AudioBitrate,
+		PixelFormat:  preset.PixelFormat,
+		GOPSize:      preset.GOPSize,
+	}
+}
+
+// CheckDefaultPreset reports an error if the configured default encoding
+// preset does not exist. It is checked once at startup; the default cannot be
+// deleted afterwards, so requests need not check it again.
+func CheckDefaultPreset(ctx context.Context, cfg config.Config, presets storage.PresetStorage) error {
+	if cfg.DefaultEncodingPreset == "" {
+		return nil
+	}
+	preset, err := presets.GetPreset(ctx, cfg.DefaultEncodingPreset)
+	if err != nil {
+		return fmt.Errorf("failed to get default encoding preset: %w", err)
+	}
+	if preset == nil {
+		return fmt.Errorf("default encoding preset %s does not exist: create it or unset DEFAULT_ENCODING_PRESET", cfg.DefaultEncodingPreset)
+	}
+	return nil
+}
+
+// withEncoding attaches the encoding a processing request should use to its
+// context: the preset named by the encoding_preset query parameter, else the
+// configured default preset, else video.DefaultEncoding. Only POST requests
+// process videos, so others are returned unchanged. It writes an error
+// response and returns nil if a requested preset cannot be loaded.
+func (h *VideoHandler) withEncoding(w http.ResponseWriter, r *http.Request) *http.Request {
+	if r.Method != http.MethodPost {
+		return r
+	}
+
+	name := r.URL.Query().Get("encoding_preset")
+	requested := name != ""
+	if !requested {
+		name = h.config.DefaultEncodingPreset
+	}
+	if name == "" {
+		return r
+	}
+
+	preset, err := h.presets.GetPreset(r.Context(), name)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get encoding preset")
+		return nil
+	}
+	if preset == nil {
+		if !requested {
+			// CheckDefaultPreset runs at startup, so this is only reached
+			// by handlers built without it.
+			return r
+		}
+		SendError(w, http.StatusBadRequest, fmt.Sprintf("unknown encoding preset %s", name))
+		return nil
+	}
+
+	ctx := video.WithEncoding(r.Context(), presetEncoding(preset))
+	return r.WithContext(withEncodingPreset(ctx, name))
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+	"time"
+	"vidproc-go/internal/config"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func (m *MockVideoStorage) SavePreset(ctx context.Context, preset *storage.EncodingPreset) error {
+	m.presets[preset.Name] = preset
+	return nil
+}
+
+func (m *MockVideoStorage) GetPreset(ctx context.Context, name string) (*storage.EncodingPreset, error) {
+	return m.presets[name], nil
+}
+
+func (m *MockVideoStorage) ListPresets(ctx context.Context) ([]*storage.EncodingPreset, error) {
+	var presets []*storage.EncodingPreset
+	for _, preset := range m.presets {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+func (m *MockVideoStorage) UpdatePreset(ctx context.Context, preset *storage.EncodingPreset) error {
+	m.presets[preset.Name] = preset
+	return nil
+}
+
+func (m *MockVideoStorage) DeletePreset(ctx context.Context, name string) error {
+	delete(m.presets, name)
+	return nil
+}
+
+func TestHandlePresets(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+	cfg.DefaultEncodingPreset = "web"
+
+	mockStorage := NewMockStorage()
+	handler := NewPresetHandler(cfg, mockStorage)
+
+	steps := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"create", http.MethodPost, "/api/presets", `{"name": "web", "video_codec": "libx264", "preset": "medium", "crf": 23, "max_bitrate": "4M"}`, http.StatusCreated},
+		{"create duplicate", http.MethodPost, "/api/presets", `{"name": "web", "video_codec": "libx264"}`, http.StatusConflict},
+		{"create archive", http.MethodPost, "/api/presets", `{"name": "archive", "video_codec": "libx265", "crf": 18, "pixel_format": "yuv420p10le"}`, http.StatusCreated},
+		{"invalid name", http.MethodPost, "/api/presets", `{"name": "Web Preset", "video_codec": "libx264"}`, http.StatusBadRequest},
+		{"invalid encoding", http.MethodPost, "/api/presets", `{"name": "fast", "video_codec": "libx264", "crf": 60}`, http.StatusBadRequest},
+		{"list", http.MethodGet, "/api/presets", "", http.StatusOK},
+		{"get", http.MethodGet, "/api/presets/web", "", http.StatusOK},
+		{"get missing", http.MethodGet, "/api/presets/missing", "", http.StatusNotFound},
+		{"update", http.MethodPut, "/api/presets/archive", `{"video_codec": "libsvtav1", "preset": "6", "crf": 30}`, http.StatusOK},
+		{"update invalid", http.MethodPut, "/api/presets/archive", `{"video_codec": "libsvtav1", "preset": "slow"}`, http.StatusBadRequest},
+		{"update missing", http.MethodPut, "/api/presets/missing", `{"video_codec": "libx264"}`, http.StatusNotFound},
+		{"delete default", http.MethodDelete, "/api/presets/web", "", http.StatusConflict},
+		{"delete", http.MethodDelete, "/api/presets/archive", "", http.StatusOK},
+		{"delete missing", http.MethodDelete, "/api/presets/archive", "", http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, bytes.NewBufferString(step.body))
+		rr := httptest.NewRecorder()
+
+		if step.path == "/api/presets" {
+			handler.HandlePresets(rr, req)
+		} else {
+			handler.HandlePresetOperations(rr, req)
+		}
+
+		if rr.Code != step.wantStatus {
+			t.Fatalf("%s: handler returned wrong status code: got %v want %v\nResponse body: %v",
+				step.name, rr.Code, step.wantStatus, rr.Body.String())
+		}
+
+		if step.name == "list" {
+			var response struct {
+				Data []storage.EncodingPreset `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Data) != 2 || response.Data[0].Name != "archive" || response.Data[1].Name != "web" {
+				t.Errorf("Expected both presets sorted by name, got %+v", response.Data)
+			}
+		}
+	}
+
+	if web := mockStorage.presets["web"]; web == nil || web.CRF == nil || *web.CRF != 23 || web.MaxBitrate != "4M" {
+		t.Errorf("Unexpected stored preset %+v", web)
+	}
+}
+
+func TestEncodingPresetOverride(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	crf := 20
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		defaultPreset string
+		query         string
+		wantStatus    int
+		wantEncoding  video.Encoding
+	}{
+		{"built-in default", "", "", http.StatusOK, video.DefaultEncoding},
+		{"configured default", "web", "", http.StatusOK, video.Encoding{VideoCodec: "libx264", Preset: "fast", CRF: &crf}},
+		{"request override", "web", "?encoding_preset=archive", http.StatusOK, video.Encoding{VideoCodec: "libx265", GOPSize: 250}},
+		{"unknown preset", "", "?encoding_preset=missing", http.StatusBadRequest, video.Encoding{}},
+		{"missing default", "gone", "", http.StatusOK, video.DefaultEncoding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cfg
+			cfg.DefaultEncodingPreset = tt.defaultPreset
+
+			mockStorage := NewMockStorage()
+			mockStorage.SavePreset(context.Background(), &storage.EncodingPreset{Name: "web", VideoCodec: "libx264", Preset: "fast", CRF: &crf, CreatedAt: now, UpdatedAt: now})
+			mockStorage.SavePreset(context.Background(), &storage.EncodingPreset{Name: "archive", VideoCodec: "libx265", GOPSize: 250, CreatedAt: now, UpdatedAt: now})
+			mockStorage.SaveVideo(context.Background(), &storage.Video{
+				ID:       "test-video",
+				Filename: "test.mp4",
+				Size:     1000,
+				Duration: 10,
+				Status:   storage.StatusCompleted,
+			})
+
+			var trimEncoding, reverseEncoding *video.Encoding
+			handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+				trimFunc: func(ctx context.Context, input, output string, start, end float64) error {
+					enc := video.EncodingFromContext(ctx)
+					trimEncoding = &enc
+					return os.WriteFile(output, []byte("trimmed"), 0644)
+				},
+				reverseFunc: func(ctx context.Context, input, output string) error {
+					enc := video.EncodingFromContext(ctx)
+					reverseEncoding = &enc
+					return os.WriteFile(output, []byte("reversed"), 0644)
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/trim/test-video"+tt.query, bytes.NewBufferString(`{"start": 1, "end": 4}`))
+			rr := httptest.NewRecorder()
+			handler.HandleTrim(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Trim returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+
+			req = httptest.NewRequest(http.MethodPost, "/api/videos/test-video/reverse"+tt.query, nil)
+			rr = httptest.NewRecorder()
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Reverse returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				if trimEncoding != nil || reverseEncoding != nil {
+					t.Error("Expected no processing when the preset cannot be loaded")
+				}
+				return
+			}
+			for _, got := range []*video.Encoding{trimEncoding, reverseEncoding} {
+				if got == nil {
+					t.Fatal("Expected the video to be processed")
+				}
+				gotArgs, wantArgs := got.Args(), tt.wantEncoding.Args()
+				if len(gotArgs) != len(wantArgs) {
+					t.Fatalf("Expected encoding %v, got %v", wantArgs, gotArgs)
+				}
+				for i := range gotArgs {
+					if gotArgs[i] != wantArgs[i] {
+						t.Errorf("Expected encoding %v, got %v", wantArgs, gotArgs)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDefaultPreset(t *testing.T) {
+	mockStorage := NewMockStorage()
+	mockStorage.SavePreset(context.Background(), &storage.EncodingPreset{Name: "web", VideoCodec: "libx264"})
+
+	for _, tt := range []struct {
+		name    string
+		wantErr bool
+	}{
+		{"", false},
+		{"web", false},
+		{"gone", true},
+	} {
+		err := CheckDefaultPreset(context.Background(), config.Config{DefaultEncodingPreset: tt.name}, mockStorage)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckDefaultPreset(%q) = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+const (
+	defaultPreviewDuration = 3.0
+	defaultPreviewFPS      = 12
+	defaultPreviewWidth    = 480
+)
+
+func (h *VideoHandler) handlePreviews(w http.ResponseWriter, r *http.Request, videoID, previewID string) {
+	switch {
+	case previewID != "" && r.Method == http.MethodGet:
+		h.handleGetPreview(w, r, videoID, previewID)
+	case previewID == "" && r.Method == http.MethodGet:
+		h.handleListPreviews(w, r, videoID)
+	case previewID == "" && r.Method == http.MethodPost:
+		h.handleCreatePreview(w, r, videoID)
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *VideoHandler) handleCreatePreview(w http.ResponseWriter, r *http.Request, videoID string) {
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	opts := video.AnimationOptions{
+		Format:   video.AnimationGIF,
+		Duration: math.Min(defaultPreviewDuration, float64(source.Duration)),
+		FPS:      defaultPreviewFPS,
+		Width:    defaultPreviewWidth,
+	}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := opts.Validate(float64(source.Duration)); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	preview, err := h.renderArtifact(r.Context(), source.ID, storage.ArtifactPreview, "."+opts.Format, opts.ContentType(), opts,
+		func(path string) error {
+			return h.processor.RenderAnimation(r.Context(), sourcePath, path, opts)
+		})
+	if err != nil {
+		sendProcessingError(w, err, "failed to render preview")
+		return
+	}
+
+	SendSuccess(w, http.StatusCreated, preview, "preview created successfully")
+}
+
+func (h *VideoHandler) handleListPreviews(w http.ResponseWriter, r *http.Request, videoID string) {
+	if h.loadVideo(w, r, videoID) == nil {
+		return
+	}
+
+	previews, err := h.artifacts.ListArtifacts(r.Context(), videoID, storage.ArtifactPreview)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list previews")
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, previews, "")
+}
+
+func (h *VideoHandler) handleGetPreview(w http.ResponseWriter, r *http.Request, videoID, previewID string) {
+	preview := findArtifact(w, r, h.artifacts, videoID, previewID, storage.ArtifactPreview)
+	if preview == nil {
+		return
+	}
+
+	serveArtifact(w, r, h.blobs, preview)
+}
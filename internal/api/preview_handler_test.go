@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func (m *MockVideoStorage) SaveArtifact(ctx context.Context, artifact *storage.Artifact) error {
+	m.artifacts[artifact.ID] = artifact
+	return nil
+}
+
+func (m *MockVideoStorage) GetArtifact(ctx context.Context, id string) (*storage.Artifact, error) {
+	artifact, exists := m.artifacts[id]
+	if !exists {
+		return nil, nil
+	}
+	return artifact, nil
+}
+
+func (m *MockVideoStorage) ListArtifacts(ctx context.Context, videoID string, kind storage.ArtifactKind) ([]*storage.Artifact, error) {
+	var artifacts []*storage.Artifact
+	for _, artifact := range m.artifacts {
+		if artifact.VideoID == videoID && artifact.Kind == kind {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].CreatedAt.After(artifacts[j].CreatedAt)
+	})
+	return artifacts, nil
+}
+
+func (m *MockVideoStorage) DeleteArtifact(ctx context.Context, id string) error {
+	delete(m.artifacts, id)
+	return nil
+}
+
+func TestHandleCreatePreview(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var gotOpts video.AnimationOptions
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		renderAnimationFunc: func(ctx context.Context, input, output string, opts video.AnimationOptions) error {
+			gotOpts = opts
+			return (&MockProcessor{}).RenderAnimation(ctx, input, output, opts)
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	tests := []struct {
+		name            string
+		videoID         string
+		body            string
+		wantStatus      int
+		wantErrMsg      string
+		wantContentType string
+	}{
+		{
+			name:            "defaults",
+			videoID:         "test-video",
+			body:            "",
+			wantStatus:      http.StatusCreated,
+			wantContentType: "image/gif",
+		},
+		{
+			name:            "webp with loop count",
+			videoID:         "test-video",
+			body:            `{"format":"webp","start":2,"duration":4,"fps":15,"width":320,"loop":2}`,
+			wantStatus:      http.StatusCreated,
+			wantContentType: "image/webp",
+		},
+		{
+			name:       "webp played once",
+			videoID:    "test-video",
+			body:       `{"format":"webp","loop":-1}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrMsg: "invalid animation options: loop must be between 0 and 65535 for webp",
+		},
+		{
+			name:       "range past end",
+			videoID:    "test-video",
+			body:       `{"start":9,"duration":3}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrMsg: "invalid animation options: time range must lie within the video",
+		},
+		{
+			name:       "video not found",
+			videoID:    "nonexistent",
+			body:       "",
+			wantStatus: http.StatusNotFound,
+			wantErrMsg: "video not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/"+tt.videoID+"/previews", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if status := rr.Code; status != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					status, tt.wantStatus, rr.Body.String())
+			}
+
+			var response struct {
+				Error string            `json:"error"`
+				Data  *storage.Artifact `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if tt.wantErrMsg != "" && response.Error != tt.wantErrMsg {
+				t.Errorf("Handler returned wrong error message: got %v want %v", response.Error, tt.wantErrMsg)
+			}
+			if tt.wantContentType != "" {
+				if response.Data.ContentType != tt.wantContentType {
+					t.Errorf("Expected content type %s, got %s", tt.wantContentType, response.Data.ContentType)
+				}
+				if response.Data.Size == 0 {
+					t.Error("Expected preview size to be recorded")
+				}
+			}
+		})
+	}
+
+	if gotOpts.Loop != 2 || gotOpts.Width != 320 {
+		t.Errorf("Expected request options to reach the processor, got %+v", gotOpts)
+	}
+
+	t.Run("serve preview", func(t *testing.T) {
+		previews, _ := mockStorage.ListArtifacts(context.Background(), "test-video", storage.ArtifactPreview)
+		if len(previews) == 0 {
+			t.Fatal("Expected previews to be stored")
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/videos/test-video/previews/"+previews[0].ID, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleVideoOperations(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != previews[0].ContentType {
+			t.Errorf("Expected content type %s, got %s", previews[0].ContentType, ct)
+		}
+	})
+
+	t.Run("preview of another video", func(t *testing.T) {
+		previews, _ := mockStorage.ListArtifacts(context.Background(), "test-video", storage.ArtifactPreview)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/videos/other-video/previews/"+previews[0].ID, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleVideoOperations(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"vidproc-go/internal/config"
+	"vidproc-go/internal/storage"
+)
+
+// PublicHandler serves the content reachable through a share link without
+// requiring the API token. Every request is scoped to the shared video.
+type PublicHandler struct {
+	config    config.Config
+	storage   storage.VideoStorage
+	share     storage.ShareLinkStorage
+	artifacts storage.ArtifactStorage
+	versions  storage.VersionStorage
+	blobs     storage.BlobStore
+}
+
+func NewPublicHandler(cfg config.Config, stores storage.Stores) *PublicHandler {
+	return &PublicHandler{
+		config:    cfg,
+		storage:   stores.Videos,
+		share:     stores.Shares,
+		artifacts: stores.Artifacts,
+		versions:  stores.Versions,
+		blobs:     blobStore(cfg, stores),
+	}
+}
+
+// HandlePublicShares serves /api/public/shares/{shareId}[/{resource}[/{id}]].
+// A share link follows the current version of its video unless it is pinned
+// to one.
+func (h *PublicHandler) HandlePublicShares(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	shareID, resource, resourceID := splitPath(strings.TrimPrefix(r.URL.Path, "/api/public/shares/"))
+	if shareID == "" {
+		SendError(w, http.StatusBadRequest, "share ID required")
+		return
+	}
+
+	shareLink, video := h.resolveShare(w, r, shareID)
+	if video == nil {
+		return
+	}
+	shared := sharedVersion(w, r, h.versions, shareLink, video)
+	if shared == nil {
+		return
+	}
+
+	// Previews and storyboards are rendered from the current version, so a
+	// link pinned to an earlier one does not serve them.
+	if resource != "" && resource != "video" && shared.Version != video.Version {
+		SendError(w, http.StatusNotFound, "not available for a pinned version")
+		return
+	}
+
+	switch {
+	case resource == "":
+		SendSuccess(w, http.StatusOK, struct {
+			ShareLink *storage.ShareLink `json:"share_link"`
+			Video     *storage.Video     `json:"video"`
+		}{shareLink, shared}, "")
+	case resource == "video" && resourceID == "":
+		serveVideo(w, r, h.blobs, shared)
+	case resource == "previews" && resourceID == "":
+		previews, err := h.artifacts.ListArtifacts(r.Context(), video.ID, storage.ArtifactPreview)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to list previews")
+			return
+		}
+		SendSuccess(w, http.StatusOK, previews, "")
+	case resource == "previews":
+		preview := findArtifact(w, r, h.artifacts, video.ID, resourceID, storage.ArtifactPreview)
+		if preview == nil {
+			return
+		}
+		serveArtifact(w, r, h.blobs, preview)
+	case resource == storyboardVTTName:
+		serveLatestArtifact(w, r, h.artifacts, h.blobs, video.ID, storage.ArtifactStoryboardVTT)
+	case resource == storyboardSpriteName:
+		serveLatestArtifact(w, r, h.artifacts, h.blobs, video.ID, storage.ArtifactStoryboard)
+	default:
+		SendError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// resolveShare loads a share link and its video, writing the error response
+// and returning a nil video if the link is unknown or has expired.
+func (h *PublicHandler) resolveShare(w http.ResponseWriter, r *http.Request, shareID string) (*storage.ShareLink, *storage.Video) {
+	shareLink, err := h.share.GetShareLink(r.Context(), shareID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get share link")
+		return nil, nil
+	}
+	if shareLink == nil {
+		SendError(w, http.StatusNotFound, "share link not found")
+		return nil, nil
+	}
+
+	if time.Now().After(shareLink.ExpiresAt) {
+		SendError(w, http.StatusGone, "share link has expired")
+		return nil, nil
+	}
+
+	video, err := h.storage.GetVideo(r.Context(), shareLink.VideoID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video")
+		return nil, nil
+	}
+	if video == nil {
+		SendError(w, http.StatusNotFound, "video not found")
+		return nil, nil
+	}
+
+	return shareLink, video
+}
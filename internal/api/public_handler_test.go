@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"vidproc-go/internal/storage"
+)
+
+func TestHandlePublicShares(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewPublicHandler(cfg, mockStorage.Stores())
+	ctx := context.Background()
+
+	mockStorage.SaveVideo(ctx, &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+	mockStorage.SaveVideo(ctx, &storage.Video{
+		ID:       "other-video",
+		Filename: "other.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	mockStorage.SaveShareLink(ctx, &storage.ShareLink{
+		ID:        "valid-share",
+		VideoID:   "test-video",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	})
+	mockStorage.SaveShareLink(ctx, &storage.ShareLink{
+		ID:        "expired-share",
+		VideoID:   "test-video",
+		ExpiresAt: time.Now().Add(-time.Hour),
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+	})
+
+	for _, artifact := range []*storage.Artifact{
+		{ID: "shared-preview", VideoID: "test-video", Kind: storage.ArtifactPreview, Filename: "shared.gif", ContentType: "image/gif"},
+		{ID: "private-preview", VideoID: "other-video", Kind: storage.ArtifactPreview, Filename: "private.gif", ContentType: "image/gif"},
+	} {
+		if err := os.WriteFile(filepath.Join(tmpDir, artifact.Filename), []byte("GIF89a"), 0644); err != nil {
+			t.Fatalf("Failed to write artifact file: %v", err)
+		}
+		mockStorage.SaveArtifact(ctx, artifact)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"share details", http.MethodGet, "/api/public/shares/valid-share", http.StatusOK},
+		{"list previews", http.MethodGet, "/api/public/shares/valid-share/previews", http.StatusOK},
+		{"serve preview", http.MethodGet, "/api/public/shares/valid-share/previews/shared-preview", http.StatusOK},
+		{"preview of another video", http.MethodGet, "/api/public/shares/valid-share/previews/private-preview", http.StatusNotFound},
+		{"expired share", http.MethodGet, "/api/public/shares/expired-share/previews/shared-preview", http.StatusGone},
+		{"unknown share", http.MethodGet, "/api/public/shares/nonexistent", http.StatusNotFound},
+		{"unknown resource", http.MethodGet, "/api/public/shares/valid-share/unknown", http.StatusNotFound},
+		{"write method", http.MethodPost, "/api/public/shares/valid-share", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rr := httptest.NewRecorder()
+
+			handler.HandlePublicShares(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
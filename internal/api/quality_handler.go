@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// qualityDurationTolerance allows for the container rounding of a derived
+// video's duration when checking that its segment lies within the source.
+const qualityDurationTolerance = 0.5
+
+// QualityRequest names the video a derived video was made from and where in
+// it the derived video starts, such as the start of a trim.
+type QualityRequest struct {
+	SourceID string  `json:"source_id"`
+	Start    float64 `json:"start"`
+}
+
+func (h *VideoHandler) handleQuality(w http.ResponseWriter, r *http.Request, videoID string) {
+	switch r.Method {
+	case http.MethodPost:
+		h.measureQuality(w, r, videoID)
+	case http.MethodGet:
+		derived := h.loadVideo(w, r, videoID)
+		if derived == nil {
+			return
+		}
+		if derived.Quality == nil {
+			SendError(w, http.StatusNotFound, "quality has not been measured")
+			return
+		}
+		SendSuccess(w, http.StatusOK, derived.Quality, "quality retrieved successfully")
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// measureQuality compares a derived video with the matching segment of its
+// source and records the PSNR and SSIM on the derived video's row.
+func (h *VideoHandler) measureQuality(w http.ResponseWriter, r *http.Request, videoID string) {
+	var req QualityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.SourceID == "" {
+		SendError(w, http.StatusBadRequest, "source_id is required")
+		return
+	}
+	if req.SourceID == videoID {
+		SendError(w, http.StatusBadRequest, "a video cannot be compared with itself")
+		return
+	}
+	if req.Start < 0 {
+		SendError(w, http.StatusBadRequest, "start must not be negative")
+		return
+	}
+
+	derived := h.loadVideo(w, r, videoID)
+	if derived == nil {
+		return
+	}
+	source := h.loadVideo(w, r, req.SourceID)
+	if source == nil {
+		return
+	}
+
+	derivedPath, err := h.localPath(r.Context(), derived.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	derivedInfo, err := h.processor.GetVideoInfo(r.Context(), derivedPath)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	sourceInfo, err := h.processor.GetVideoInfo(r.Context(), sourcePath)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+
+	if req.Start+derivedInfo.Duration > sourceInfo.Duration+qualityDurationTolerance {
+		SendError(w, http.StatusBadRequest, "the compared segment extends past the end of the source")
+		return
+	}
+
+	width, height := derivedInfo.DisplaySize()
+	stats, err := h.processor.MeasureQuality(r.Context(), derivedPath, video.QualityReference{
+		Path:     sourcePath,
+		Start:    req.Start,
+		Duration: derivedInfo.Duration,
+		Width:    width,
+		Height:   height,
+	})
+	if errors.Is(err, video.ErrNoFramesCompared) {
+		SendError(w, http.StatusUnprocessableEntity, "no frames could be compared")
+		return
+	}
+	if err != nil {
+		sendProcessingError(w, err, "failed to measure quality")
+		return
+	}
+
+	quality := &storage.Quality{
+		SourceID:    source.ID,
+		SourceStart: req.Start,
+		PSNRAverage: stats.PSNRAverage,
+		PSNRMin:     stats.PSNRMin,
+		SSIMAverage: stats.SSIMAverage,
+		SSIMMin:     stats.SSIMMin,
+	}
+	if err := h.storage.UpdateVideoQuality(r.Context(), derived.ID, quality); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to save quality")
+		return
+	}
+	derived.Quality = quality
+
+	SendSuccess(w, http.StatusOK, derived, "quality measured successfully")
+}
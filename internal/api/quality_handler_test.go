@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleQuality(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantStart  float64
+	}{
+		{"trimmed segment", `{"source_id": "source", "start": 12}`, http.StatusOK, 12},
+		{"from start", `{"source_id": "source"}`, http.StatusOK, 0},
+		{"past end of source", `{"source_id": "source", "start": 16}`, http.StatusBadRequest, 0},
+		{"negative start", `{"source_id": "source", "start": -1}`, http.StatusBadRequest, 0},
+		{"missing source", `{}`, http.StatusBadRequest, 0},
+		{"self", `{"source_id": "derived"}`, http.StatusBadRequest, 0},
+		{"unknown source", `{"source_id": "nope"}`, http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := NewMockStorage()
+			var gotRef video.QualityReference
+			handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+				getVideoInfoFunc: func(ctx context.Context, path string) (*video.VideoInfo, error) {
+					if filepath.Base(path) == "source.mp4" {
+						return &video.VideoInfo{Duration: 20, Width: 1920, Height: 1080}, nil
+					}
+					return &video.VideoInfo{Duration: 5, Width: 1280, Height: 720}, nil
+				},
+				measureQualityFunc: func(ctx context.Context, input string, ref video.QualityReference) (*video.QualityStats, error) {
+					gotRef = ref
+					return (&MockProcessor{}).MeasureQuality(ctx, input, ref)
+				},
+			})
+
+			for _, v := range []*storage.Video{
+				{ID: "source", Filename: "source.mp4", Size: 1000, Duration: 20, Status: storage.StatusCompleted},
+				{ID: "derived", Filename: "derived.mp4", Size: 500, Duration: 5, Status: storage.StatusCompleted},
+			} {
+				mockStorage.SaveVideo(context.Background(), v)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/derived/quality", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			want := video.QualityReference{Path: filepath.Join(cfg.VideoStoragePath, "source.mp4"), Start: tt.wantStart, Duration: 5, Width: 1280, Height: 720}
+			if gotRef != want {
+				t.Errorf("Expected reference %+v, got %+v", want, gotRef)
+			}
+
+			req = httptest.NewRequest(http.MethodGet, "/api/videos/derived/quality", nil)
+			rr = httptest.NewRecorder()
+			handler.HandleVideoOperations(rr, req)
+
+			var response struct {
+				Data storage.Quality `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Data.SourceID != "source" || response.Data.SourceStart != tt.wantStart || response.Data.PSNRMin != 36.1 {
+				t.Errorf("Unexpected stored quality %+v", response.Data)
+			}
+		})
+	}
+}
+
+func TestHandleQualityNotMeasured(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/videos/test-video/quality", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleVideoOperations(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
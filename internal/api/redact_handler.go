@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// handleRedact blurs or pixelates regions of a video. The redacted copy
+// replaces the source in share links created afterwards; existing links are
+// left alone.
+func (h *VideoHandler) handleRedact(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var opts video.RedactOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	info, err := h.processor.GetVideoInfo(r.Context(), sourcePath)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+
+	width, height := info.DisplaySize()
+	if err := opts.Validate(width, height, info.Duration); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, filename, outputPath, err := h.newOutput("redacted", filepath.Ext(source.Filename))
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
+		return
+	}
+
+	op := h.startOperation(r.Context(), storage.OperationRedact, []string{source.ID}, opts)
+	if err := h.processor.Redact(r.Context(), sourcePath, outputPath, opts); err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to redact video")
+		return
+	}
+
+	redacted, err := h.saveDerivedVideo(r.Context(), id, filename)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save redacted video")
+		return
+	}
+
+	// A redacted copy the source does not point at would never be shared,
+	// so it is discarded if the redaction cannot be recorded.
+	if err := h.storage.UpdateVideoRedaction(r.Context(), source.ID, redacted.ID); err != nil {
+		h.discardVideos(r.Context(), []*storage.Video{redacted})
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to record redaction")
+		return
+	}
+	h.finishOperation(r.Context(), op, nil, redacted)
+
+	SendSuccess(w, http.StatusOK, redacted, "video redacted successfully")
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleRedact(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantMode   string
+	}{
+		{"blur face", `{"regions": [{"x": 100, "y": 80, "width": 200, "height": 200, "start": 1, "end": 4}]}`, http.StatusOK, video.RedactBlur},
+		{"pixelate moving plate", `{"mode": "pixelate", "regions": [{"x": 0, "y": 900, "width": 300, "height": 100, "keyframes": [{"time": 5, "x": 1500, "y": 900}]}]}`, http.StatusOK, video.RedactPixelate},
+		{"no regions", `{"regions": []}`, http.StatusBadRequest, ""},
+		{"region outside picture", `{"regions": [{"x": 1800, "y": 0, "width": 200, "height": 200}]}`, http.StatusBadRequest, ""},
+		{"region past the end", `{"regions": [{"x": 0, "y": 0, "width": 20, "height": 20, "end": 12}]}`, http.StatusBadRequest, ""},
+		{"invalid body", `{`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := NewMockStorage()
+			var got *video.RedactOptions
+			handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+				getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+					return &video.VideoInfo{Duration: 10, Format: "mp4", Size: 1024, Width: 1920, Height: 1080}, nil
+				},
+				redactFunc: func(ctx context.Context, input, output string, opts video.RedactOptions) error {
+					got = &opts
+					return os.WriteFile(output, []byte("redacted"), 0644)
+				},
+			})
+
+			mockStorage.SaveVideo(context.Background(), &storage.Video{
+				ID:       "test-video",
+				Filename: "test.mp4",
+				Size:     1000,
+				Duration: 10,
+				Status:   storage.StatusCompleted,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/redact", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if got != nil || mockStorage.videos["test-video"].RedactedID != "" {
+					t.Error("Expected no redaction for an invalid request")
+				}
+				return
+			}
+
+			var response struct {
+				Data storage.Video `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if got == nil || got.Mode != tt.wantMode {
+				t.Errorf("Expected mode %q, got %+v", tt.wantMode, got)
+			}
+			if redactedID := mockStorage.videos["test-video"].RedactedID; redactedID != response.Data.ID {
+				t.Errorf("Expected the source to point at %s, got %q", response.Data.ID, redactedID)
+			}
+		})
+	}
+}
+
+func TestShareAfterRedaction(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	for _, v := range []*storage.Video{
+		{ID: "original", Filename: "original.mp4", Status: storage.StatusCompleted},
+		{ID: "redacted", Filename: "redacted.mp4", Status: storage.StatusCompleted},
+		{ID: "redacted-again", Filename: "redacted-again.mp4", Status: storage.StatusCompleted},
+	} {
+		mockStorage.SaveVideo(context.Background(), v)
+	}
+
+	create := func() *httptest.ResponseRecorder {
+		handler := NewShareHandler(cfg, mockStorage, mockStorage, mockStorage)
+		req := httptest.NewRequest(http.MethodPost, "/api/shares", bytes.NewBufferString(`{"video_id": "original", "duration": 24}`))
+		rr := httptest.NewRecorder()
+		handler.HandleShares(rr, req)
+		return rr
+	}
+	share := func() string {
+		t.Helper()
+		rr := create()
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+
+		var response struct {
+			Data storage.ShareLink `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Data.VideoID
+	}
+
+	if got := share(); got != "original" {
+		t.Errorf("Expected a link to the original before redaction, got %s", got)
+	}
+
+	mockStorage.UpdateVideoRedaction(context.Background(), "original", "redacted")
+	if got := share(); got != "redacted" {
+		t.Errorf("Expected a link to the redacted copy, got %s", got)
+	}
+
+	mockStorage.UpdateVideoRedaction(context.Background(), "redacted", "redacted-again")
+	if got := share(); got != "redacted-again" {
+		t.Errorf("Expected a link to the latest redaction, got %s", got)
+	}
+
+	// Sharing the original, or the first redaction, in place of a deleted
+	// redaction would leak what was redacted.
+	mockStorage.DeleteVideo(context.Background(), "redacted-again")
+	if rr := create(); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 once the redacted copy is deleted, got %v %s", rr.Code, rr.Body.String())
+	}
+}
+
+// failingRedactionStorage fails to record redactions.
+type failingRedactionStorage struct {
+	*MockVideoStorage
+}
+
+func (s failingRedactionStorage) UpdateVideoRedaction(ctx context.Context, id, redactedID string) error {
+	return errors.New("database is locked")
+}
+
+func TestRedactUnrecorded(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	mockStorage.SaveVideo(context.Background(), &storage.Video{ID: "test-video", Filename: "test.mp4", Duration: 10, Status: storage.StatusCompleted})
+	stores := mockStorage.Stores()
+	stores.Videos = failingRedactionStorage{mockStorage}
+	handler := NewVideoHandler(cfg, stores, &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			return &video.VideoInfo{Duration: 10, Format: "mp4", Size: 1024, Width: 1920, Height: 1080}, nil
+		},
+		redactFunc: func(ctx context.Context, input, output string, opts video.RedactOptions) error {
+			return os.WriteFile(output, []byte("redacted"), 0644)
+		},
+	})
+
+	body := `{"regions": [{"x": 100, "y": 80, "width": 200, "height": 200}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/redact", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusInternalServerError)
+	}
+
+	if len(mockStorage.videos) != 1 {
+		t.Errorf("Expected the redacted copy to be discarded, got %d videos", len(mockStorage.videos))
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 0 {
+		t.Errorf("Expected the redacted file to be removed, got %d files", len(entries))
+	}
+	op := mockStorage.operations[len(mockStorage.operations)-1]
+	if op.Error == "" || len(op.OutputIDs) != 0 {
+		t.Errorf("Expected the redaction to be recorded as failed, got %+v", op)
+	}
+}
@@ -0,0 +1,535 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+var (
+	errSourceMissing    = errors.New("source no longer exists")
+	errOutputsChanged   = errors.New("operation produced a different number of videos than recorded")
+	errUnknownOperation = errors.New("unknown operation type")
+)
+
+// RerenderResponse returns the rerendered video at its new version and the
+// video the replayed operations produced, whose file that version copies.
+// Superseded maps each intermediate video that was rendered again to the
+// video that replaces it.
+type RerenderResponse struct {
+	Video      *storage.Video    `json:"video"`
+	Rendering  *storage.Video    `json:"rendering"`
+	Superseded map[string]string `json:"superseded"`
+}
+
+// handleRerender renders a derived video again by replaying the recorded
+// operations that produced it, starting from the original sources, and adds
+// the result as a new version of the video, so that it keeps its ID and
+// share links following it serve the new rendering. Intermediate videos
+// rendered again are marked as superseded. It picks up changes to the
+// encoding presets the operations were recorded with and to the processor.
+func (h *VideoHandler) handleRerender(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	target := h.loadVideo(w, r, videoID)
+	if target == nil {
+		return
+	}
+
+	op, err := h.producingOperation(r.Context(), target.ID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get operations")
+		return
+	}
+	if op == nil {
+		SendError(w, http.StatusConflict, "video was not produced by a recorded operation")
+		return
+	}
+
+	state := &rerenderState{
+		requestedEncoding: r.URL.Query().Get("encoding_preset") != "",
+		replaced:          make(map[string]*storage.Video),
+	}
+	rerendered, err := h.rerenderVideo(r.Context(), target.ID, state)
+	if err != nil {
+		state.rollback(r.Context(), h)
+	}
+	switch {
+	case errors.Is(err, errSourceMissing), errors.Is(err, errOutputsChanged):
+		SendError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, errSegmentTooLarge):
+		SendError(w, http.StatusUnprocessableEntity, "keyframes are too far apart to split the video under max_bytes")
+		return
+	case errors.Is(err, errDerivedDuration):
+		SendError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	case err != nil:
+		sendProcessingError(w, err, "failed to rerender video")
+		return
+	}
+
+	// Nothing is changed until the whole chain has rendered, so a failure
+	// leaves the existing videos current. The new version is added last, as
+	// the one change that is not undone.
+	superseded := make(map[string]string)
+	for oldID, replacement := range state.replaced {
+		if oldID == replacement.ID || oldID == target.ID {
+			continue
+		}
+		if err := state.supersede(r.Context(), h, oldID, replacement.ID); err != nil {
+			state.rollback(r.Context(), h)
+			SendError(w, http.StatusInternalServerError, "failed to mark superseded video")
+			return
+		}
+		superseded[oldID] = replacement.ID
+	}
+
+	target, err = h.addRenderedVersion(r.Context(), target.ID, rerendered)
+	if err != nil {
+		state.rollback(r.Context(), h)
+		SendError(w, http.StatusInternalServerError, "failed to save video version")
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, RerenderResponse{Video: target, Rendering: rerendered, Superseded: superseded}, "video rerendered successfully")
+}
+
+// rerenderState tracks one rerender. replaced maps every video rendered
+// again to its replacement, and each original source to itself, so that a
+// video several operations read is only rendered once. created and undo
+// record the videos saved and the changes made to existing ones, which are
+// reverted if a later step fails.
+type rerenderState struct {
+	requestedEncoding bool
+	replaced          map[string]*storage.Video
+	created           []*storage.Video
+	undo              []func(ctx context.Context)
+}
+
+// rollback reverts a rerender that failed part way through, leaving the
+// videos as they were before it started.
+func (s *rerenderState) rollback(ctx context.Context, h *VideoHandler) {
+	ctx = context.WithoutCancel(ctx)
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		s.undo[i](ctx)
+	}
+	h.discardVideos(ctx, s.created)
+}
+
+// supersede marks oldID as superseded by newID, recording how to restore
+// what it was superseded by before.
+func (s *rerenderState) supersede(ctx context.Context, h *VideoHandler, oldID, newID string) error {
+	old, err := h.storage.GetVideo(ctx, oldID)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return fmt.Errorf("video %s not found", oldID)
+	}
+
+	previous := old.SupersededBy
+	if err := h.storage.UpdateVideoSupersededBy(ctx, oldID, newID); err != nil {
+		return err
+	}
+	s.undo = append(s.undo, func(ctx context.Context) {
+		h.storage.UpdateVideoSupersededBy(ctx, oldID, previous)
+	})
+	return nil
+}
+
+// rerenderVideo renders videoID again by replaying the operation that
+// produced it, after rendering again those of its inputs that were
+// themselves produced by operations. Videos with no recorded operation are
+// original sources and are used as they are.
+func (h *VideoHandler) rerenderVideo(ctx context.Context, videoID string, state *rerenderState) (*storage.Video, error) {
+	if replacement, ok := state.replaced[videoID]; ok {
+		return replacement, nil
+	}
+
+	op, err := h.producingOperation(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if op == nil {
+		source, err := h.storage.GetVideo(ctx, videoID)
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			return nil, fmt.Errorf("%w: video %s", errSourceMissing, videoID)
+		}
+		state.replaced[videoID] = source
+		return source, nil
+	}
+
+	inputs := make([]*storage.Video, 0, len(op.InputIDs))
+	for _, id := range op.InputIDs {
+		input, err := h.rerenderVideo(ctx, id, state)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+
+	ctx, err = h.replayEncoding(ctx, op, state.requestedEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	inputIDs := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		inputIDs = append(inputIDs, input.ID)
+	}
+	replay := h.startOperation(ctx, op.Type, inputIDs, op.Params)
+	outputs, err := h.replay(ctx, op, inputs, state)
+	if err == nil && len(outputs) != len(op.OutputIDs) {
+		err = fmt.Errorf("%w: %s gave %d, not %d", errOutputsChanged, op.Type, len(outputs), len(op.OutputIDs))
+	}
+	h.finishOperation(ctx, replay, err, outputs...)
+	state.created = append(state.created, outputs...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, id := range op.OutputIDs {
+		state.replaced[id] = outputs[i]
+	}
+	return state.replaced[videoID], nil
+}
+
+// producingOperation returns the latest successful operation that output
+// videoID, or nil if it was not produced by one.
+func (h *VideoHandler) producingOperation(ctx context.Context, videoID string) (*storage.Operation, error) {
+	ops, err := h.operations.ListOperations(ctx, videoID, storage.RoleOutput)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(ops) - 1; i >= 0; i-- {
+		if ops[i].Error == "" {
+			return ops[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// replayEncoding returns ctx with the encoding a replayed operation should
+// use. A preset named in the rerender request applies to every operation.
+// Otherwise each operation uses the current settings of the preset it was
+// recorded with, so that changes to the preset take effect, or the encoding
+// it was recorded with if it had no preset or the preset has been deleted.
+func (h *VideoHandler) replayEncoding(ctx context.Context, op *storage.Operation, requested bool) (context.Context, error) {
+	if requested {
+		return ctx, nil
+	}
+
+	if op.EncodingPreset != "" {
+		preset, err := h.presets.GetPreset(ctx, op.EncodingPreset)
+		if err != nil {
+			return nil, err
+		}
+		if preset != nil {
+			return withEncodingPreset(video.WithEncoding(ctx, presetEncoding(preset)), preset.Name), nil
+		}
+	}
+
+	enc := video.DefaultEncoding
+	if len(op.Encoding) > 0 {
+		if err := json.Unmarshal(op.Encoding, &enc); err != nil {
+			return nil, fmt.Errorf("invalid encoding for operation %s: %w", op.ID, err)
+		}
+	}
+	return withEncodingPreset(video.WithEncoding(ctx, enc), ""), nil
+}
+
+// replay runs a recorded operation again with its recorded parameters on
+// new inputs, saving and returning its outputs in the recorded order. The
+// parameters were validated when the operation was first requested, but the
+// inputs may have changed length since, so operations that set the length of
+// their output are checked against the duration limits again. Changes to
+// existing videos are recorded in state so that they can be undone.
+func (h *VideoHandler) replay(ctx context.Context, op *storage.Operation, inputs []*storage.Video, state *rerenderState) ([]*storage.Video, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: operation %s has no inputs", errSourceMissing, op.ID)
+	}
+
+	paths := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		path, err := h.localPath(ctx, input.Filename)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	source, sourcePath := inputs[0], paths[0]
+
+	switch op.Type {
+	case storage.OperationTrim:
+		var params TrimRequest
+		if err := decodeParams(op, &params); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "trimmed", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				if err := h.processor.Trim(ctx, sourcePath, outputPath, params.Start, params.End); err != nil {
+					return err
+				}
+				return h.applyMetadata(ctx, outputPath, params.Metadata)
+			},
+			func(trimmed *storage.Video) error {
+				return h.shiftSubtitles(ctx, source.ID, trimmed.ID, params.Start, params.End)
+			})
+
+	case storage.OperationMerge:
+		var params MergeRequest
+		if err := decodeParams(op, &params); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "merged", ".mp4", nil,
+			func(outputPath string) error {
+				if params.NormalizeLoudness {
+					prefix := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+					normalized, err := h.normalizeSegments(ctx, paths, prefix, h.loudnessTarget(params.LoudnessTarget, nil, nil))
+					defer removeFiles(normalized)
+					if err != nil {
+						return err
+					}
+					paths = normalized
+				}
+				if err := h.processor.Merge(ctx, paths, outputPath); err != nil {
+					return err
+				}
+				return h.applyMetadata(ctx, outputPath, params.Metadata)
+			}, nil)
+
+	case storage.OperationLoudnorm:
+		var params LoudnessRequest
+		if err := decodeParams(op, &params); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "loudnorm", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				target := h.loudnessTarget(params.Target, params.TruePeak, params.LRA)
+				stats, err := h.processor.MeasureLoudness(ctx, sourcePath, target)
+				if err != nil {
+					return err
+				}
+				return h.processor.NormalizeLoudness(ctx, sourcePath, outputPath, target, stats)
+			}, nil)
+
+	case storage.OperationTransform:
+		var opts video.TransformOptions
+		if err := decodeParams(op, &opts); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "transformed", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				return h.processor.Transform(ctx, sourcePath, outputPath, opts)
+			}, nil)
+
+	case storage.OperationSpeed:
+		var params SpeedRequest
+		if err := decodeParams(op, &params); err != nil {
+			return nil, err
+		}
+		if err := h.checkReplayDuration(ctx, sourcePath, func(d float64) float64 { return d / params.Factor }); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "speed", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				return h.processor.ChangeSpeed(ctx, sourcePath, outputPath, params.Factor)
+			}, nil)
+
+	case storage.OperationReverse:
+		var params struct{}
+		if err := decodeParams(op, &params); err != nil {
+			return nil, err
+		}
+		if err := h.checkReplayDuration(ctx, sourcePath, func(d float64) float64 { return d }); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "reversed", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				return h.processor.Reverse(ctx, sourcePath, outputPath)
+			}, nil)
+
+	case storage.OperationLoop:
+		var opts video.LoopOptions
+		if err := decodeParams(op, &opts); err != nil {
+			return nil, err
+		}
+		if err := h.checkReplayDuration(ctx, sourcePath, opts.OutputDuration); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "looped", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				return h.processor.Loop(ctx, sourcePath, outputPath, opts)
+			}, nil)
+
+	case storage.OperationCompose:
+		var opts video.ComposeOptions
+		if err := decodeParams(op, &opts); err != nil {
+			return nil, err
+		}
+		var composeInputs []video.ComposeInput
+		for _, input := range inputs {
+			composeInput, err := h.composeInput(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			composeInputs = append(composeInputs, composeInput)
+		}
+		if err := h.derivedDurationError(opts.OutputDuration(composeInputs)); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "composed", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				return h.processor.Compose(ctx, composeInputs, outputPath, opts)
+			}, nil)
+
+	case storage.OperationCaptions:
+		var params CaptionRequest
+		if err := decodeParams(op, &params); err != nil {
+			return nil, err
+		}
+		track, err := h.artifacts.GetArtifact(ctx, params.SubtitleID)
+		if err != nil {
+			return nil, err
+		}
+		if track == nil {
+			return nil, fmt.Errorf("%w: subtitles %s", errSourceMissing, params.SubtitleID)
+		}
+		ext, err := captionContainer(params.Container, source.Filename)
+		if err != nil {
+			return nil, err
+		}
+		trackPath, err := h.localPath(ctx, track.Filename)
+		if err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "captioned", ext, nil,
+			func(outputPath string) error {
+				if params.Burn {
+					return h.processor.BurnSubtitles(ctx, sourcePath, trackPath, outputPath)
+				}
+				return h.processor.MuxSubtitles(ctx, sourcePath, trackPath, outputPath, subtitleLanguage(track))
+			}, nil)
+
+	case storage.OperationMetadata:
+		var meta video.Metadata
+		if err := decodeParams(op, &meta); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "metadata", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				return h.processor.SetMetadata(ctx, sourcePath, outputPath, meta)
+			}, nil)
+
+	case storage.OperationEnhance:
+		var opts video.EnhanceOptions
+		if err := decodeParams(op, &opts); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "enhanced", filepath.Ext(source.Filename), opts.Filters(),
+			func(outputPath string) error {
+				return h.processor.Enhance(ctx, sourcePath, outputPath, opts)
+			}, nil)
+
+	case storage.OperationRedact:
+		var opts video.RedactOptions
+		if err := decodeParams(op, &opts); err != nil {
+			return nil, err
+		}
+		return h.replayOne(ctx, "redacted", filepath.Ext(source.Filename), nil,
+			func(outputPath string) error {
+				return h.processor.Redact(ctx, sourcePath, outputPath, opts)
+			},
+			func(redacted *storage.Video) error {
+				previous := source.RedactedID
+				if err := h.storage.UpdateVideoRedaction(ctx, source.ID, redacted.ID); err != nil {
+					return err
+				}
+				state.undo = append(state.undo, func(ctx context.Context) {
+					h.storage.UpdateVideoRedaction(ctx, source.ID, previous)
+				})
+				return nil
+			})
+
+	case storage.OperationSplit:
+		var params splitParams
+		if err := decodeParams(op, &params); err != nil {
+			return nil, err
+		}
+		return h.splitVideo(ctx, source, sourcePath, params.SegmentTime, params.MaxBytes)
+
+	case storage.OperationSceneSplit:
+		var params sceneSplitParams
+		if err := decodeParams(op, &params); err != nil {
+			return nil, err
+		}
+		return h.splitScenes(ctx, source, sourcePath, params.Scenes)
+	}
+
+	return nil, fmt.Errorf("%w %q", errUnknownOperation, op.Type)
+}
+
+// checkReplayDuration checks the length a replayed operation will give its
+// output, computed from the exact duration of its input, against the limits
+// on derived videos.
+func (h *VideoHandler) checkReplayDuration(ctx context.Context, inputPath string, outputDuration func(inputDuration float64) float64) error {
+	info, err := h.processor.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+	return h.derivedDurationError(outputDuration(info.Duration))
+}
+
+// replayOne replays an operation that writes a single video, recording the
+// output with the given filters. after, if set, runs once the output has
+// been saved; if it fails the output is discarded.
+func (h *VideoHandler) replayOne(ctx context.Context, suffix, ext string, filters []string,
+	render func(outputPath string) error, after func(output *storage.Video) error) ([]*storage.Video, error) {
+
+	id, filename, outputPath, err := h.newOutput(suffix, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := render(outputPath); err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+
+	output, err := h.saveDerived(ctx, &storage.Video{ID: id, Filename: filename, Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+
+	if after != nil {
+		if err := after(output); err != nil {
+			h.discardVideos(context.WithoutCancel(ctx), []*storage.Video{output})
+			return nil, err
+		}
+	}
+	return []*storage.Video{output}, nil
+}
+
+func decodeParams(op *storage.Operation, params interface{}) error {
+	if len(op.Params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(op.Params, params); err != nil {
+		return fmt.Errorf("invalid parameters for operation %s: %w", op.ID, err)
+	}
+	return nil
+}
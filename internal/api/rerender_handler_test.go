@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleRerender(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	crf, newCRF := 23, 30
+	now := time.Now()
+
+	mockStorage := NewMockStorage()
+	mockStorage.SavePreset(context.Background(), &storage.EncodingPreset{Name: "web", VideoCodec: "libx264", CRF: &crf, CreatedAt: now, UpdatedAt: now})
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "source",
+		Filename: "source.mp4",
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	var trims []video.Encoding
+	var failReverse bool
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		trimFunc: func(ctx context.Context, input, output string, start, end float64) error {
+			trims = append(trims, video.EncodingFromContext(ctx))
+			return os.WriteFile(output, []byte("trimmed"), 0644)
+		},
+		reverseFunc: func(ctx context.Context, input, output string) error {
+			if failReverse {
+				return errors.New("failed to reverse video: exit status 1")
+			}
+			return os.WriteFile(output, []byte("reversed"), 0644)
+		},
+	})
+
+	post := func(path, body string, serve http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		serve(rr, req)
+		return rr
+	}
+	decode := func(rr *httptest.ResponseRecorder, data interface{}) {
+		t.Helper()
+		resp := Response{Data: data}
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+
+	var trimmed, reversed storage.Video
+	decode(post("/api/videos/trim/source?encoding_preset=web", `{"start": 1, "end": 4}`, handler.HandleTrim), &trimmed)
+	decode(post("/api/videos/"+trimmed.ID+"/reverse", "", handler.HandleVideoOperations), &reversed)
+	if trimmed.ID == "" || reversed.ID == "" {
+		t.Fatal("Expected the trim and reverse to succeed")
+	}
+
+	mockStorage.UpdatePreset(context.Background(), &storage.EncodingPreset{Name: "web", VideoCodec: "libx264", CRF: &newCRF, CreatedAt: now, UpdatedAt: now})
+
+	t.Run("source video", func(t *testing.T) {
+		rr := post("/api/videos/source/rerender", "", handler.HandleVideoOperations)
+		if rr.Code != http.StatusConflict {
+			t.Errorf("Expected 409 for a video with no recorded operation, got %v", rr.Code)
+		}
+	})
+
+	// The trim is replayed before the reverse fails, so its output has to be
+	// discarded.
+	t.Run("failed replay", func(t *testing.T) {
+		failReverse = true
+		defer func() { failReverse = false }()
+
+		videos := len(mockStorage.videos)
+		files, _ := os.ReadDir(tmpDir)
+
+		rr := post("/api/videos/"+reversed.ID+"/rerender", "", handler.HandleVideoOperations)
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("Expected 500, got %v", rr.Code)
+		}
+		if got := mockStorage.videos[reversed.ID].SupersededBy; got != "" {
+			t.Errorf("Expected nothing to be superseded after a failure, got %s", got)
+		}
+		if len(mockStorage.videos) != videos {
+			t.Errorf("Expected no new videos to remain, got %d instead of %d", len(mockStorage.videos), videos)
+		}
+		if after, _ := os.ReadDir(tmpDir); len(after) != len(files) {
+			t.Errorf("Expected no new files to remain, got %d instead of %d", len(after), len(files))
+		}
+	})
+
+	unchanged := func(t *testing.T, videos int, files []os.DirEntry) {
+		t.Helper()
+		if len(mockStorage.videos) != videos {
+			t.Errorf("Expected no new videos to remain, got %d instead of %d", len(mockStorage.videos), videos)
+		}
+		if after, _ := os.ReadDir(tmpDir); len(after) != len(files) {
+			t.Errorf("Expected no new files to remain, got %d instead of %d", len(after), len(files))
+		}
+		if got := mockStorage.videos[trimmed.ID].SupersededBy; got != "" {
+			t.Errorf("Expected nothing to be superseded after a failure, got %s", got)
+		}
+		if got := mockStorage.videos[reversed.ID].Version; got != 1 {
+			t.Errorf("Expected no new version after a failure, got version %d", got)
+		}
+	}
+
+	// The source is 10 seconds long, so the replayed reverse is checked
+	// against limits it no longer meets.
+	t.Run("duration limits", func(t *testing.T) {
+		handler.config.MaxDuration = 5
+		defer func() { handler.config.MaxDuration = cfg.MaxDuration }()
+
+		videos := len(mockStorage.videos)
+		files, _ := os.ReadDir(tmpDir)
+
+		rr := post("/api/videos/"+reversed.ID+"/rerender", "", handler.HandleVideoOperations)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected 422, got %v %s", rr.Code, rr.Body.String())
+		}
+		unchanged(t, videos, files)
+	})
+
+	t.Run("failed marking", func(t *testing.T) {
+		stores := mockStorage.Stores()
+		stores.Videos = failingSupersedeStorage{mockStorage}
+		failing := NewVideoHandler(cfg, stores, handler.processor)
+
+		videos := len(mockStorage.videos)
+		files, _ := os.ReadDir(tmpDir)
+
+		rr := post("/api/videos/"+reversed.ID+"/rerender", "", failing.HandleVideoOperations)
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("Expected 500, got %v %s", rr.Code, rr.Body.String())
+		}
+		unchanged(t, videos, files)
+	})
+
+	t.Run("replays the chain from the source", func(t *testing.T) {
+		trims = nil
+		rr := post("/api/videos/"+reversed.ID+"/rerender", "", handler.HandleVideoOperations)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Rerender returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusOK, rr.Body.String())
+		}
+
+		var resp RerenderResponse
+		decode(rr, &resp)
+
+		if len(trims) != 1 || trims[0].CRF == nil || *trims[0].CRF != newCRF {
+			t.Errorf("Expected the trim to be replayed with the updated preset, got %+v", trims)
+		}
+
+		newTrimmed := resp.Superseded[trimmed.ID]
+		if newTrimmed == "" || resp.Rendering == nil {
+			t.Fatalf("Expected the trimmed video to be superseded and a new rendering, got %+v", resp)
+		}
+		newReversed := resp.Rendering.ID
+		if _, ok := resp.Superseded["source"]; ok {
+			t.Error("Expected the source not to be superseded")
+		}
+		if _, ok := resp.Superseded[reversed.ID]; ok {
+			t.Error("Expected the rerendered video not to be superseded")
+		}
+
+		current := mockStorage.videos[reversed.ID]
+		if resp.Video.ID != reversed.ID || resp.Video.Version != 2 || current.Version != 2 || current.SupersededBy != "" {
+			t.Errorf("Expected the rendering to be added as version 2 of %s, got %+v", reversed.ID, resp.Video)
+		}
+		if data, err := os.ReadFile(filepath.Join(tmpDir, current.Filename)); err != nil || string(data) != "reversed" {
+			t.Errorf("Expected version 2 to copy the rendering, got %q, %v", data, err)
+		}
+		if len(mockStorage.versions[reversed.ID]) != 2 {
+			t.Errorf("Expected 2 versions of %s, got %d", reversed.ID, len(mockStorage.versions[reversed.ID]))
+		}
+
+		last := mockStorage.operations[len(mockStorage.operations)-1]
+		if last.Type != storage.OperationReverse || !reflect.DeepEqual(last.InputIDs, []string{newTrimmed}) || !reflect.DeepEqual(last.OutputIDs, []string{newReversed}) {
+			t.Errorf("Expected the replayed reverse to be recorded, got %+v", last)
+		}
+	})
+}
+
+// failingSupersedeStorage fails to mark videos as superseded.
+type failingSupersedeStorage struct {
+	*MockVideoStorage
+}
+
+func (s failingSupersedeStorage) UpdateVideoSupersededBy(ctx context.Context, id, supersededBy string) error {
+	return errors.New("database is locked")
+}
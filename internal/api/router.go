@@ -10,24 +10,22 @@ import (
 )
 
 type Router struct {
-	db           *sql.DB
-	config       config.Config
-	storage      storage.VideoStorage
-	shareStorage storage.ShareLinkStorage
-	processor    video.Processor
+	db        *sql.DB
+	config    config.Config
+	stores    storage.Stores
+	processor video.Processor
 }
 
-func NewRouter(db *sql.DB, cfg config.Config) *Router {
-	videoStorage := storage.NewVideoStorage(db)
-	shareStorage := storage.NewShareLinkStorage(db)
-	videoProcessor := video.NewFFmpegProcessor()
-
+// NewRouter serves the API from db, processing videos with processor, which
+// is usually created from cfg.Processor by video.NewProcessor, and keeping
+// their files in blobs, usually created from cfg.BlobStore by
+// storage.NewBlobStore.
+func NewRouter(db *sql.DB, cfg config.Config, processor video.Processor, blobs storage.BlobStore) *Router {
 	return &Router{
-		db:           db,
-		config:       cfg,
-		storage:      videoStorage,
-		shareStorage: shareStorage,
-		processor:    videoProcessor,
+		db:        db,
+		config:    cfg,
+		stores:    storage.NewStores(db, blobs),
+		processor: processor,
 	}
 }
 
@@ -52,19 +50,30 @@ func (r *Router) SetupRoutes() http.Handler {
 		AuthMiddleware(r.config.APIToken),
 	)
 
-	videoHandler := NewVideoHandler(r.config, r.storage)
-	shareHandler := NewShareHandler(r.config, r.storage, r.shareStorage)
+	videoHandler := NewVideoHandler(r.config, r.stores, r.processor)
+	shareHandler := NewShareHandler(r.config, r.stores.Videos, r.stores.Shares, r.stores.Versions)
+	publicHandler := NewPublicHandler(r.config, r.stores)
+	presetHandler := NewPresetHandler(r.config, r.stores.Presets)
 
 	protected := http.NewServeMux()
 
 	protected.HandleFunc("/api/videos", videoHandler.HandleVideos)
+	protected.HandleFunc("/api/videos/", videoHandler.HandleVideoOperations)
 	protected.HandleFunc("/api/videos/trim/", videoHandler.HandleTrim)
 	protected.HandleFunc("/api/videos/merge", videoHandler.HandleMerge)
 
 	protected.HandleFunc("/api/shares", shareHandler.HandleShares)
 	protected.HandleFunc("/api/shares/", shareHandler.HandleShareOperations)
 
+	protected.HandleFunc("/api/presets", presetHandler.HandlePresets)
+	protected.HandleFunc("/api/presets/", presetHandler.HandlePresetOperations)
+
+	public := http.NewServeMux()
+
+	public.HandleFunc("/api/public/shares/", publicHandler.HandlePublicShares)
+
 	mux.HandleFunc("/api/health", r.handleHealth)
+	mux.Handle("/api/public/", LoggingMiddleware(public))
 	mux.Handle("/api/", middleware(protected))
 
 	return mux
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// SceneRequest configures scene detection. With Split set, every detected
+// scene is also cut out into a new video.
+type SceneRequest struct {
+	Threshold float64 `json:"threshold"`
+	Split     bool    `json:"split"`
+}
+
+// sceneSplitParams records the scenes a video was cut into.
+type sceneSplitParams struct {
+	Scenes []video.Scene `json:"scenes"`
+}
+
+type ScenesResponse struct {
+	Cuts   []*storage.SceneCut `json:"cuts"`
+	Scenes []video.Scene       `json:"scenes"`
+	Videos []*storage.Video    `json:"videos,omitempty"`
+}
+
+func (h *VideoHandler) handleScenes(w http.ResponseWriter, r *http.Request, videoID string) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handleDetectScenes(w, r, videoID)
+	case http.MethodGet:
+		h.handleListScenes(w, r, videoID)
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *VideoHandler) handleDetectScenes(w http.ResponseWriter, r *http.Request, videoID string) {
+	req := SceneRequest{Threshold: video.DefaultSceneThreshold}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Threshold <= 0 || req.Threshold > 1 {
+		SendError(w, http.StatusBadRequest, "threshold must be greater than 0 and at most 1")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	detected, err := h.processor.DetectScenes(r.Context(), sourcePath, req.Threshold)
+	if err != nil {
+		sendProcessingError(w, err, "failed to detect scenes")
+		return
+	}
+
+	cuts := make([]*storage.SceneCut, 0, len(detected))
+	for _, cut := range detected {
+		cuts = append(cuts, &storage.SceneCut{VideoID: source.ID, Time: cut.Time, Score: cut.Score})
+	}
+
+	if err := h.scenes.ReplaceSceneCuts(r.Context(), source.ID, cuts); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to save scenes")
+		return
+	}
+
+	response := ScenesResponse{
+		Cuts:   cuts,
+		Scenes: video.ScenesFromCuts(detected, float64(source.Duration)),
+	}
+
+	if req.Split && len(response.Scenes) > 1 {
+		op := h.startOperation(r.Context(), storage.OperationSceneSplit, []string{source.ID},
+			sceneSplitParams{Scenes: response.Scenes})
+		videos, err := h.splitScenes(r.Context(), source, sourcePath, response.Scenes)
+		h.finishOperation(r.Context(), op, err, videos...)
+		if err != nil {
+			sendProcessingError(w, err, "failed to split video at scene boundaries")
+			return
+		}
+		response.Videos = videos
+	}
+
+	SendSuccess(w, http.StatusOK, response, "scenes detected successfully")
+}
+
+func (h *VideoHandler) handleListScenes(w http.ResponseWriter, r *http.Request, videoID string) {
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	cuts, err := h.scenes.ListSceneCuts(r.Context(), source.ID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list scenes")
+		return
+	}
+
+	detected := make([]video.SceneCut, 0, len(cuts))
+	for _, cut := range cuts {
+		detected = append(detected, video.SceneCut{Time: cut.Time, Score: cut.Score})
+	}
+
+	SendSuccess(w, http.StatusOK, ScenesResponse{
+		Cuts:   cuts,
+		Scenes: video.ScenesFromCuts(detected, float64(source.Duration)),
+	}, "")
+}
+
+// splitScenes renders every scene before recording any of them, so a failed
+// render leaves no partial set of videos behind.
+func (h *VideoHandler) splitScenes(ctx context.Context, source *storage.Video, sourcePath string, scenes []video.Scene) ([]*storage.Video, error) {
+	ext := filepath.Ext(source.Filename)
+
+	var ids, filenames, paths []string
+	for _, scene := range scenes {
+		id, filename, path, err := h.newOutput(fmt.Sprintf("scene%d", scene.Index), ext)
+		if err != nil {
+			removeFiles(paths)
+			return nil, err
+		}
+		ids = append(ids, id)
+		filenames = append(filenames, filename)
+		paths = append(paths, path)
+
+		if err := h.processor.Trim(ctx, sourcePath, path, scene.Start, scene.End); err != nil {
+			removeFiles(paths)
+			return nil, err
+		}
+	}
+
+	var videos []*storage.Video
+	for i := range ids {
+		derived, err := h.saveDerivedVideo(ctx, ids[i], filenames[i])
+		if err != nil {
+			removeFiles(paths[i+1:])
+			h.discardVideos(context.WithoutCancel(ctx), videos)
+			return nil, err
+		}
+		videos = append(videos, derived)
+	}
+	return videos, nil
+}
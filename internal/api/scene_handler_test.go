@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func (m *MockVideoStorage) ReplaceSceneCuts(ctx context.Context, videoID string, cuts []*storage.SceneCut) error {
+	m.sceneCuts[videoID] = cuts
+	return nil
+}
+
+func (m *MockVideoStorage) ListSceneCuts(ctx context.Context, videoID string) ([]*storage.SceneCut, error) {
+	return m.sceneCuts[videoID], nil
+}
+
+func TestHandleScenes(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var trims [][2]float64
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		detectScenesFunc: func(ctx context.Context, input string, threshold float64) ([]video.SceneCut, error) {
+			return []video.SceneCut{{Time: 4, Score: 0.6}, {Time: 7.5, Score: 0.8}}, nil
+		},
+		trimFunc: func(ctx context.Context, input, output string, start, end float64) error {
+			trims = append(trims, [2]float64{start, end})
+			return nil
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantScenes int
+		wantVideos int
+	}{
+		{"detect", http.MethodPost, `{"threshold":0.3}`, http.StatusOK, 3, 0},
+		{"list", http.MethodGet, "", http.StatusOK, 3, 0},
+		{"detect and split", http.MethodPost, `{"split":true}`, http.StatusOK, 3, 3},
+		{"invalid threshold", http.MethodPost, `{"threshold":1.5}`, http.StatusBadRequest, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/videos/test-video/scenes", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data ScenesResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Data.Scenes) != tt.wantScenes {
+				t.Errorf("Expected %d scenes, got %d", tt.wantScenes, len(response.Data.Scenes))
+			}
+			if len(response.Data.Videos) != tt.wantVideos {
+				t.Errorf("Expected %d videos, got %d", tt.wantVideos, len(response.Data.Videos))
+			}
+		})
+	}
+
+	want := [][2]float64{{0, 4}, {4, 7.5}, {7.5, 10}}
+	if len(trims) != len(want) {
+		t.Fatalf("Expected %d trims, got %v", len(want), trims)
+	}
+	for i := range want {
+		if trims[i] != want[i] {
+			t.Errorf("Trim %d = %v, want %v", i, trims[i], want[i])
+		}
+	}
+}
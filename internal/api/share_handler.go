@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -10,28 +11,36 @@ import (
 )
 
 type ShareHandler struct {
-	config  config.Config
-	storage storage.VideoStorage
-	share   storage.ShareLinkStorage
+	config   config.Config
+	storage  storage.VideoStorage
+	share    storage.ShareLinkStorage
+	versions storage.VersionStorage
 }
 
-func NewShareHandler(cfg config.Config, store storage.VideoStorage, shareStore storage.ShareLinkStorage) *ShareHandler {
+func NewShareHandler(cfg config.Config, store storage.VideoStorage, shareStore storage.ShareLinkStorage, versionStore storage.VersionStorage) *ShareHandler {
 	if store == nil {
 		panic("video storage cannot be nil")
 	}
 	if shareStore == nil {
 		panic("share storage cannot be nil")
 	}
+	if versionStore == nil {
+		panic("version storage cannot be nil")
+	}
 	return &ShareHandler{
-		config:  cfg,
-		storage: store,
-		share:   shareStore,
+		config:   cfg,
+		storage:  store,
+		share:    shareStore,
+		versions: versionStore,
 	}
 }
 
+// CreateShareRequest shares a video for Duration hours. The link follows the
+// current version of the video unless Version pins it to one.
 type CreateShareRequest struct {
 	VideoID  string `json:"video_id"`
 	Duration int    `json:"duration"`
+	Version  int    `json:"version,omitempty"`
 }
 
 func (h *ShareHandler) HandleShares(w http.ResponseWriter, r *http.Request) {
@@ -84,6 +93,43 @@ func (h *ShareHandler) handleCreateShare(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if req.Version < 0 {
+		SendError(w, http.StatusBadRequest, "invalid version")
+		return
+	}
+	if req.Version > 0 {
+		version, err := h.versions.GetVersion(r.Context(), video.ID, req.Version)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to get video version")
+			return
+		}
+		if version == nil {
+			SendError(w, http.StatusNotFound, "video version not found")
+			return
+		}
+	}
+
+	// A redacted video is shared in place of the original, and a redaction
+	// of that copy in place of it in turn. If a redacted copy has been
+	// deleted the original must not be shared instead, so the video has to
+	// be redacted again.
+	for video.RedactedID != "" {
+		redacted, err := h.storage.GetVideo(r.Context(), video.RedactedID)
+		if err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to get redacted video")
+			return
+		}
+		if redacted == nil {
+			SendError(w, http.StatusConflict, fmt.Sprintf("redacted copy of video %s no longer exists; redact it again", video.ID))
+			return
+		}
+		if req.Version > 0 {
+			SendError(w, http.StatusBadRequest, "cannot pin a version of a redacted video")
+			return
+		}
+		video = redacted
+	}
+
 	shareID, err := generateID()
 	if err != nil {
 		SendError(w, http.StatusInternalServerError, "failed to generate share ID")
@@ -94,7 +140,8 @@ func (h *ShareHandler) handleCreateShare(w http.ResponseWriter, r *http.Request)
 
 	shareLink := &storage.ShareLink{
 		ID:        shareID,
-		VideoID:   req.VideoID,
+		VideoID:   video.ID,
+		Version:   req.Version,
 		ExpiresAt: expiresAt,
 		CreatedAt: time.Now(),
 	}
@@ -160,6 +207,9 @@ func (h *ShareHandler) handleGetShare(w http.ResponseWriter, r *http.Request, sh
 		SendError(w, http.StatusNotFound, "video not found")
 		return
 	}
+	if video = sharedVersion(w, r, h.versions, shareLink, video); video == nil {
+		return
+	}
 
 	response := struct {
 		ShareLink *storage.ShareLink `json:"share_link"`
@@ -16,7 +16,7 @@ func TestHandleCreateShare(t *testing.T) {
 	defer cleanup()
 
 	mockStorage := NewMockStorage()
-	handler := NewShareHandler(cfg, mockStorage, mockStorage)
+	handler := NewShareHandler(cfg, mockStorage, mockStorage, mockStorage)
 
 	testVideo := &storage.Video{
 		ID:       "test-video",
@@ -106,7 +106,7 @@ func TestHandleGetShare(t *testing.T) {
 	defer cleanup()
 
 	mockStorage := NewMockStorage()
-	handler := NewShareHandler(cfg, mockStorage, mockStorage)
+	handler := NewShareHandler(cfg, mockStorage, mockStorage, mockStorage)
 
 	testVideo := &storage.Video{
 		ID:       "test-video",
@@ -190,7 +190,7 @@ func TestHandleListShares(t *testing.T) {
 	defer cleanup()
 
 	mockStorage := NewMockStorage()
-	handler := NewShareHandler(cfg, mockStorage, mockStorage)
+	handler := NewShareHandler(cfg, mockStorage, mockStorage, mockStorage)
 
 	testVideos := map[string]*storage.Video{
 		"video1": {
@@ -316,7 +316,7 @@ func TestHandleDeleteShare(t *testing.T) {
 	defer cleanup()
 
 	mockStorage := NewMockStorage()
-	handler := NewShareHandler(cfg, mockStorage, mockStorage)
+	handler := NewShareHandler(cfg, mockStorage, mockStorage, mockStorage)
 
 	testVideo := &storage.Video{
 		ID:       "test-video",
@@ -413,13 +413,41 @@ func (m *MockVideoStorage) DeleteShareLink(ctx context.Context, id string) error
 }
 
 type MockVideoStorage struct {
-	videos     map[string]*storage.Video
-	shareLinks map[string]*storage.ShareLink
+	videos       map[string]*storage.Video
+	shareLinks   map[string]*storage.ShareLink
+	artifacts    map[string]*storage.Artifact
+	sceneCuts    map[string][]*storage.SceneCut
+	intervals    map[string][]*storage.Interval
+	fingerprints map[string]*storage.Fingerprint
+	presets      map[string]*storage.EncodingPreset
+	operations   []*storage.Operation
+	versions     map[string][]*storage.VideoVersion
 }
 
 func NewMockStorage() *MockVideoStorage {
 	return &MockVideoStorage{
-		videos:     make(map[string]*storage.Video),
-		shareLinks: make(map[string]*storage.ShareLink),
+		videos:       make(map[string]*storage.Video),
+		shareLinks:   make(map[string]*storage.ShareLink),
+		artifacts:    make(map[string]*storage.Artifact),
+		sceneCuts:    make(map[string][]*storage.SceneCut),
+		intervals:    make(map[string][]*storage.Interval),
+		fingerprints: make(map[string]*storage.Fingerprint),
+		presets:      make(map[string]*storage.EncodingPreset),
+		versions:     make(map[string][]*storage.VideoVersion),
+	}
+}
+
+// Stores returns the mock as every storage backend.
+func (m *MockVideoStorage) Stores() storage.Stores {
+	return storage.Stores{
+		Videos:       m,
+		Shares:       m,
+		Artifacts:    m,
+		Scenes:       m,
+		Intervals:    m,
+		Fingerprints: m,
+		Presets:      m,
+		Operations:   m,
+		Versions:     m,
 	}
 }
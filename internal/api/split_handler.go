@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// errSegmentTooLarge is returned when a segment exceeds the byte limit of a
+// split because the keyframes of the source are too far apart to cut it finer.
+var errSegmentTooLarge = errors.New("segment exceeds max_bytes")
+
+// splitParams records a split with its segment duration resolved, since
+// the request may give a size instead.
+type splitParams struct {
+	SegmentTime float64 `json:"segment_time"`
+	MaxBytes    int64   `json:"max_bytes,omitempty"`
+}
+
+type SplitResponse struct {
+	ParentID string           `json:"parent_id"`
+	Segments []*storage.Video `json:"segments"`
+}
+
+func (h *VideoHandler) handleSplit(w http.ResponseWriter, r *http.Request, videoID string) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handleCreateSplit(w, r, videoID)
+	case http.MethodGet:
+		h.handleListSegments(w, r, videoID)
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleCreateSplit cuts a video into segments of a given duration or under
+// a given size, each recorded as a video that references the source.
+func (h *VideoHandler) handleCreateSplit(w http.ResponseWriter, r *http.Request, videoID string) {
+	var opts video.SplitOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	existing, err := h.storage.ListSegments(r.Context(), source.ID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list segments")
+		return
+	}
+	if len(existing) > 0 {
+		SendError(w, http.StatusConflict, "video has already been split")
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	info, err := h.processor.GetVideoInfo(r.Context(), sourcePath)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+
+	segmentTime, err := opts.SegmentTime(info.Duration, info.Size)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	op := h.startOperation(r.Context(), storage.OperationSplit, []string{source.ID},
+		splitParams{SegmentTime: segmentTime, MaxBytes: opts.MaxBytes})
+	segments, err := h.splitVideo(r.Context(), source, sourcePath, segmentTime, opts.MaxBytes)
+	h.finishOperation(r.Context(), op, err, segments...)
+	if errors.Is(err, errSegmentTooLarge) {
+		SendError(w, http.StatusUnprocessableEntity, "keyframes are too far apart to split the video under max_bytes")
+		return
+	}
+	if err != nil {
+		sendProcessingError(w, err, "failed to split video")
+		return
+	}
+
+	SendSuccess(w, http.StatusCreated, SplitResponse{ParentID: source.ID, Segments: segments}, "video split successfully")
+}
+
+func (h *VideoHandler) handleListSegments(w http.ResponseWriter, r *http.Request, videoID string) {
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	segments, err := h.storage.ListSegments(r.Context(), source.ID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list segments")
+		return
+	}
+	if segments == nil {
+		segments = []*storage.Video{}
+	}
+
+	SendSuccess(w, http.StatusOK, SplitResponse{ParentID: source.ID, Segments: segments}, "")
+}
+
+// splitVideo writes the segments to a scratch directory and checks them all
+// before moving any into storage, so a failed split leaves nothing behind.
+// A maxBytes of zero disables the size check.
+func (h *VideoHandler) splitVideo(ctx context.Context, source *storage.Video, sourcePath string, segmentTime float64, maxBytes int64) ([]*storage.Video, error) {
+	scratch, err := os.MkdirTemp(h.config.VideoStoragePath, "split-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	parts, err := h.processor.Split(ctx, sourcePath, scratch, segmentTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxBytes > 0 {
+		for _, part := range parts {
+			stat, err := os.Stat(part)
+			if err != nil {
+				return nil, err
+			}
+			if stat.Size() > maxBytes {
+				return nil, fmt.Errorf("%w: %s is %d bytes", errSegmentTooLarge, filepath.Base(part), stat.Size())
+			}
+		}
+	}
+
+	ext := filepath.Ext(source.Filename)
+	var ids, filenames, paths []string
+	for i, part := range parts {
+		id, filename, path, err := h.newOutput(fmt.Sprintf("segment%d", i+1), ext)
+		if err != nil {
+			removeFiles(paths)
+			return nil, err
+		}
+		if err := os.Rename(part, path); err != nil {
+			removeFiles(paths)
+			return nil, err
+		}
+		ids = append(ids, id)
+		filenames = append(filenames, filename)
+		paths = append(paths, path)
+	}
+
+	var segments []*storage.Video
+	for i := range ids {
+		segment, err := h.saveDerived(ctx, &storage.Video{
+			ID:           ids[i],
+			Filename:     filenames[i],
+			ParentID:     source.ID,
+			SegmentIndex: i + 1,
+		})
+		if err != nil {
+			removeFiles(paths[i+1:])
+			h.discardVideos(context.WithoutCancel(ctx), segments)
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"vidproc-go/internal/storage"
+)
+
+func (m *MockVideoStorage) ListSegments(ctx context.Context, parentID string) ([]*storage.Video, error) {
+	var segments []*storage.Video
+	for _, v := range m.videos {
+		if v.ParentID == parentID {
+			segments = append(segments, v)
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].SegmentIndex < segments[j].SegmentIndex })
+	return segments, nil
+}
+
+func TestHandleSplit(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	tests := []struct {
+		name            string
+		body            string
+		segmentBytes    int
+		wantStatus      int
+		wantSegmentTime float64
+	}{
+		{"by duration", `{"segment_duration": 4}`, 100, http.StatusCreated, 4},
+		{"by size", `{"max_bytes": 512}`, 100, http.StatusCreated, 4.5},
+		{"segment over size", `{"max_bytes": 512}`, 600, http.StatusUnprocessableEntity, 4.5},
+		{"nothing selected", `{}`, 100, http.StatusBadRequest, 0},
+		{"longer than video", `{"segment_duration": 20}`, 100, http.StatusBadRequest, 0},
+		{"invalid body", `{`, 100, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := NewMockStorage()
+			var gotSegmentTime float64
+			handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+				splitFunc: func(ctx context.Context, input, outputDir string, segmentTime float64) ([]string, error) {
+					gotSegmentTime = segmentTime
+					var paths []string
+					for _, name := range []string{"segment000.mp4", "segment001.mp4", "segment002.mp4"} {
+						path := filepath.Join(outputDir, name)
+						if err := os.WriteFile(path, bytes.Repeat([]byte("x"), tt.segmentBytes), 0644); err != nil {
+							return nil, err
+						}
+						paths = append(paths, path)
+					}
+					return paths, nil
+				},
+			})
+
+			mockStorage.SaveVideo(context.Background(), &storage.Video{
+				ID:       "test-video",
+				Filename: "test.mp4",
+				Size:     1000,
+				Duration: 10,
+				Status:   storage.StatusCompleted,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/split", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if gotSegmentTime != tt.wantSegmentTime {
+				t.Errorf("Expected segment time %v, got %v", tt.wantSegmentTime, gotSegmentTime)
+			}
+			if matches, _ := filepath.Glob(filepath.Join(tmpDir, "split-*")); len(matches) != 0 {
+				t.Errorf("Expected the scratch directory to be removed, found %v", matches)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				if len(mockStorage.videos) != 1 {
+					t.Errorf("Expected no segments to be saved, got %d videos", len(mockStorage.videos))
+				}
+				return
+			}
+
+			var response struct {
+				Data SplitResponse `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Data.ParentID != "test-video" || len(response.Data.Segments) != 3 {
+				t.Fatalf("Unexpected response %+v", response.Data)
+			}
+			for i, segment := range response.Data.Segments {
+				if segment.ParentID != "test-video" || segment.SegmentIndex != i+1 {
+					t.Errorf("Segment %d has parent %q and index %d", i, segment.ParentID, segment.SegmentIndex)
+				}
+				if _, err := os.Stat(filepath.Join(tmpDir, segment.Filename)); err != nil {
+					t.Errorf("Expected segment file %s: %v", segment.Filename, err)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleSplitTwice(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	for _, wantStatus := range []int{http.StatusCreated, http.StatusConflict} {
+		req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/split", bytes.NewBufferString(`{"segment_duration": 4}`))
+		rr := httptest.NewRecorder()
+		handler.HandleVideoOperations(rr, req)
+
+		if rr.Code != wantStatus {
+			t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, wantStatus, rr.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/videos/test-video/split", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+
+	var response struct {
+		Data SplitResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Data.Segments) != 3 || response.Data.Segments[0].SegmentIndex != 1 {
+		t.Errorf("Expected the three segments in order, got %+v", response.Data.Segments)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// The track references the sprite by a relative URL so that the same file
+// works under both /api/videos/{id}/ and /api/public/shares/{shareId}/.
+const (
+	storyboardVTTName    = "storyboard.vtt"
+	storyboardSpriteName = "storyboard.jpg"
+)
+
+var defaultStoryboardOptions = video.StoryboardOptions{
+	Interval:   5,
+	TileWidth:  160,
+	TileHeight: 90,
+	Columns:    5,
+}
+
+func (h *VideoHandler) handleCreateStoryboard(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	opts := defaultStoryboardOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	// The track has to cover the whole video, including the fraction of a
+	// second that the stored duration drops.
+	sourcePath, info, err := h.probeVideo(r.Context(), source)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+
+	duration := info.Duration
+	if err := opts.Validate(duration); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sprite, err := h.renderArtifact(r.Context(), source.ID, storage.ArtifactStoryboard, ".jpg", "image/jpeg", opts,
+		func(path string) error {
+			return h.processor.GenerateStoryboard(r.Context(), sourcePath, path, duration, opts)
+		})
+	if err != nil {
+		sendProcessingError(w, err, "failed to generate storyboard")
+		return
+	}
+
+	track, err := h.renderArtifact(r.Context(), source.ID, storage.ArtifactStoryboardVTT, ".vtt", "text/vtt", opts,
+		func(path string) error {
+			return os.WriteFile(path, video.StoryboardVTT(duration, opts, storyboardSpriteName), 0644)
+		})
+	if err != nil {
+		h.artifacts.DeleteArtifact(r.Context(), sprite.ID)
+		h.removeBlob(r.Context(), sprite.Filename)
+		SendError(w, http.StatusInternalServerError, "failed to write storyboard track")
+		return
+	}
+
+	if err := h.pruneArtifacts(r.Context(), source.ID, storage.ArtifactStoryboard, sprite.ID); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to remove previous storyboard")
+		return
+	}
+	if err := h.pruneArtifacts(r.Context(), source.ID, storage.ArtifactStoryboardVTT, track.ID); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to remove previous storyboard")
+		return
+	}
+
+	SendSuccess(w, http.StatusCreated, struct {
+		Sprite *storage.Artifact `json:"sprite"`
+		Track  *storage.Artifact `json:"track"`
+	}{sprite, track}, "storyboard created successfully")
+}
+
+func (h *VideoHandler) handleGetStoryboard(w http.ResponseWriter, r *http.Request, videoID string, kind storage.ArtifactKind) {
+	if r.Method != http.MethodGet {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	serveLatestArtifact(w, r, h.artifacts, h.blobs, videoID, kind)
+}
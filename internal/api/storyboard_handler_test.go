@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleStoryboard(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			return &video.VideoInfo{Duration: 12.4, Format: "mp4", Size: 1024}, nil
+		},
+	})
+	ctx := context.Background()
+
+	mockStorage.SaveVideo(ctx, &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 12,
+		Status:   storage.StatusCompleted,
+	})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		handler.HandleVideoOperations(rr, req)
+		return rr
+	}
+
+	if rr := do(http.MethodGet, "/api/videos/test-video/storyboard.vtt", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before a storyboard exists, got %v", rr.Code)
+	}
+
+	if rr := do(http.MethodPost, "/api/videos/test-video/storyboard", `{"interval":0}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid interval, got %v", rr.Code)
+	}
+
+	if rr := do(http.MethodPost, "/api/videos/test-video/storyboard", `{"columns":2}`); rr.Code != http.StatusCreated {
+		t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+			rr.Code, http.StatusCreated, rr.Body.String())
+	}
+
+	rr := do(http.MethodGet, "/api/videos/test-video/storyboard.vtt", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/vtt" {
+		t.Errorf("Expected text/vtt content type, got %s", ct)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "00:00:10.000 --> 00:00:12.400\nstoryboard.jpg#xywh=0,90,160,90") {
+		t.Errorf("Unexpected storyboard track:\n%s", body)
+	}
+
+	if rr := do(http.MethodGet, "/api/videos/test-video/storyboard.jpg", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected sprite to be served, got %v", rr.Code)
+	}
+
+	t.Run("regenerating replaces the previous storyboard", func(t *testing.T) {
+		if rr := do(http.MethodPost, "/api/videos/test-video/storyboard", ""); rr.Code != http.StatusCreated {
+			t.Fatalf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+
+		for _, kind := range []storage.ArtifactKind{storage.ArtifactStoryboard, storage.ArtifactStoryboardVTT} {
+			artifacts, _ := mockStorage.ListArtifacts(ctx, "test-video", kind)
+			if len(artifacts) != 1 {
+				t.Errorf("Expected a single %s artifact, got %d", kind, len(artifacts))
+			}
+		}
+	})
+
+	t.Run("served through share links", func(t *testing.T) {
+		mockStorage.SaveShareLink(ctx, &storage.ShareLink{
+			ID:        "storyboard-share",
+			VideoID:   "test-video",
+			ExpiresAt: time.Now().Add(time.Hour),
+			CreatedAt: time.Now(),
+		})
+
+		public := NewPublicHandler(cfg, mockStorage.Stores())
+		for _, name := range []string{"storyboard.vtt", "storyboard.jpg"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/public/shares/storyboard-share/"+name, nil)
+			rr := httptest.NewRecorder()
+			public.HandlePublicShares(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("Expected %s to be served, got %v", name, rr.Code)
+			}
+		}
+	})
+}
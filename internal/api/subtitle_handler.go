@@ -0,0 +1,323 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+const maxSubtitleSize = 5 << 20
+
+// SubtitleParams are recorded with every subtitle track. Tracks are stored as
+// WebVTT whatever format they were uploaded in.
+type SubtitleParams struct {
+	Language string `json:"language,omitempty"`
+	Cues     int    `json:"cues"`
+}
+
+// CaptionRequest selects a subtitle track to add to a new copy of a video,
+// either as a soft track or burned into the picture.
+type CaptionRequest struct {
+	SubtitleID string `json:"subtitle_id"`
+	Burn       bool   `json:"burn"`
+	Container  string `json:"container"`
+}
+
+func (h *VideoHandler) handleSubtitles(w http.ResponseWriter, r *http.Request, videoID, subtitleID string) {
+	switch {
+	case subtitleID == "" && r.Method == http.MethodPost:
+		h.handleUploadSubtitles(w, r, videoID)
+	case subtitleID == "" && r.Method == http.MethodGet:
+		h.handleListSubtitles(w, r, videoID)
+	case subtitleID != "" && r.Method == http.MethodGet:
+		h.handleGetSubtitles(w, r, videoID, subtitleID)
+	case subtitleID != "" && r.Method == http.MethodDelete:
+		h.handleDeleteSubtitles(w, r, videoID, subtitleID)
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *VideoHandler) handleUploadSubtitles(w http.ResponseWriter, r *http.Request, videoID string) {
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxSubtitleSize); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid subtitle upload")
+		return
+	}
+
+	file, _, err := r.FormFile("subtitles")
+	if err != nil {
+		SendError(w, http.StatusBadRequest, "failed to get subtitle file")
+		return
+	}
+	defer file.Close()
+
+	language := strings.ToLower(r.FormValue("language"))
+	if language != "" && !isValidLanguage(language) {
+		SendError(w, http.StatusBadRequest, "language must be a two or three letter ISO 639 code")
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSubtitleSize+1))
+	if err != nil {
+		SendError(w, http.StatusBadRequest, "failed to read subtitle file")
+		return
+	}
+	if len(data) > maxSubtitleSize {
+		SendError(w, http.StatusBadRequest, "subtitle file too large")
+		return
+	}
+
+	cues, _, err := video.ParseSubtitles(data)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The stored duration is truncated to whole seconds, so cues are checked
+	// against the exact length of the file.
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	info, err := h.processor.GetVideoInfo(r.Context(), sourcePath)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+	if err := video.ValidateCues(cues, info.Duration); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	track, err := h.saveSubtitles(r.Context(), source.ID, language, cues)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to save subtitles")
+		return
+	}
+
+	SendSuccess(w, http.StatusCreated, track, "subtitles uploaded successfully")
+}
+
+func (h *VideoHandler) handleListSubtitles(w http.ResponseWriter, r *http.Request, videoID string) {
+	if h.loadVideo(w, r, videoID) == nil {
+		return
+	}
+
+	tracks, err := h.artifacts.ListArtifacts(r.Context(), videoID, storage.ArtifactSubtitles)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list subtitles")
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, tracks, "")
+}
+
+// handleGetSubtitles serves a track as WebVTT, or as SRT when requested with
+// ?format=srt.
+func (h *VideoHandler) handleGetSubtitles(w http.ResponseWriter, r *http.Request, videoID, subtitleID string) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != video.SubtitleVTT && format != video.SubtitleSRT {
+		SendError(w, http.StatusBadRequest, "format must be vtt or srt")
+		return
+	}
+
+	track := findArtifact(w, r, h.artifacts, videoID, subtitleID, storage.ArtifactSubtitles)
+	if track == nil {
+		return
+	}
+
+	if format != video.SubtitleSRT {
+		serveArtifact(w, r, h.blobs, track)
+		return
+	}
+
+	cues, err := h.readCues(r.Context(), track)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to read subtitles")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-subrip")
+	w.Write(video.FormatSubtitles(cues, video.SubtitleSRT))
+}
+
+func (h *VideoHandler) handleDeleteSubtitles(w http.ResponseWriter, r *http.Request, videoID, subtitleID string) {
+	track := findArtifact(w, r, h.artifacts, videoID, subtitleID, storage.ArtifactSubtitles)
+	if track == nil {
+		return
+	}
+
+	if err := h.artifacts.DeleteArtifact(r.Context(), track.ID); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to delete subtitles")
+		return
+	}
+	h.removeBlob(r.Context(), track.Filename)
+
+	SendSuccess(w, http.StatusOK, nil, "subtitles deleted successfully")
+}
+
+func (h *VideoHandler) handleCaptions(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	var req CaptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ext, err := captionContainer(req.Container, source.Filename)
+	if err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	track := findArtifact(w, r, h.artifacts, source.ID, req.SubtitleID, storage.ArtifactSubtitles)
+	if track == nil {
+		return
+	}
+
+	id, filename, outputPath, err := h.newOutput("captioned", ext)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	trackPath, err := h.localPath(r.Context(), track.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch subtitles")
+		return
+	}
+	op := h.startOperation(r.Context(), storage.OperationCaptions, []string{source.ID}, req)
+	if req.Burn {
+		err = h.processor.BurnSubtitles(r.Context(), sourcePath, trackPath, outputPath)
+	} else {
+		err = h.processor.MuxSubtitles(r.Context(), sourcePath, trackPath, outputPath, subtitleLanguage(track))
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to add subtitles")
+		return
+	}
+
+	captioned, err := h.saveDerivedVideo(r.Context(), id, filename)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save captioned video")
+		return
+	}
+	h.finishOperation(r.Context(), op, nil, captioned)
+
+	SendSuccess(w, http.StatusOK, captioned, "subtitles added successfully")
+}
+
+// shiftSubtitles copies the subtitle tracks of a video to a copy trimmed to
+// start and end, keeping the cues inside that range aligned with the new
+// timeline. Tracks with no cues left are not copied.
+func (h *VideoHandler) shiftSubtitles(ctx context.Context, sourceID, targetID string, start, end float64) error {
+	tracks, err := h.artifacts.ListArtifacts(ctx, sourceID, storage.ArtifactSubtitles)
+	if err != nil {
+		return err
+	}
+
+	for _, track := range tracks {
+		cues, err := h.readCues(ctx, track)
+		if err != nil {
+			return err
+		}
+
+		shifted := video.ShiftCues(cues, start, end)
+		if len(shifted) == 0 {
+			continue
+		}
+		if _, err := h.saveSubtitles(ctx, targetID, subtitleLanguage(track), shifted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *VideoHandler) saveSubtitles(ctx context.Context, videoID, language string, cues []video.Cue) (*storage.Artifact, error) {
+	params := SubtitleParams{Language: language, Cues: len(cues)}
+	return h.renderArtifact(ctx, videoID, storage.ArtifactSubtitles, ".vtt", "text/vtt", params,
+		func(path string) error {
+			return os.WriteFile(path, video.FormatSubtitles(cues, video.SubtitleVTT), 0644)
+		})
+}
+
+func (h *VideoHandler) readCues(ctx context.Context, track *storage.Artifact) ([]video.Cue, error) {
+	path, err := h.localPath(ctx, track.Filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cues, _, err := video.ParseSubtitles(data)
+	return cues, err
+}
+
+func subtitleLanguage(track *storage.Artifact) string {
+	var params SubtitleParams
+	json.Unmarshal(track.Params, &params)
+	return params.Language
+}
+
+// captionContainer returns the file extension for a captioned copy. Without
+// an explicit choice the source container is kept when it can carry a soft
+// subtitle track, and MP4 is used otherwise.
+func captionContainer(container, sourceFilename string) (string, error) {
+	switch container {
+	case "mp4", "mov", "mkv":
+		return "." + container, nil
+	case "":
+	default:
+		return "", fmt.Errorf("container must be mp4, mov or mkv")
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(sourceFilename)); ext {
+	case ".mp4", ".mov", ".mkv":
+		return ext, nil
+	default:
+		return ".mp4", nil
+	}
+}
+
+func isValidLanguage(language string) bool {
+	if len(language) < 2 || len(language) > 3 {
+		return false
+	}
+	for _, c := range language {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"vidproc-go/internal/storage"
+)
+
+const testSRT = "1\n00:00:01,000 --> 00:00:02,000\nFirst\n\n2\n00:00:06,000 --> 00:00:08,000\nSecond\n"
+
+func newSubtitleUpload(t *testing.T, videoID, content, language string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("subtitles", "captions.srt")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write([]byte(content))
+	if language != "" {
+		writer.WriteField("language", language)
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/"+videoID+"/subtitles", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func uploadTestSubtitles(t *testing.T, handler *VideoHandler, videoID string) *storage.Artifact {
+	rr := httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, newSubtitleUpload(t, videoID, testSRT, "eng"))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to upload subtitles: %v %s", rr.Code, rr.Body.String())
+	}
+
+	var response struct {
+		Data storage.Artifact `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return &response.Data
+}
+
+func TestHandleUploadSubtitles(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	tests := []struct {
+		name       string
+		content    string
+		language   string
+		wantStatus int
+	}{
+		{"srt", testSRT, "eng", http.StatusCreated},
+		{"vtt", "WEBVTT\n\n00:01.000 --> 00:02.000\nHello\n", "", http.StatusCreated},
+		{"cue past end", "1\n00:00:09,000 --> 00:00:12,000\nLate\n", "", http.StatusBadRequest},
+		{"malformed", "not subtitles", "", http.StatusBadRequest},
+		{"invalid language", testSRT, "english", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.HandleVideoOperations(rr, newSubtitleUpload(t, "test-video", tt.content, tt.language))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+
+	track := uploadTestSubtitles(t, handler, "test-video")
+	stored, err := os.ReadFile(filepath.Join(tmpDir, track.Filename))
+	if err != nil {
+		t.Fatalf("Failed to read stored track: %v", err)
+	}
+	if !strings.HasPrefix(string(stored), "WEBVTT") {
+		t.Errorf("Expected track stored as WebVTT, got %q", stored)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/videos/test-video/subtitles/"+track.ID+"?format=srt", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+	if rr.Code != http.StatusOK || rr.Body.String() != testSRT {
+		t.Errorf("Expected SRT conversion %q, got %v %q", testSRT, rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/videos/test-video/subtitles/"+track.ID, nil)
+	rr = httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected delete to succeed, got %v", rr.Code)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, track.Filename)); !os.IsNotExist(err) {
+		t.Error("Expected track file to be removed")
+	}
+}
+
+func TestHandleCaptions(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var muxed, burned string
+	var language string
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		muxSubtitlesFunc: func(ctx context.Context, input, subtitles, output, lang string) error {
+			muxed, language = output, lang
+			return nil
+		},
+		burnSubtitlesFunc: func(ctx context.Context, input, subtitles, output string) error {
+			burned = output
+			return nil
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+	track := uploadTestSubtitles(t, handler, "test-video")
+
+	tests := []struct {
+		name       string
+		req        CaptionRequest
+		wantStatus int
+	}{
+		{"soft mkv", CaptionRequest{SubtitleID: track.ID, Container: "mkv"}, http.StatusOK},
+		{"burn", CaptionRequest{SubtitleID: track.ID, Burn: true}, http.StatusOK},
+		{"unknown track", CaptionRequest{SubtitleID: "missing"}, http.StatusNotFound},
+		{"unsupported container", CaptionRequest{SubtitleID: track.ID, Container: "avi"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, _ := json.Marshal(tt.req)
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/captions", bytes.NewBuffer(reqBody))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+
+	if filepath.Ext(muxed) != ".mkv" || language != "eng" {
+		t.Errorf("Expected soft track muxed into mkv with language eng, got %q %q", muxed, language)
+	}
+	if filepath.Ext(burned) != ".mp4" {
+		t.Errorf("Expected burned output to keep the mp4 container, got %q", burned)
+	}
+}
+
+func TestTrimShiftsSubtitles(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+	uploadTestSubtitles(t, handler, "test-video")
+
+	reqBody, _ := json.Marshal(TrimRequest{Start: 5, End: 7})
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/trim/test-video", bytes.NewBuffer(reqBody))
+	rr := httptest.NewRecorder()
+	handler.HandleTrim(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Trim failed: %v %s", rr.Code, rr.Body.String())
+	}
+
+	var response struct {
+		Data storage.Video `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+
+	tracks, _ := mockStorage.ListArtifacts(context.Background(), response.Data.ID, storage.ArtifactSubtitles)
+	if len(tracks) != 1 {
+		t.Fatalf("Expected 1 subtitle track on trimmed video, got %d", len(tracks))
+	}
+
+	shifted, err := os.ReadFile(filepath.Join(tmpDir, tracks[0].Filename))
+	if err != nil {
+		t.Fatalf("Failed to read shifted track: %v", err)
+	}
+	want := "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nSecond\n"
+	if string(shifted) != want {
+		t.Errorf("Shifted track =\n%s\nwant\n%s", shifted, want)
+	}
+
+	t.Run("unreadable track", func(t *testing.T) {
+		sources, _ := mockStorage.ListArtifacts(context.Background(), "test-video", storage.ArtifactSubtitles)
+		os.Remove(filepath.Join(tmpDir, sources[0].Filename))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/videos/trim/test-video", bytes.NewBuffer(reqBody))
+		rr := httptest.NewRecorder()
+		handler.HandleTrim(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected the saved trim to succeed without its subtitles, got %v %s", rr.Code, rr.Body.String())
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+var errDerivedDuration = errors.New("resulting video duration")
+
+type SpeedRequest struct {
+	Factor float64 `json:"factor"`
+}
+
+func (h *VideoHandler) handleSpeed(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	var req SpeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := video.ValidateSpeed(req.Factor); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.renderRetimed(w, r, source, storage.OperationSpeed, req, "speed", "change video speed",
+		func(duration float64) float64 { return duration / req.Factor },
+		func(inputPath, outputPath string) error {
+			return h.processor.ChangeSpeed(r.Context(), inputPath, outputPath, req.Factor)
+		})
+}
+
+func (h *VideoHandler) handleReverse(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	h.renderRetimed(w, r, source, storage.OperationReverse, struct{}{}, "reversed", "reverse video",
+		func(duration float64) float64 { return duration },
+		func(inputPath, outputPath string) error {
+			return h.processor.Reverse(r.Context(), inputPath, outputPath)
+		})
+}
+
+func (h *VideoHandler) handleLoop(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	var opts video.LoopOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.renderRetimed(w, r, source, storage.OperationLoop, opts, "looped", "loop video", opts.OutputDuration,
+		func(inputPath, outputPath string) error {
+			return h.processor.Loop(r.Context(), inputPath, outputPath, opts)
+		})
+}
+
+// renderRetimed renders an operation that changes the length of a video into
+// a new derived video. The length the output will have is checked before
+// rendering, using the exact duration of the source rather than the rounded
+// one stored with it, and the output is discarded if it turns out longer or
+// shorter than allowed. The render is recorded as an operation of opType with
+// params.
+func (h *VideoHandler) renderRetimed(w http.ResponseWriter, r *http.Request, source *storage.Video, opType string, params interface{}, suffix, action string,
+	outputDuration func(sourceDuration float64) float64, render func(inputPath, outputPath string) error) {
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	info, err := h.processor.GetVideoInfo(r.Context(), sourcePath)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+
+	if !h.checkDerivedDuration(w, outputDuration(info.Duration)) {
+		return
+	}
+
+	id, filename, outputPath, err := h.newOutput(suffix, filepath.Ext(source.Filename))
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
+		return
+	}
+
+	op := h.startOperation(r.Context(), opType, []string{source.ID}, params)
+	if err := render(sourcePath, outputPath); err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to "+action)
+		return
+	}
+
+	// The encoder can make the output a little longer than predicted, for
+	// instance by padding the last loop to a whole frame, so the limits are
+	// applied again to what was actually rendered.
+	rendered, err := h.processor.GetVideoInfo(r.Context(), outputPath)
+	if err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+	if err := h.derivedDurationError(rendered.Duration); err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	derived, err := h.saveDerivedVideo(r.Context(), id, filename)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save derived video")
+		return
+	}
+	h.finishOperation(r.Context(), op, nil, derived)
+
+	SendSuccess(w, http.StatusOK, derived, "video processed successfully")
+}
+
+// checkDerivedDuration applies the upload duration limits to the output of an
+// operation, writing a 400 response if they are exceeded.
+func (h *VideoHandler) checkDerivedDuration(w http.ResponseWriter, duration float64) bool {
+	if err := h.derivedDurationError(duration); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// derivedDurationError returns an error wrapping errDerivedDuration if a
+// derived video of the given duration would exceed the upload duration
+// limits. Configuration can exempt derived videos from the limits.
+func (h *VideoHandler) derivedDurationError(duration float64) error {
+	if h.config.AllowDerivedDurations {
+		return nil
+	}
+	if duration < float64(h.config.MinDuration) || duration > float64(h.config.MaxDuration) {
+		return fmt.Errorf("%w must be between %d and %d seconds", errDerivedDuration, h.config.MinDuration, h.config.MaxDuration)
+	}
+	return nil
+}
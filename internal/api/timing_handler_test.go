@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleRetiming(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		operation  string
+		body       string
+		allow      bool
+		wantStatus int
+	}{
+		{"faster", "speed", `{"factor": 2}`, false, http.StatusOK},
+		{"slower beyond max duration", "speed", `{"factor": 0.25}`, false, http.StatusBadRequest},
+		{"slower allowed by config", "speed", `{"factor": 0.25}`, true, http.StatusOK},
+		{"speed out of range", "speed", `{"factor": 5}`, true, http.StatusBadRequest},
+		{"reverse", "reverse", ``, false, http.StatusOK},
+		{"loop count", "loop", `{"count": 3}`, false, http.StatusOK},
+		{"loop beyond max duration", "loop", `{"count": 4}`, false, http.StatusBadRequest},
+		{"loop to duration", "loop", `{"duration": 25}`, false, http.StatusOK},
+		{"loop count and duration", "loop", `{"count": 2, "duration": 25}`, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cfg
+			cfg.AllowDerivedDurations = tt.allow
+
+			mockStorage := NewMockStorage()
+			var rendered string
+			handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+				changeSpeedFunc: func(ctx context.Context, input, output string, factor float64) error {
+					rendered = "speed"
+					return nil
+				},
+				reverseFunc: func(ctx context.Context, input, output string) error {
+					rendered = "reverse"
+					return nil
+				},
+				loopFunc: func(ctx context.Context, input, output string, opts video.LoopOptions) error {
+					rendered = "loop"
+					return nil
+				},
+			})
+
+			mockStorage.SaveVideo(context.Background(), &storage.Video{
+				ID:       "test-video",
+				Filename: "test.mp4",
+				Size:     1000,
+				Duration: 10,
+				Status:   storage.StatusCompleted,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/"+tt.operation, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && rendered != tt.operation {
+				t.Errorf("Expected %s to be rendered, got %q", tt.operation, rendered)
+			}
+			if tt.wantStatus != http.StatusOK && rendered != "" {
+				t.Errorf("Expected nothing to be rendered, got %q", rendered)
+			}
+		})
+	}
+}
+
+func TestHandleRetimingRenderedTooLong(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var output string
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			if strings.Contains(filepath, "_looped") {
+				return &video.VideoInfo{Duration: 30.5, Format: "mp4", Size: 1024}, nil
+			}
+			return &video.VideoInfo{Duration: 10, Format: "mp4", Size: 1024}, nil
+		},
+		loopFunc: func(ctx context.Context, input, out string, opts video.LoopOptions) error {
+			output = out
+			return os.WriteFile(out, []byte("looped"), 0644)
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/loop", bytes.NewBufferString(`{"count": 3}`))
+	rr := httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for output past max duration, got %v: %s", rr.Code, rr.Body.String())
+	}
+	if output == "" {
+		t.Fatal("Expected the loop to be rendered")
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Expected rendered output to be removed, got %v", err)
+	}
+	if videos, _ := mockStorage.ListVideos(context.Background()); len(videos) != 1 {
+		t.Errorf("Expected no derived video to be saved, got %d videos", len(videos))
+	}
+
+	ops, _ := mockStorage.ListOperations(context.Background(), "test-video", storage.RoleInput)
+	if len(ops) != 1 || ops[0].Error == "" || len(ops[0].OutputIDs) != 0 {
+		t.Errorf("Expected a failed loop operation to be recorded, got %+v", ops)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+// TransformRequest describes a geometric change to a video. A named preset
+// sets the output size; the remaining fields can refine or override it.
+type TransformRequest struct {
+	Preset string `json:"preset,omitempty"`
+	video.TransformOptions
+}
+
+func (h *VideoHandler) handleTransform(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodPost {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	var req TransformRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	opts := req.TransformOptions
+	if req.Preset != "" {
+		if err := opts.ApplyPreset(req.Preset); err != nil {
+			SendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	info, err := h.processor.GetVideoInfo(r.Context(), sourcePath)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+
+	width, height := info.DisplaySize()
+	if err := opts.Validate(width, height); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, filename, outputPath, err := h.newOutput("transformed", filepath.Ext(source.Filename))
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate video ID")
+		return
+	}
+
+	op := h.startOperation(r.Context(), storage.OperationTransform, []string{source.ID}, opts)
+	if err := h.processor.Transform(r.Context(), sourcePath, outputPath, opts); err != nil {
+		os.Remove(outputPath)
+		h.finishOperation(r.Context(), op, err)
+		sendProcessingError(w, err, "failed to transform video")
+		return
+	}
+
+	transformed, err := h.saveDerivedVideo(r.Context(), id, filename)
+	if err != nil {
+		h.finishOperation(r.Context(), op, err)
+		SendError(w, http.StatusInternalServerError, "failed to save transformed video")
+		return
+	}
+	h.finishOperation(r.Context(), op, nil, transformed)
+
+	SendSuccess(w, http.StatusOK, transformed, "video transformed successfully")
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleTransform(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	var gotOpts video.TransformOptions
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			// A phone recording stored landscape with a 90 degree rotation tag.
+			return &video.VideoInfo{Duration: 10, Size: 1024, Width: 1920, Height: 1080, Rotation: 90}, nil
+		},
+		transformFunc: func(ctx context.Context, input, output string, opts video.TransformOptions) error {
+			gotOpts = opts
+			return nil
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantOpts   *video.TransformOptions
+	}{
+		{
+			name:       "preset",
+			body:       `{"preset": "square-1080x1080"}`,
+			wantStatus: http.StatusOK,
+			wantOpts:   &video.TransformOptions{Width: 1080, Height: 1080, Fit: video.FitPad, Background: video.BackgroundBlur},
+		},
+		{
+			name:       "preset with fit override",
+			body:       `{"preset": "vertical-1080x1920", "fit": "crop"}`,
+			wantStatus: http.StatusOK,
+			wantOpts:   &video.TransformOptions{Width: 1080, Height: 1920, Fit: video.FitCrop},
+		},
+		{
+			name:       "crop in display orientation",
+			body:       `{"crop": {"x": 0, "y": 1000, "width": 1080, "height": 900}}`,
+			wantStatus: http.StatusOK,
+			wantOpts:   &video.TransformOptions{Crop: &video.Rect{Y: 1000, Width: 1080, Height: 900}},
+		},
+		{
+			name:       "crop outside display orientation",
+			body:       `{"crop": {"x": 0, "y": 0, "width": 1920, "height": 1080}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown preset",
+			body:       `{"preset": "cinema"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "empty",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOpts = video.TransformOptions{}
+			req := httptest.NewRequest(http.MethodPost, "/api/videos/test-video/transform", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantOpts == nil {
+				return
+			}
+			if gotOpts.Width != tt.wantOpts.Width || gotOpts.Height != tt.wantOpts.Height ||
+				gotOpts.Fit != tt.wantOpts.Fit || gotOpts.Background != tt.wantOpts.Background ||
+				(tt.wantOpts.Crop != nil && (gotOpts.Crop == nil || *gotOpts.Crop != *tt.wantOpts.Crop)) {
+				t.Errorf("Transform options = %+v, want %+v", gotOpts, *tt.wantOpts)
+			}
+		})
+	}
+}
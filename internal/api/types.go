@@ -2,7 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"vidproc-go/internal/video"
 )
 
 type Response struct {
@@ -34,3 +38,21 @@ func SendSuccess(w http.ResponseWriter, status int, data interface{}, message st
 		Message: message,
 	})
 }
+
+// sendProcessingError reports a failed processor call. When the processor's
+// encode queue is full it answers 503 with a Retry-After estimate, and when
+// the configured processor cannot perform the operation at all 501, instead
+// of a 500 with message.
+func sendProcessingError(w http.ResponseWriter, err error, message string) {
+	var full *video.QueueFullError
+	if errors.As(err, &full) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(full.RetryAfter.Seconds()))))
+		SendError(w, http.StatusServiceUnavailable, "too many videos are being processed, retry later")
+		return
+	}
+	if errors.Is(err, video.ErrUnsupportedOperation) {
+		SendError(w, http.StatusNotImplemented, "operation not supported by the configured processor")
+		return
+	}
+	SendError(w, http.StatusInternalServerError, message)
+}
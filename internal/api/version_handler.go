@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"vidproc-go/internal/storage"
+)
+
+// VersionRequest makes the file of another video, typically one rendered
+// from this video to fix it, the next version of this video. A replacement
+// can also be uploaded as the "video" field of a multipart request.
+type VersionRequest struct {
+	VideoID string `json:"video_id"`
+}
+
+// handleVersions serves /api/videos/{id}/versions[/{version}].
+func (h *VideoHandler) handleVersions(w http.ResponseWriter, r *http.Request, videoID, resource string) {
+	if resource == "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleListVersions(w, r, videoID)
+		case http.MethodPost:
+			h.handleCreateVersion(w, r, videoID)
+		default:
+			SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	number, err := strconv.Atoi(resource)
+	if err != nil || number < 1 {
+		SendError(w, http.StatusBadRequest, "invalid version")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.handleGetVersion(w, r, videoID, number)
+	case http.MethodDelete:
+		h.handlePruneVersion(w, r, videoID, number)
+	default:
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *VideoHandler) handleListVersions(w http.ResponseWriter, r *http.Request, videoID string) {
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	versions, err := h.versions.ListVersions(r.Context(), source.ID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list video versions")
+		return
+	}
+	if versions == nil {
+		versions = []*storage.VideoVersion{}
+	}
+
+	SendSuccess(w, http.StatusOK, versions, "")
+}
+
+func (h *VideoHandler) handleGetVersion(w http.ResponseWriter, r *http.Request, videoID string, number int) {
+	version := h.loadVersion(w, r, videoID, number)
+	if version == nil {
+		return
+	}
+
+	SendSuccess(w, http.StatusOK, version, "")
+}
+
+// handleCreateVersion replaces the file of a video, keeping its ID, so that
+// share links following the current version serve the replacement.
+func (h *VideoHandler) handleCreateVersion(w http.ResponseWriter, r *http.Request, videoID string) {
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	prefix, err := versionPrefix(source.ID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to generate version ID")
+		return
+	}
+
+	var filename string
+	var size int64
+	var duration float64
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		uploaded, uploadedSize, info := h.receiveUpload(w, r, prefix)
+		if info == nil {
+			return
+		}
+		filename, size, duration = uploaded, uploadedSize, info.Duration
+	} else {
+		var req VersionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			SendError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.VideoID == "" || req.VideoID == source.ID {
+			SendError(w, http.StatusBadRequest, "video_id must name another video")
+			return
+		}
+
+		rendered := h.loadVideo(w, r, req.VideoID)
+		if rendered == nil {
+			return
+		}
+		if rendered.Status != storage.StatusCompleted {
+			SendError(w, http.StatusConflict, "video is not ready")
+			return
+		}
+
+		filename = prefix + filepath.Ext(rendered.Filename)
+		if err := h.copyVideoFile(r.Context(), rendered.Filename, filename); err != nil {
+			SendError(w, http.StatusInternalServerError, "failed to copy video")
+			return
+		}
+		size, duration = rendered.Size, float64(rendered.Duration)
+	}
+
+	current, err := h.addVersion(r.Context(), source.ID, filename, size, duration)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to save video version")
+		return
+	}
+
+	SendSuccess(w, http.StatusCreated, current, "video version created successfully")
+}
+
+// versionPrefix returns the name, without extension, of the file of a new
+// version of videoID. The version number is only allocated when the version
+// is saved, so every new version gets a file of its own and concurrent
+// requests cannot overwrite each other's.
+func versionPrefix(videoID string) (string, error) {
+	tag, err := generateID()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s_%s", videoID, tag), nil
+}
+
+// addRenderedVersion copies the file of a rendered video, which is kept, and
+// saves the copy as the new current version of videoID.
+func (h *VideoHandler) addRenderedVersion(ctx context.Context, videoID string, rendered *storage.Video) (*storage.Video, error) {
+	prefix, err := versionPrefix(videoID)
+	if err != nil {
+		return nil, err
+	}
+	filename := prefix + filepath.Ext(rendered.Filename)
+	if err := h.copyVideoFile(ctx, rendered.Filename, filename); err != nil {
+		return nil, err
+	}
+	return h.addVersion(ctx, videoID, filename, rendered.Size, float64(rendered.Duration))
+}
+
+// addVersion saves a file written to the working directory as the new
+// current version of a video, resets what was derived from the previous
+// version and returns the updated video. The file is removed if the version
+// cannot be saved.
+func (h *VideoHandler) addVersion(ctx context.Context, videoID, filename string, size int64, duration float64) (*storage.Video, error) {
+	if err := h.storeBlob(ctx, filename); err != nil {
+		h.removeBlob(ctx, filename)
+		return nil, err
+	}
+
+	version := &storage.VideoVersion{
+		VideoID:   videoID,
+		Filename:  filename,
+		Size:      size,
+		Duration:  int(duration),
+		CreatedAt: time.Now(),
+	}
+	if err := h.versions.AddVersion(ctx, version); err != nil {
+		h.removeBlob(ctx, filename)
+		return nil, err
+	}
+
+	h.resetDerivedState(ctx, videoID, filepath.Join(h.config.VideoStoragePath, filename), duration)
+
+	current, err := h.storage.GetVideo(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("video %s not found", videoID)
+	}
+	return current, nil
+}
+
+// resetDerivedState drops what was computed from the previous version of a
+// video: cached artifacts other than subtitles, which are authored rather
+// than rendered, detected scenes and intervals, and the fingerprint, which
+// is recomputed from path. Like fingerprints on upload this is best effort,
+// since the new version has already been saved.
+func (h *VideoHandler) resetDerivedState(ctx context.Context, videoID, path string, duration float64) {
+	for _, kind := range []storage.ArtifactKind{
+		storage.ArtifactPreview,
+		storage.ArtifactStoryboard,
+		storage.ArtifactStoryboardVTT,
+		storage.ArtifactFrame,
+		storage.ArtifactWaveform,
+		storage.ArtifactWaveformPeaks,
+	} {
+		h.pruneArtifacts(ctx, videoID, kind, "")
+	}
+
+	h.scenes.ReplaceSceneCuts(ctx, videoID, nil)
+	h.intervals.ReplaceIntervals(ctx, videoID, nil)
+
+	if hashes, err := h.processor.Fingerprint(ctx, path, duration); err == nil {
+		h.fingerprints.SaveFingerprint(ctx, &storage.Fingerprint{VideoID: videoID, Hashes: hashes})
+	}
+}
+
+// handlePruneVersion removes an old version of a video and its file. The
+// current version cannot be pruned, nor can a version that an unexpired
+// share link is pinned to.
+func (h *VideoHandler) handlePruneVersion(w http.ResponseWriter, r *http.Request, videoID string, number int) {
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+	if number == source.Version {
+		SendError(w, http.StatusConflict, "cannot prune the current version")
+		return
+	}
+
+	version := h.loadVersion(w, r, source.ID, number)
+	if version == nil {
+		return
+	}
+
+	links, err := h.shares.GetShareLinksByVideo(r.Context(), source.ID)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to list share links")
+		return
+	}
+	now := time.Now()
+	for _, link := range links {
+		if link.Version == number && link.ExpiresAt.After(now) {
+			SendError(w, http.StatusConflict, fmt.Sprintf("version is pinned by share link %s", link.ID))
+			return
+		}
+	}
+
+	if err := h.versions.DeleteVersion(r.Context(), source.ID, number); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to delete video version")
+		return
+	}
+	h.removeBlob(r.Context(), version.Filename)
+
+	SendSuccess(w, http.StatusOK, nil, "video version pruned successfully")
+}
+
+// loadVersion fetches a version of a video, writing the error response and
+// returning nil if it cannot be found.
+func (h *VideoHandler) loadVersion(w http.ResponseWriter, r *http.Request, videoID string, number int) *storage.VideoVersion {
+	version, err := h.versions.GetVersion(r.Context(), videoID, number)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video version")
+		return nil
+	}
+	if version == nil {
+		SendError(w, http.StatusNotFound, "video version not found")
+		return nil
+	}
+	return version
+}
+
+// copyVideoFile copies a stored video to a new file in the working
+// directory, removing the copy if it is incomplete.
+func (h *VideoHandler) copyVideoFile(ctx context.Context, srcName, dstName string) error {
+	srcPath, err := h.localPath(ctx, srcName)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst := filepath.Join(h.config.VideoStoragePath, dstName)
+	if _, err := h.saveUploadedFile(src, dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// sharedVersion returns the version of a video that a share link serves:
+// the current one, or the one the link is pinned to. It writes the error
+// response and returns nil if the pinned version has been pruned.
+func sharedVersion(w http.ResponseWriter, r *http.Request, store storage.VersionStorage, link *storage.ShareLink, current *storage.Video) *storage.Video {
+	if link.Version == 0 || link.Version == current.Version {
+		return current
+	}
+
+	version, err := store.GetVersion(r.Context(), current.ID, link.Version)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video version")
+		return nil
+	}
+	if version == nil {
+		SendError(w, http.StatusGone, "shared version has been pruned")
+		return nil
+	}
+	return asVersion(current, version)
+}
+
+// asVersion describes a video as it was at an earlier version. Loudness and
+// quality are only measured for the current version, so they are left out.
+func asVersion(current *storage.Video, version *storage.VideoVersion) *storage.Video {
+	v := *current
+	v.Filename = version.Filename
+	v.Size = version.Size
+	v.Duration = version.Duration
+	v.Version = version.Version
+	v.Loudness = nil
+	v.Quality = nil
+	return &v
+}
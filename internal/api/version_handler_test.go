@@ -0,0 +1,222 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"vidproc-go/internal/storage"
+)
+
+func (m *MockVideoStorage) AddVersion(ctx context.Context, version *storage.VideoVersion) error {
+	if video, ok := m.videos[version.VideoID]; ok {
+		version.Version = video.Version + 1
+	}
+	m.versions[version.VideoID] = append(m.versions[version.VideoID], version)
+	if video, ok := m.videos[version.VideoID]; ok {
+		video.Filename = version.Filename
+		video.Size = version.Size
+		video.Duration = version.Duration
+		video.Version = version.Version
+		video.Loudness = nil
+		video.Quality = nil
+	}
+	return nil
+}
+
+func (m *MockVideoStorage) GetVersion(ctx context.Context, videoID string, number int) (*storage.VideoVersion, error) {
+	for _, version := range m.versions[videoID] {
+		if version.Version == number {
+			return version, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockVideoStorage) ListVersions(ctx context.Context, videoID string) ([]*storage.VideoVersion, error) {
+	return m.versions[videoID], nil
+}
+
+func (m *MockVideoStorage) DeleteVersion(ctx context.Context, videoID string, number int) error {
+	versions := m.versions[videoID][:0]
+	for _, version := range m.versions[videoID] {
+		if version.Version != number {
+			versions = append(versions, version)
+		}
+	}
+	m.versions[videoID] = versions
+	return nil
+}
+
+func TestVideoVersions(t *testing.T) {
+	cfg, tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	for id, content := range map[string]string{"video": "original", "fixed": "fixed"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, id+".mp4"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		mockStorage.SaveVideo(context.Background(), &storage.Video{
+			ID:       id,
+			Filename: id + ".mp4",
+			Size:     int64(len(content)),
+			Duration: 10,
+			Status:   storage.StatusCompleted,
+		})
+	}
+	mockStorage.ReplaceSceneCuts(context.Background(), "video", []*storage.SceneCut{{VideoID: "video", Time: 4, Score: 0.5}})
+
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{})
+	shares := NewShareHandler(cfg, mockStorage, mockStorage, mockStorage)
+	public := NewPublicHandler(cfg, mockStorage.Stores())
+
+	serve := func(method, path string, body io.Reader, contentType string, h http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rr := httptest.NewRecorder()
+		h(rr, req)
+		return rr
+	}
+	share := func(version int) *storage.ShareLink {
+		t.Helper()
+		body, _ := json.Marshal(CreateShareRequest{VideoID: "video", Duration: 1, Version: version})
+		rr := serve(http.MethodPost, "/api/shares", bytes.NewReader(body), "", shares.HandleShares)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Failed to create share link: %v %s", rr.Code, rr.Body.String())
+		}
+		var link storage.ShareLink
+		json.NewDecoder(rr.Body).Decode(&Response{Data: &link})
+		return &link
+	}
+	publicVideo := func(link *storage.ShareLink) string {
+		t.Helper()
+		rr := serve(http.MethodGet, "/api/public/shares/"+link.ID+"/video", nil, "", public.HandlePublicShares)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Failed to download shared video: %v %s", rr.Code, rr.Body.String())
+		}
+		return rr.Body.String()
+	}
+
+	following := share(0)
+	pinned := share(1)
+	var replacement string
+
+	t.Run("replacement upload", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("video", "typo-fixed.mp4")
+		part.Write([]byte("replacement"))
+		writer.Close()
+
+		rr := serve(http.MethodPost, "/api/videos/video/versions", body, writer.FormDataContentType(), handler.HandleVideoOperations)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Create version returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusCreated, rr.Body.String())
+		}
+
+		var current storage.Video
+		json.NewDecoder(rr.Body).Decode(&Response{Data: &current})
+		if current.ID != "video" || current.Version != 2 || !strings.HasPrefix(current.Filename, "video_") || !strings.HasSuffix(current.Filename, "_typo-fixed.mp4") {
+			t.Errorf("Expected version 2 under the same ID, got %+v", current)
+		}
+		replacement = current.Filename
+		if len(mockStorage.sceneCuts["video"]) != 0 {
+			t.Error("Expected the scenes of the previous version to be cleared")
+		}
+	})
+
+	t.Run("rendered fix", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/api/videos/video/versions", bytes.NewBufferString(`{"video_id": "fixed"}`), "", handler.HandleVideoOperations)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Create version returned wrong status code: got %v want %v\nResponse body: %v",
+				rr.Code, http.StatusCreated, rr.Body.String())
+		}
+		if v := mockStorage.videos["video"]; v.Version != 3 || v.Filename == replacement || !strings.HasPrefix(v.Filename, "video_") || filepath.Ext(v.Filename) != ".mp4" {
+			t.Errorf("Expected version 3 copied from the rendered video, got %+v", v)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "fixed.mp4")); err != nil {
+			t.Error("Expected the rendered video to be kept")
+		}
+
+		rr = serve(http.MethodGet, "/api/videos/video/versions", nil, "", handler.HandleVideoOperations)
+		var versions []*storage.VideoVersion
+		json.NewDecoder(rr.Body).Decode(&Response{Data: &versions})
+		if len(versions) != 3 {
+			t.Errorf("Expected 3 versions, got %d", len(versions))
+		}
+	})
+
+	t.Run("share links", func(t *testing.T) {
+		if got := publicVideo(following); got != "fixed" {
+			t.Errorf("Expected the unpinned link to follow the current version, got %q", got)
+		}
+		if got := publicVideo(pinned); got != "original" {
+			t.Errorf("Expected the pinned link to serve version 1, got %q", got)
+		}
+
+		rr := serve(http.MethodGet, "/api/public/shares/"+pinned.ID, nil, "", public.HandlePublicShares)
+		var resp struct {
+			Video storage.Video `json:"video"`
+		}
+		json.NewDecoder(rr.Body).Decode(&Response{Data: &resp})
+		if resp.Video.Version != 1 || resp.Video.Filename != "video.mp4" {
+			t.Errorf("Expected the pinned link to describe version 1, got %+v", resp.Video)
+		}
+
+		body, _ := json.Marshal(CreateShareRequest{VideoID: "video", Duration: 1, Version: 9})
+		rr = serve(http.MethodPost, "/api/shares", bytes.NewReader(body), "", shares.HandleShares)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 when pinning an unknown version, got %v", rr.Code)
+		}
+	})
+
+	t.Run("download", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/api/videos/video/download?version=2", nil, "", handler.HandleVideoOperations)
+		if rr.Code != http.StatusOK || rr.Body.String() != "replacement" {
+			t.Errorf("Expected version 2 to be downloaded, got %v %q", rr.Code, rr.Body.String())
+		}
+		if got := rr.Header().Get("Content-Disposition"); got != `attachment; filename="video_v2.mp4"` {
+			t.Errorf("Unexpected Content-Disposition %q", got)
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			version    string
+			wantStatus int
+		}{
+			{"current version", "3", http.StatusConflict},
+			{"pinned version", "1", http.StatusConflict},
+			{"unknown version", "7", http.StatusNotFound},
+			{"old version", "2", http.StatusOK},
+		}
+		for _, tt := range tests {
+			rr := serve(http.MethodDelete, "/api/videos/video/versions/"+tt.version, nil, "", handler.HandleVideoOperations)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("%s: got %v want %v", tt.name, rr.Code, tt.wantStatus)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, replacement)); !os.IsNotExist(err) {
+			t.Error("Expected the file of the pruned version to be removed")
+		}
+
+		pinned.ExpiresAt = time.Now().Add(-time.Minute)
+		mockStorage.shareLinks[pinned.ID] = pinned
+		rr := serve(http.MethodDelete, "/api/videos/video/versions/1", nil, "", handler.HandleVideoOperations)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected a version pinned only by expired links to be pruned, got %v", rr.Code)
+		}
+	})
+}
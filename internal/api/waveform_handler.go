@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+const (
+	waveformImageName = "waveform.png"
+	waveformPeaksName = "waveform.json"
+)
+
+// handleWaveformImage serves the waveform PNG of a video, rendering it on
+// the first request and whenever the size or colour changes.
+func (h *VideoHandler) handleWaveformImage(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodGet {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	opts := video.DefaultWaveformOptions
+	query := r.URL.Query()
+	if err := queryInt(query.Get("width"), &opts.Width); err != nil {
+		SendError(w, http.StatusBadRequest, "width must be an integer")
+		return
+	}
+	if err := queryInt(query.Get("height"), &opts.Height); err != nil {
+		SendError(w, http.StatusBadRequest, "height must be an integer")
+		return
+	}
+	if color := query.Get("color"); color != "" {
+		opts.Color = color
+	}
+	if err := opts.Validate(); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.serveWaveform(w, r, videoID, storage.ArtifactWaveform, ".png", "image/png", opts,
+		func(sourcePath, path string) error {
+			return h.processor.RenderWaveform(r.Context(), sourcePath, path, opts)
+		})
+}
+
+// handleWaveformPeaks serves the waveform peaks of a video as JSON in the
+// audiowaveform format, computing them on the first request and whenever
+// the resolution changes.
+func (h *VideoHandler) handleWaveformPeaks(w http.ResponseWriter, r *http.Request, videoID string) {
+	if r.Method != http.MethodGet {
+		SendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	opts := video.DefaultPeaksOptions
+	query := r.URL.Query()
+	if err := queryInt(query.Get("samples_per_pixel"), &opts.SamplesPerPixel); err != nil {
+		SendError(w, http.StatusBadRequest, "samples_per_pixel must be an integer")
+		return
+	}
+	if err := queryInt(query.Get("bits"), &opts.Bits); err != nil {
+		SendError(w, http.StatusBadRequest, "bits must be an integer")
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		SendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.serveWaveform(w, r, videoID, storage.ArtifactWaveformPeaks, ".json", "application/json", opts,
+		func(sourcePath, path string) error {
+			peaks, err := h.processor.ComputePeaks(r.Context(), sourcePath, path+".pcm", opts)
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(peaks)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, data, 0644)
+		})
+}
+
+// serveWaveform serves the cached artifact of a kind if it was rendered with
+// the same options, and otherwise renders a new one that replaces it.
+func (h *VideoHandler) serveWaveform(w http.ResponseWriter, r *http.Request, videoID string, kind storage.ArtifactKind,
+	ext, contentType string, opts interface{}, render func(sourcePath, path string) error) {
+
+	source := h.loadVideo(w, r, videoID)
+	if source == nil {
+		return
+	}
+
+	params, err := json.Marshal(opts)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to encode waveform options")
+		return
+	}
+
+	cached, err := latestArtifact(r.Context(), h.artifacts, source.ID, kind)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get artifact")
+		return
+	}
+	if cached != nil && bytes.Equal(cached.Params, params) {
+		serveArtifact(w, r, h.blobs, cached)
+		return
+	}
+
+	sourcePath, err := h.localPath(r.Context(), source.Filename)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to fetch video")
+		return
+	}
+	info, err := h.processor.GetVideoInfo(r.Context(), sourcePath)
+	if err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to get video info")
+		return
+	}
+	if info.AudioCodec == "" {
+		SendError(w, http.StatusUnprocessableEntity, "video has no audio track")
+		return
+	}
+
+	artifact, err := h.renderArtifact(r.Context(), source.ID, kind, ext, contentType, opts,
+		func(path string) error {
+			return render(sourcePath, path)
+		})
+	if err != nil {
+		sendProcessingError(w, err, "failed to generate waveform")
+		return
+	}
+
+	if err := h.pruneArtifacts(r.Context(), source.ID, kind, artifact.ID); err != nil {
+		SendError(w, http.StatusInternalServerError, "failed to remove previous waveform")
+		return
+	}
+
+	serveArtifact(w, r, h.blobs, artifact)
+}
+
+// queryInt parses an optional integer query parameter into dst, leaving it
+// untouched when the parameter is absent.
+func queryInt(value string, dst *int) error {
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", value)
+	}
+	*dst = n
+	return nil
+}
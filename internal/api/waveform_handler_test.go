@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
+)
+
+func TestHandleWaveform(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	renders := 0
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{
+		getVideoInfoFunc: func(ctx context.Context, filepath string) (*video.VideoInfo, error) {
+			return &video.VideoInfo{Duration: 10, Size: 1024, AudioCodec: "aac"}, nil
+		},
+		renderWaveformFunc: func(ctx context.Context, input, output string, opts video.WaveformOptions) error {
+			renders++
+			return (&MockProcessor{}).RenderWaveform(ctx, input, output, opts)
+		},
+		computePeaksFunc: func(ctx context.Context, input, scratch string, opts video.PeaksOptions) (*video.Peaks, error) {
+			renders++
+			return (&MockProcessor{}).ComputePeaks(ctx, input, scratch, opts)
+		},
+	})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantRenders int
+		wantKind    storage.ArtifactKind
+	}{
+		{"image rendered", "waveform.png", http.StatusOK, 1, storage.ArtifactWaveform},
+		{"image cached", "waveform.png", http.StatusOK, 1, storage.ArtifactWaveform},
+		{"image resized", "waveform.png?width=900&height=100", http.StatusOK, 2, storage.ArtifactWaveform},
+		{"image bad color", "waveform.png?color=%2312", http.StatusBadRequest, 2, ""},
+		{"image bad width", "waveform.png?width=wide", http.StatusBadRequest, 2, ""},
+		{"peaks rendered", "waveform.json", http.StatusOK, 3, storage.ArtifactWaveformPeaks},
+		{"peaks cached", "waveform.json", http.StatusOK, 3, storage.ArtifactWaveformPeaks},
+		{"peaks 16 bit", "waveform.json?bits=16&samples_per_pixel=512", http.StatusOK, 4, storage.ArtifactWaveformPeaks},
+		{"peaks bad bits", "waveform.json?bits=12", http.StatusBadRequest, 4, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/videos/test-video/"+tt.path, nil)
+			rr := httptest.NewRecorder()
+
+			handler.HandleVideoOperations(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Handler returned wrong status code: got %v want %v\nResponse body: %v",
+					rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if renders != tt.wantRenders {
+				t.Errorf("Expected %d renders, got %d", tt.wantRenders, renders)
+			}
+			if tt.wantKind == "" {
+				return
+			}
+
+			artifacts, _ := mockStorage.ListArtifacts(context.Background(), "test-video", tt.wantKind)
+			if len(artifacts) != 1 {
+				t.Errorf("Expected one cached %s artifact, got %d", tt.wantKind, len(artifacts))
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/videos/test-video/waveform.json?bits=16&samples_per_pixel=512", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleVideoOperations(rr, req)
+
+	var peaks video.Peaks
+	if err := json.NewDecoder(rr.Body).Decode(&peaks); err != nil {
+		t.Fatalf("Failed to decode peaks: %v", err)
+	}
+	if peaks.Bits != 16 || peaks.SamplesPerPixel != 512 {
+		t.Errorf("Expected cached 16-bit peaks at 512 samples per pixel, got %+v", peaks)
+	}
+}
+
+func TestHandleWaveformWithoutAudio(t *testing.T) {
+	cfg, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	mockStorage := NewMockStorage()
+	handler := NewVideoHandler(cfg, mockStorage.Stores(), &MockProcessor{})
+
+	mockStorage.SaveVideo(context.Background(), &storage.Video{
+		ID:       "test-video",
+		Filename: "test.mp4",
+		Size:     1000,
+		Duration: 10,
+		Status:   storage.StatusCompleted,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/videos/test-video/waveform.png", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleVideoOperations(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"vidproc-go/internal/video"
 
 	"github.com/joho/godotenv"
 )
@@ -17,14 +18,81 @@ type Config struct {
 	MaxVideoSize     int64
 	MaxDuration      int
 	MinDuration      int
+	LoudnessTarget   float64
+
+	// AllowDerivedDurations lets operations such as speed changes and loops
+	// produce videos outside MinDuration/MaxDuration, which otherwise apply
+	// to derived videos just as they do to uploads.
+	AllowDerivedDurations bool
+
+	// DuplicatePolicy decides what happens when an upload's fingerprint is
+	// within DuplicateThreshold of an existing video: "off" ignores it,
+	// "warn" accepts the upload and reports the matches and "reject" refuses
+	// it. The threshold is the mean number of differing bits per 64-bit
+	// frame hash.
+	DuplicatePolicy    string
+	DuplicateThreshold float64
+
+	// DefaultEncodingPreset names the stored encoding preset that processing
+	// operations use when a request does not choose one. When empty, outputs
+	// are encoded with libx264 and AAC at the encoders' default quality.
+	DefaultEncodingPreset string
+
+	// Processor names the backend that probes and processes videos: "ffmpeg",
+	// "native-probe", which reads MP4/MOV metadata without external tools
+	// and supports no processing, or "fake", which writes placeholder files
+	// for tests and demos.
+	Processor string
+
+	// MaxConcurrentEncodes ffmpeg processes run at once and up to
+	// MaxQueuedEncodes more wait for a slot; beyond that requests are
+	// answered with 503 and Retry-After. EncodeThreads, EncodeNice and
+	// EncodeMemoryLimit (bytes) bound each process when non-zero, the memory
+	// limit being applied as an rlimit or a cgroup as set by EncodeMemoryCap.
+	MaxConcurrentEncodes int
+	MaxQueuedEncodes     int
+	EncodeThreads        int
+	EncodeNice           int
+	EncodeMemoryLimit    int64
+	EncodeMemoryCap      string
+
+	// BlobStore names where video files are kept: "local" stores them in
+	// VideoStoragePath and "s3" in the S3Bucket of an S3-compatible server,
+	// which lets several servers share them. With "s3", VideoStoragePath
+	// is the working directory the processor reads and writes, and keeps
+	// the files it has fetched or stored until they have gone unused for
+	// WorkingCopyTTL minutes; 0 keeps them.
+	BlobStore      string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3PathStyle    bool
+	WorkingCopyTTL int
 }
 
 const (
-	defaultPort             = "8080"
-	defaultEnvironment      = "development"
-	defaultMaxVideoSize     = 25 * 1024 * 1024
-	defaultMaxVideoDuration = 25
-	defaultMinVideoDuration = 5
+	DuplicateOff    = "off"
+	DuplicateWarn   = "warn"
+	DuplicateReject = "reject"
+)
+
+const (
+	defaultPort               = "8080"
+	defaultEnvironment        = "development"
+	defaultMaxVideoSize       = 25 * 1024 * 1024
+	defaultMaxVideoDuration   = 25
+	defaultMinVideoDuration   = 5
+	defaultLoudnessTarget     = -16.0
+	defaultDuplicatePolicy    = DuplicateWarn
+	defaultDuplicateThreshold = 10.0
+	defaultProcessor          = "ffmpeg"
+	defaultMaxConcurrent      = 2
+	defaultMaxQueued          = 10
+	defaultMemoryCap          = video.MemoryCapRlimit
+	defaultBlobStore          = "local"
+	defaultWorkingCopyTTL     = 60
 )
 
 func Load() (Config, error) {
@@ -55,6 +123,39 @@ func Load() (Config, error) {
 	cfg.MaxVideoSize = getEnvInt64WithDefault("MAX_VIDEO_SIZE", defaultMaxVideoSize)
 	cfg.MaxDuration = getEnvIntWithDefault("MAX_VIDEO_DURATION", defaultMaxVideoDuration)
 	cfg.MinDuration = getEnvIntWithDefault("MIN_VIDEO_DURATION", defaultMinVideoDuration)
+	cfg.LoudnessTarget = getEnvFloatWithDefault("LOUDNESS_TARGET", defaultLoudnessTarget)
+	cfg.AllowDerivedDurations = getEnvBoolWithDefault("ALLOW_DERIVED_DURATIONS", false)
+	cfg.DuplicateThreshold = getEnvFloatWithDefault("DUPLICATE_THRESHOLD", defaultDuplicateThreshold)
+
+	cfg.DefaultEncodingPreset = os.Getenv("DEFAULT_ENCODING_PRESET")
+	cfg.Processor = getEnvWithDefault("PROCESSOR", defaultProcessor)
+
+	cfg.MaxConcurrentEncodes = getEnvIntWithDefault("MAX_CONCURRENT_ENCODES", defaultMaxConcurrent)
+	cfg.MaxQueuedEncodes = getEnvIntWithDefault("MAX_QUEUED_ENCODES", defaultMaxQueued)
+	cfg.EncodeThreads = getEnvIntWithDefault("ENCODE_THREADS", 0)
+	cfg.EncodeNice = getEnvIntWithDefault("ENCODE_NICE", 0)
+	cfg.EncodeMemoryLimit = getEnvInt64WithDefault("ENCODE_MEMORY_LIMIT", 0)
+
+	switch cfg.EncodeMemoryCap = getEnvWithDefault("ENCODE_MEMORY_CAP", defaultMemoryCap); cfg.EncodeMemoryCap {
+	case video.MemoryCapRlimit, video.MemoryCapCgroup:
+	default:
+		cfg.EncodeMemoryCap = defaultMemoryCap
+	}
+
+	cfg.BlobStore = getEnvWithDefault("BLOB_STORE", defaultBlobStore)
+	cfg.S3Endpoint = os.Getenv("S3_ENDPOINT")
+	cfg.S3Region = os.Getenv("S3_REGION")
+	cfg.S3Bucket = os.Getenv("S3_BUCKET")
+	cfg.S3AccessKey = os.Getenv("S3_ACCESS_KEY_ID")
+	cfg.S3SecretKey = os.Getenv("S3_SECRET_ACCESS_KEY")
+	cfg.S3PathStyle = getEnvBoolWithDefault("S3_PATH_STYLE", true)
+	cfg.WorkingCopyTTL = getEnvIntWithDefault("WORKING_COPY_TTL", defaultWorkingCopyTTL)
+
+	switch cfg.DuplicatePolicy = getEnvWithDefault("DUPLICATE_POLICY", defaultDuplicatePolicy); cfg.DuplicatePolicy {
+	case DuplicateOff, DuplicateWarn, DuplicateReject:
+	default:
+		cfg.DuplicatePolicy = defaultDuplicatePolicy
+	}
 
 	return cfg, nil
 }
@@ -91,3 +192,29 @@ func getEnvInt64WithDefault(key string, defaultVal int64) int64 {
 	}
 	return val
 }
+
+func getEnvFloatWithDefault(key string, defaultVal float64) float64 {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultVal
+	}
+
+	val, err := strconv.ParseFloat(valStr, 64)
+	if err != nil {
+		return defaultVal
+	}
+	return val
+}
+
+func getEnvBoolWithDefault(key string, defaultVal bool) bool {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultVal
+	}
+
+	val, err := strconv.ParseBool(valStr)
+	if err != nil {
+		return defaultVal
+	}
+	return val
+}
@@ -3,11 +3,12 @@ package config
 import (
 	"os"
 	"testing"
+	"vidproc-go/internal/video"
 )
 
 func TestLoadConfig(t *testing.T) {
 	origEnv := make(map[string]string)
-	envVars := []string{"DB_PATH", "VIDEO_STORAGE_PATH", "API_TOKEN_SECRET", "MAX_VIDEO_SIZE", "MAX_VIDEO_DURATION", "MIN_VIDEO_DURATION", "PORT", "ENVIRONMENT"}
+	envVars := []string{"DB_PATH", "VIDEO_STORAGE_PATH", "API_TOKEN_SECRET", "MAX_VIDEO_SIZE", "MAX_VIDEO_DURATION", "MIN_VIDEO_DURATION", "PORT", "ENVIRONMENT", "LOUDNESS_TARGET", "ALLOW_DERIVED_DURATIONS", "DUPLICATE_POLICY", "DUPLICATE_THRESHOLD", "DEFAULT_ENCODING_PRESET", "PROCESSOR", "MAX_CONCURRENT_ENCODES", "MAX_QUEUED_ENCODES", "ENCODE_THREADS", "ENCODE_NICE", "ENCODE_MEMORY_LIMIT", "ENCODE_MEMORY_CAP", "BLOB_STORE", "S3_ENDPOINT", "S3_REGION", "S3_BUCKET", "S3_ACCESS_KEY_ID", "S3_SECRET_ACCESS_KEY", "S3_PATH_STYLE", "WORKING_COPY_TTL"}
 
 	for _, env := range envVars {
 		origEnv[env] = os.Getenv(env)
@@ -31,25 +32,65 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "valid configuration from env vars",
 			envVars: map[string]string{
-				"DB_PATH":            "/app/data/db/videos.db",
-				"VIDEO_STORAGE_PATH": "/app/data/videos",
-				"MAX_VIDEO_SIZE":     "25000000",
-				"MAX_VIDEO_DURATION": "25",
-				"MIN_VIDEO_DURATION": "5",
-				"API_TOKEN_SECRET":   "test-token",
-				"PORT":               "8080",
-				"ENVIRONMENT":        "development",
+				"DB_PATH":                 "/app/data/db/videos.db",
+				"VIDEO_STORAGE_PATH":      "/app/data/videos",
+				"MAX_VIDEO_SIZE":          "25000000",
+				"MAX_VIDEO_DURATION":      "25",
+				"MIN_VIDEO_DURATION":      "5",
+				"API_TOKEN_SECRET":        "test-token",
+				"PORT":                    "8080",
+				"ENVIRONMENT":             "development",
+				"LOUDNESS_TARGET":         "-23",
+				"ALLOW_DERIVED_DURATIONS": "true",
+				"DUPLICATE_POLICY":        "reject",
+				"DUPLICATE_THRESHOLD":     "6.5",
+				"DEFAULT_ENCODING_PRESET": "web",
+				"PROCESSOR":               "fake",
+				"MAX_CONCURRENT_ENCODES":  "4",
+				"MAX_QUEUED_ENCODES":      "20",
+				"ENCODE_THREADS":          "2",
+				"ENCODE_NICE":             "10",
+				"ENCODE_MEMORY_LIMIT":     "2147483648",
+				"ENCODE_MEMORY_CAP":       "cgroup",
+				"BLOB_STORE":              "s3",
+				"S3_ENDPOINT":             "http://minio:9000",
+				"S3_REGION":               "eu-west-1",
+				"S3_BUCKET":               "videos",
+				"S3_ACCESS_KEY_ID":        "access",
+				"S3_SECRET_ACCESS_KEY":    "secret",
+				"S3_PATH_STYLE":           "false",
+				"WORKING_COPY_TTL":        "15",
 			},
 			wantErr: false,
 			expected: Config{
-				DBPath:           "/app/data/db/videos.db",
-				VideoStoragePath: "/app/data/videos",
-				MaxVideoSize:     25000000,
-				MaxDuration:      25,
-				MinDuration:      5,
-				APIToken:         "test-token",
-				Port:             "8080",
-				Environment:      "development",
+				DBPath:                "/app/data/db/videos.db",
+				VideoStoragePath:      "/app/data/videos",
+				MaxVideoSize:          25000000,
+				MaxDuration:           25,
+				MinDuration:           5,
+				APIToken:              "test-token",
+				Port:                  "8080",
+				Environment:           "development",
+				LoudnessTarget:        -23,
+				AllowDerivedDurations: true,
+				DuplicatePolicy:       DuplicateReject,
+				DuplicateThreshold:    6.5,
+				DefaultEncodingPreset: "web",
+				Processor:             "fake",
+				MaxConcurrentEncodes:  4,
+				MaxQueuedEncodes:      20,
+				EncodeThreads:         2,
+				EncodeNice:            10,
+				EncodeMemoryLimit:     2147483648,
+				EncodeMemoryCap:       video.MemoryCapCgroup,
+				BlobStore:             "s3",
+				S3Endpoint:            "http://minio:9000",
+				S3Region:              "eu-west-1",
+				S3Bucket:              "videos",
+				S3AccessKey:           "access",
+				S3SecretKey:           "secret",
+				S3PathStyle:           false,
+				WorkingCopyTTL:        15,
 			},
 		},
 		{
@@ -60,25 +101,42 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "invalid numeric values",
 			envVars: map[string]string{
-				"DB_PATH":            "/app/data/db/videos.db",
-				"VIDEO_STORAGE_PATH": "/app/data/videos",
-				"API_TOKEN_SECRET":   "test-token",
-				"MAX_VIDEO_SIZE":     "invalid",
-				"MAX_VIDEO_DURATION": "invalid",
-				"MIN_VIDEO_DURATION": "invalid",
-				"PORT":               "8080",
-				"ENVIRONMENT":        "development",
+				"DB_PATH":                 "/app/data/db/videos.db",
+				"VIDEO_STORAGE_PATH":      "/app/data/videos",
+				"API_TOKEN_SECRET":        "test-token",
+				"MAX_VIDEO_SIZE":          "invalid",
+				"MAX_VIDEO_DURATION":      "invalid",
+				"MIN_VIDEO_DURATION":      "invalid",
+				"PORT":                    "8080",
+				"ENVIRONMENT":             "development",
+				"LOUDNESS_TARGET":         "invalid",
+				"ALLOW_DERIVED_DURATIONS": "invalid",
+				"DUPLICATE_POLICY":        "delete",
+				"DUPLICATE_THRESHOLD":     "invalid",
+				"MAX_CONCURRENT_ENCODES":  "invalid",
+				"ENCODE_MEMORY_CAP":       "swap",
+				"WORKING_COPY_TTL":        "invalid",
 			},
 			wantErr: false,
 			expected: Config{
-				DBPath:           "/app/data/db/videos.db",
-				VideoStoragePath: "/app/data/videos",
-				APIToken:         "test-token",
-				MaxVideoSize:     defaultMaxVideoSize,
-				MaxDuration:      defaultMaxVideoDuration,
-				MinDuration:      defaultMinVideoDuration,
-				Port:             "8080",
-				Environment:      "development",
+				DBPath:               "/app/data/db/videos.db",
+				VideoStoragePath:     "/app/data/videos",
+				APIToken:             "test-token",
+				MaxVideoSize:         defaultMaxVideoSize,
+				MaxDuration:          defaultMaxVideoDuration,
+				MinDuration:          defaultMinVideoDuration,
+				LoudnessTarget:       defaultLoudnessTarget,
+				DuplicatePolicy:      defaultDuplicatePolicy,
+				DuplicateThreshold:   defaultDuplicateThreshold,
+				Processor:            defaultProcessor,
+				MaxConcurrentEncodes: defaultMaxConcurrent,
+				MaxQueuedEncodes:     defaultMaxQueued,
+				EncodeMemoryCap:      defaultMemoryCap,
+				BlobStore:            defaultBlobStore,
+				S3PathStyle:          true,
+				WorkingCopyTTL:       defaultWorkingCopyTTL,
+				Port:                 "8080",
+				Environment:          "development",
 			},
 		},
 	}
@@ -119,6 +177,58 @@ func TestLoadConfig(t *testing.T) {
 				if config.APIToken != tt.expected.APIToken {
 					t.Errorf("APIToken = %v, want %v", config.APIToken, tt.expected.APIToken)
 				}
+				if config.LoudnessTarget != tt.expected.LoudnessTarget {
+					t.Errorf("LoudnessTarget = %v, want %v", config.LoudnessTarget, tt.expected.LoudnessTarget)
+				}
+				if config.AllowDerivedDurations != tt.expected.AllowDerivedDurations {
+					t.Errorf("AllowDerivedDurations = %v, want %v", config.AllowDerivedDurations, tt.expected.AllowDerivedDurations)
+				}
+				if config.DuplicatePolicy != tt.expected.DuplicatePolicy {
+					t.Errorf("DuplicatePolicy = %v, want %v", config.DuplicatePolicy, tt.expected.DuplicatePolicy)
+				}
+				if config.DuplicateThreshold != tt.expected.DuplicateThreshold {
+					t.Errorf("DuplicateThreshold = %v, want %v", config.DuplicateThreshold, tt.expected.DuplicateThreshold)
+				}
+				if config.DefaultEncodingPreset != tt.expected.DefaultEncodingPreset {
+					t.Errorf("DefaultEncodingPreset = %v, want %v", config.DefaultEncodingPreset, tt.expected.DefaultEncodingPreset)
+				}
+				if config.Processor != tt.expected.Processor {
+					t.Errorf("Processor = %v, want %v", config.Processor, tt.expected.Processor)
+				}
+				if config.MaxConcurrentEncodes != tt.expected.MaxConcurrentEncodes {
+					t.Errorf("MaxConcurrentEncodes = %v, want %v", config.MaxConcurrentEncodes, tt.expected.MaxConcurrentEncodes)
+				}
+				if config.MaxQueuedEncodes != tt.expected.MaxQueuedEncodes {
+					t.Errorf("MaxQueuedEncodes = %v, want %v", config.MaxQueuedEncodes, tt.expected.MaxQueuedEncodes)
+				}
+				if config.EncodeThreads != tt.expected.EncodeThreads {
+					t.Errorf("EncodeThreads = %v, want %v", config.EncodeThreads, tt.expected.EncodeThreads)
+				}
+				if config.EncodeNice != tt.expected.EncodeNice {
+					t.Errorf("EncodeNice = %v, want %v", config.EncodeNice, tt.expected.EncodeNice)
+				}
+				if config.EncodeMemoryLimit != tt.expected.EncodeMemoryLimit {
+					t.Errorf("EncodeMemoryLimit = %v, want %v", config.EncodeMemoryLimit, tt.expected.EncodeMemoryLimit)
+				}
+				if config.EncodeMemoryCap != tt.expected.EncodeMemoryCap {
+					t.Errorf("EncodeMemoryCap = %v, want %v", config.EncodeMemoryCap, tt.expected.EncodeMemoryCap)
+				}
+				if config.BlobStore != tt.expected.BlobStore {
+					t.Errorf("BlobStore = %v, want %v", config.BlobStore, tt.expected.BlobStore)
+				}
+				if config.S3Endpoint != tt.expected.S3Endpoint || config.S3Region != tt.expected.S3Region || config.S3Bucket != tt.expected.S3Bucket {
+					t.Errorf("S3 location = %v %v %v, want %v %v %v", config.S3Endpoint, config.S3Region, config.S3Bucket,
+						tt.expected.S3Endpoint, tt.expected.S3Region, tt.expected.S3Bucket)
+				}
+				if config.S3AccessKey != tt.expected.S3AccessKey || config.S3SecretKey != tt.expected.S3SecretKey {
+					t.Errorf("S3 credentials = %v %v, want %v %v", config.S3AccessKey, config.S3SecretKey, tt.expected.S3AccessKey, tt.expected.S3SecretKey)
+				}
+				if config.S3PathStyle != tt.expected.S3PathStyle {
+					t.Errorf("S3PathStyle = %v, want %v", config.S3PathStyle, tt.expected.S3PathStyle)
+				}
+				if config.WorkingCopyTTL != tt.expected.WorkingCopyTTL {
+					t.Errorf("WorkingCopyTTL = %v, want %v", config.WorkingCopyTTL, tt.expected.WorkingCopyTTL)
+				}
 			}
 		})
 	}
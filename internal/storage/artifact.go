@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type ArtifactKind string
+
+const (
+	ArtifactPreview       ArtifactKind = "preview"
+	ArtifactStoryboard    ArtifactKind = "storyboard"
+	ArtifactStoryboardVTT ArtifactKind = "storyboard_vtt"
+	ArtifactSubtitles     ArtifactKind = "subtitles"
+	ArtifactFrame         ArtifactKind = "frame"
+	ArtifactWaveform      ArtifactKind = "waveform"
+	ArtifactWaveformPeaks ArtifactKind = "waveform_peaks"
+)
+
+// Artifact is a file derived from a video that is not itself a video, such as
+// an animated preview, a storyboard sprite sheet or a subtitle track.
+type Artifact struct {
+	ID          string          `json:"id"`
+	VideoID     string          `json:"video_id"`
+	Kind        ArtifactKind    `json:"kind"`
+	Filename    string          `json:"filename"`
+	ContentType string          `json:"content_type"`
+	Size        int64           `json:"size"`
+	Params      json.RawMessage `json:"params,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type ArtifactStorage interface {
+	SaveArtifact(ctx context.Context, artifact *Artifact) error
+	GetArtifact(ctx context.Context, id string) (*Artifact, error)
+	ListArtifacts(ctx context.Context, videoID string, kind ArtifactKind) ([]*Artifact, error)
+	DeleteArtifact(ctx context.Context, id string) error
+}
+
+type SQLiteArtifactStorage struct {
+	db *sql.DB
+}
+
+func NewArtifactStorage(db *sql.DB) ArtifactStorage {
+	return &SQLiteArtifactStorage{db: db}
+}
+
+func (s *SQLiteArtifactStorage) SaveArtifact(ctx context.Context, artifact *Artifact) error {
+	query := `
+        INSERT INTO artifacts (id, video_id, kind, filename, content_type, size, params, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+    `
+	var params *string
+	if len(artifact.Params) > 0 {
+		p := string(artifact.Params)
+		params = &p
+	}
+	_, err := s.db.ExecContext(ctx, query,
+		artifact.ID,
+		artifact.VideoID,
+		artifact.Kind,
+		artifact.Filename,
+		artifact.ContentType,
+		artifact.Size,
+		params,
+		artifact.CreatedAt,
+	)
+	return err
+}
+
+func (s *SQLiteArtifactStorage) GetArtifact(ctx context.Context, id string) (*Artifact, error) {
+	query := `
+        SELECT id, video_id, kind, filename, content_type, size, params, created_at
+        FROM artifacts
+        WHERE id = ?
+    `
+	artifact, err := scanArtifact(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return artifact, nil
+}
+
+func (s *SQLiteArtifactStorage) ListArtifacts(ctx context.Context, videoID string, kind ArtifactKind) ([]*Artifact, error) {
+	query := `
+        SELECT id, video_id, kind, filename, content_type, size, params, created_at
+        FROM artifacts
+        WHERE video_id = ? AND kind = ?
+        ORDER BY created_at DESC
+    `
+	rows, err := s.db.QueryContext(ctx, query, videoID, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var artifacts []*Artifact
+	for rows.Next() {
+		artifact, err := scanArtifact(rows)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, rows.Err()
+}
+
+func (s *SQLiteArtifactStorage) DeleteArtifact(ctx context.Context, id string) error {
+	query := `DELETE FROM artifacts WHERE id = ?`
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func scanArtifact(row rowScanner) (*Artifact, error) {
+	var artifact Artifact
+	var params sql.NullString
+	err := row.Scan(
+		&artifact.ID,
+		&artifact.VideoID,
+		&artifact.Kind,
+		&artifact.Filename,
+		&artifact.ContentType,
+		&artifact.Size,
+		&params,
+		&artifact.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if params.Valid {
+		artifact.Params = json.RawMessage(params.String)
+	}
+	return &artifact, nil
+}
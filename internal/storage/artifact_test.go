@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupArtifactTestDB(t *testing.T) (*sql.DB, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS artifacts (
+            id TEXT PRIMARY KEY,
+            video_id TEXT NOT NULL,
+            kind TEXT NOT NULL,
+            filename TEXT NOT NULL,
+            content_type TEXT NOT NULL,
+            size INTEGER NOT NULL,
+            params TEXT,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create artifacts table: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+	}
+}
+
+func TestArtifactStorage(t *testing.T) {
+	db, cleanup := setupArtifactTestDB(t)
+	defer cleanup()
+
+	storage := NewArtifactStorage(db)
+	ctx := context.Background()
+
+	testArtifact := &Artifact{
+		ID:          "test-artifact-id",
+		VideoID:     "test-video-id",
+		Kind:        ArtifactPreview,
+		Filename:    "test-artifact-id_preview.gif",
+		ContentType: "image/gif",
+		Size:        2048,
+		Params:      json.RawMessage(`{"fps":12}`),
+		CreatedAt:   time.Now(),
+	}
+
+	t.Run("SaveArtifact", func(t *testing.T) {
+		if err := storage.SaveArtifact(ctx, testArtifact); err != nil {
+			t.Errorf("SaveArtifact failed: %v", err)
+		}
+	})
+
+	t.Run("GetArtifact", func(t *testing.T) {
+		artifact, err := storage.GetArtifact(ctx, testArtifact.ID)
+		if err != nil {
+			t.Fatalf("GetArtifact failed: %v", err)
+		}
+		if artifact == nil {
+			t.Fatal("GetArtifact returned nil artifact")
+		}
+		if artifact.Filename != testArtifact.Filename || artifact.Kind != testArtifact.Kind {
+			t.Errorf("GetArtifact returned wrong artifact: got %+v", artifact)
+		}
+		if string(artifact.Params) != string(testArtifact.Params) {
+			t.Errorf("GetArtifact returned wrong params: got %s, want %s", artifact.Params, testArtifact.Params)
+		}
+	})
+
+	t.Run("ListArtifacts", func(t *testing.T) {
+		artifacts, err := storage.ListArtifacts(ctx, testArtifact.VideoID, ArtifactPreview)
+		if err != nil {
+			t.Fatalf("ListArtifacts failed: %v", err)
+		}
+		if len(artifacts) != 1 {
+			t.Errorf("Expected 1 artifact, got %d", len(artifacts))
+		}
+
+		other, err := storage.ListArtifacts(ctx, testArtifact.VideoID, "other")
+		if err != nil {
+			t.Fatalf("ListArtifacts failed: %v", err)
+		}
+		if len(other) != 0 {
+			t.Errorf("Expected no artifacts of another kind, got %d", len(other))
+		}
+	})
+
+	t.Run("DeleteArtifact", func(t *testing.T) {
+		if err := storage.DeleteArtifact(ctx, testArtifact.ID); err != nil {
+			t.Errorf("DeleteArtifact failed: %v", err)
+		}
+
+		artifact, err := storage.GetArtifact(ctx, testArtifact.ID)
+		if err != nil {
+			t.Errorf("GetArtifact after delete failed: %v", err)
+		}
+		if artifact != nil {
+			t.Error("Artifact was not deleted")
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrBlobNotFound is returned when a key has no blob.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobInfo describes a stored blob.
+type BlobInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// BlobStore holds the files behind videos, keyed by their filenames. Blobs
+// are written once and never modified, so readers may cache them.
+type BlobStore interface {
+	// Put stores size bytes read from r under key.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Range reads length bytes of a blob starting at offset, or the rest of
+	// it if length is negative.
+	Range(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete removes a blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (*BlobInfo, error)
+	// List returns the blobs whose keys start with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]*BlobInfo, error)
+}
+
+const (
+	BlobStoreLocal = "local"
+	BlobStoreS3    = "s3"
+)
+
+// BlobOptions configures NewBlobStore. Dir is used by the local store and
+// the S3 fields by the S3 store.
+type BlobOptions struct {
+	Dir string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle addresses the bucket in the path rather than the host
+	// name, as MinIO and most other S3-compatible servers expect.
+	S3PathStyle bool
+}
+
+// NewBlobStore creates the blob store named kind.
+func NewBlobStore(kind string, opts BlobOptions) (BlobStore, error) {
+	switch kind {
+	case BlobStoreLocal:
+		return NewLocalBlobStore(opts.Dir), nil
+	case BlobStoreS3:
+		return NewS3BlobStore(opts)
+	default:
+		return nil, fmt.Errorf("unknown blob store %q: must be one of %s, %s", kind, BlobStoreLocal, BlobStoreS3)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -21,22 +22,148 @@ CREATE TABLE IF NOT EXISTS videos (
     duration INTEGER NOT NULL,
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     status TEXT NOT NULL CHECK(status IN ('pending', 'processing', 'completed', 'failed')),
-    error_message TEXT
+    error_message TEXT,
+    loudness_integrated REAL,
+    loudness_true_peak REAL,
+    loudness_range REAL,
+    quality_source_id TEXT,
+    quality_source_start REAL,
+    psnr_avg REAL,
+    psnr_min REAL,
+    ssim_avg REAL,
+    ssim_min REAL,
+    parent_id TEXT,
+    segment_index INTEGER,
+    filters TEXT,
+    redacted_id TEXT,
+    superseded_by TEXT,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS video_versions (
+    video_id TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    filename TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    duration INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(video_id, version),
+    FOREIGN KEY(video_id) REFERENCES videos(id) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS share_links (
     id TEXT PRIMARY KEY,
     video_id TEXT NOT NULL,
+    version INTEGER,
     expires_at DATETIME NOT NULL,
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY(video_id) REFERENCES videos(id) ON DELETE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS artifacts (
+    id TEXT PRIMARY KEY,
+    video_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    params TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(video_id) REFERENCES videos(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS scene_cuts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    video_id TEXT NOT NULL,
+    time REAL NOT NULL,
+    score REAL NOT NULL,
+    FOREIGN KEY(video_id) REFERENCES videos(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS video_intervals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    video_id TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK(kind IN ('silence', 'black')),
+    start_time REAL NOT NULL,
+    end_time REAL NOT NULL,
+    FOREIGN KEY(video_id) REFERENCES videos(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS video_fingerprints (
+    video_id TEXT PRIMARY KEY,
+    hashes TEXT NOT NULL,
+    FOREIGN KEY(video_id) REFERENCES videos(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS encoding_presets (
+    name TEXT PRIMARY KEY,
+    video_codec TEXT NOT NULL,
+    preset TEXT NOT NULL DEFAULT '',
+    crf INTEGER,
+    max_bitrate TEXT NOT NULL DEFAULT '',
+    audio_bitrate TEXT NOT NULL DEFAULT '',
+    pixel_format TEXT NOT NULL DEFAULT '',
+    gop_size INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS operations (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    params TEXT,
+    encoding TEXT,
+    encoding_preset TEXT NOT NULL DEFAULT '',
+    processor_version TEXT NOT NULL DEFAULT '',
+    started_at DATETIME NOT NULL,
+    duration REAL NOT NULL,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS operation_videos (
+    operation_id TEXT NOT NULL,
+    video_id TEXT NOT NULL,
+    role TEXT NOT NULL CHECK(role IN ('input', 'output')),
+    position INTEGER NOT NULL,
+    PRIMARY KEY(operation_id, role, position),
+    FOREIGN KEY(operation_id) REFERENCES operations(id) ON DELETE CASCADE
+);
+
 CREATE INDEX IF NOT EXISTS idx_videos_status ON videos(status);
+CREATE INDEX IF NOT EXISTS idx_videos_parent_id ON videos(parent_id);
 CREATE INDEX IF NOT EXISTS idx_share_links_video_id ON share_links(video_id);
 CREATE INDEX IF NOT EXISTS idx_share_links_expires_at ON share_links(expires_at);
+CREATE INDEX IF NOT EXISTS idx_artifacts_video_kind ON artifacts(video_id, kind);
+CREATE INDEX IF NOT EXISTS idx_scene_cuts_video_id ON scene_cuts(video_id);
+CREATE INDEX IF NOT EXISTS idx_video_intervals_video_id ON video_intervals(video_id);
+CREATE INDEX IF NOT EXISTS idx_operation_videos_video_id ON operation_videos(video_id, role);
 `
 
+// migrations bring tables created by earlier versions of the schema up to
+// date. Each statement must be safe to run against a database that already
+// has it.
+var migrations = []string{
+	`ALTER TABLE videos ADD COLUMN loudness_integrated REAL`,
+	`ALTER TABLE videos ADD COLUMN loudness_true_peak REAL`,
+	`ALTER TABLE videos ADD COLUMN loudness_range REAL`,
+	`ALTER TABLE videos ADD COLUMN quality_source_id TEXT`,
+	`ALTER TABLE videos ADD COLUMN quality_source_start REAL`,
+	`ALTER TABLE videos ADD COLUMN psnr_avg REAL`,
+	`ALTER TABLE videos ADD COLUMN psnr_min REAL`,
+	`ALTER TABLE videos ADD COLUMN ssim_avg REAL`,
+	`ALTER TABLE videos ADD COLUMN ssim_min REAL`,
+	`ALTER TABLE videos ADD COLUMN parent_id TEXT`,
+	`ALTER TABLE videos ADD COLUMN segment_index INTEGER`,
+	`ALTER TABLE videos ADD COLUMN filters TEXT`,
+	`ALTER TABLE videos ADD COLUMN redacted_id TEXT`,
+	`ALTER TABLE videos ADD COLUMN superseded_by TEXT`,
+	`ALTER TABLE videos ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+	`ALTER TABLE share_links ADD COLUMN version INTEGER`,
+	// Videos saved before versioning become version 1 of themselves.
+	`INSERT OR IGNORE INTO video_versions (video_id, version, filename, size, duration, created_at)
+        SELECT id, version, filename, size, duration, created_at FROM videos`,
+}
+
 func NewDB(dbPath string) (*sql.DB, error) {
 
 	if dbPath == "" {
@@ -62,5 +189,19 @@ func NewDB(dbPath string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return db, nil
 }
+
+func migrate(db *sql.DB) error {
+	for _, stmt := range migrations {
+		if _, err := db.Exec(stmt); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return err
+		}
+	}
+	return nil
+}
@@ -46,7 +46,7 @@ func TestNewDB(t *testing.T) {
 			if err == nil {
 				defer db.Close()
 
-				var tables = []string{"videos", "share_links"}
+				var tables = []string{"videos", "share_links", "artifacts", "scene_cuts", "video_intervals"}
 				for _, table := range tables {
 					var name string
 					err := db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&name)
@@ -59,6 +59,9 @@ func TestNewDB(t *testing.T) {
 					"idx_videos_status",
 					"idx_share_links_video_id",
 					"idx_share_links_expires_at",
+					"idx_artifacts_video_kind",
+					"idx_scene_cuts_video_id",
+					"idx_video_intervals_video_id",
 				}
 				for _, index := range indexes {
 					var name string
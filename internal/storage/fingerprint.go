@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fingerprint is the sequence of perceptual hashes of frames sampled evenly
+// across a video.
+type Fingerprint struct {
+	VideoID string
+	Hashes  []uint64
+}
+
+type FingerprintStorage interface {
+	SaveFingerprint(ctx context.Context, fingerprint *Fingerprint) error
+	GetFingerprint(ctx context.Context, videoID string) (*Fingerprint, error)
+	ListFingerprints(ctx context.Context) ([]*Fingerprint, error)
+}
+
+type SQLiteFingerprintStorage struct {
+	db *sql.DB
+}
+
+func NewFingerprintStorage(db *sql.DB) FingerprintStorage {
+	return &SQLiteFingerprintStorage{db: db}
+}
+
+// SaveFingerprint stores the fingerprint of a video, replacing any earlier
+// one.
+func (s *SQLiteFingerprintStorage) SaveFingerprint(ctx context.Context, fingerprint *Fingerprint) error {
+	query := `
+        INSERT OR REPLACE INTO video_fingerprints (video_id, hashes)
+        VALUES (?, ?)
+    `
+	_, err := s.db.ExecContext(ctx, query, fingerprint.VideoID, encodeHashes(fingerprint.Hashes))
+	return err
+}
+
+func (s *SQLiteFingerprintStorage) GetFingerprint(ctx context.Context, videoID string) (*Fingerprint, error) {
+	query := `
+        SELECT video_id, hashes
+        FROM video_fingerprints
+        WHERE video_id = ?
+    `
+	fingerprint, err := scanFingerprint(s.db.QueryRowContext(ctx, query, videoID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fingerprint, nil
+}
+
+func (s *SQLiteFingerprintStorage) ListFingerprints(ctx context.Context) ([]*Fingerprint, error) {
+	query := `
+        SELECT video_id, hashes
+        FROM video_fingerprints
+    `
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fingerprints []*Fingerprint
+	for rows.Next() {
+		fingerprint, err := scanFingerprint(rows)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	return fingerprints, rows.Err()
+}
+
+func scanFingerprint(row rowScanner) (*Fingerprint, error) {
+	var fingerprint Fingerprint
+	var hashes string
+	if err := row.Scan(&fingerprint.VideoID, &hashes); err != nil {
+		return nil, err
+	}
+
+	decoded, err := decodeHashes(hashes)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint.Hashes = decoded
+	return &fingerprint, nil
+}
+
+// Hashes are stored as space-separated 16-digit hex values, since SQLite
+// integers are signed.
+func encodeHashes(hashes []uint64) string {
+	encoded := make([]string, len(hashes))
+	for i, hash := range hashes {
+		encoded[i] = fmt.Sprintf("%016x", hash)
+	}
+	return strings.Join(encoded, " ")
+}
+
+func decodeHashes(value string) ([]uint64, error) {
+	var hashes []uint64
+	for _, field := range strings.Fields(value) {
+		hash, err := strconv.ParseUint(field, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fingerprint hash %q: %w", field, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupFingerprintTestDB(t *testing.T) (*sql.DB, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS video_fingerprints (
+            video_id TEXT PRIMARY KEY,
+            hashes TEXT NOT NULL
+        )
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create video_fingerprints table: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+	}
+}
+
+func TestFingerprintStorage(t *testing.T) {
+	db, cleanup := setupFingerprintTestDB(t)
+	defer cleanup()
+
+	storage := NewFingerprintStorage(db)
+	ctx := context.Background()
+
+	t.Run("SaveAndGetFingerprint", func(t *testing.T) {
+		fingerprint := &Fingerprint{VideoID: "first", Hashes: []uint64{0, 1, 0xffffffffffffffff, 0x8000000000000000}}
+		if err := storage.SaveFingerprint(ctx, fingerprint); err != nil {
+			t.Fatalf("SaveFingerprint failed: %v", err)
+		}
+
+		retrieved, err := storage.GetFingerprint(ctx, "first")
+		if err != nil {
+			t.Fatalf("GetFingerprint failed: %v", err)
+		}
+		if !reflect.DeepEqual(retrieved, fingerprint) {
+			t.Errorf("Expected %+v, got %+v", fingerprint, retrieved)
+		}
+	})
+
+	t.Run("SaveReplaces", func(t *testing.T) {
+		replacement := &Fingerprint{VideoID: "first", Hashes: []uint64{42}}
+		if err := storage.SaveFingerprint(ctx, replacement); err != nil {
+			t.Fatalf("SaveFingerprint failed: %v", err)
+		}
+		if err := storage.SaveFingerprint(ctx, &Fingerprint{VideoID: "second", Hashes: []uint64{7}}); err != nil {
+			t.Fatalf("SaveFingerprint failed: %v", err)
+		}
+
+		fingerprints, err := storage.ListFingerprints(ctx)
+		if err != nil {
+			t.Fatalf("ListFingerprints failed: %v", err)
+		}
+		if len(fingerprints) != 2 {
+			t.Fatalf("Expected 2 fingerprints, got %d", len(fingerprints))
+		}
+		for _, fingerprint := range fingerprints {
+			if fingerprint.VideoID == "first" && !reflect.DeepEqual(fingerprint.Hashes, []uint64{42}) {
+				t.Errorf("Expected replaced hashes, got %v", fingerprint.Hashes)
+			}
+		}
+	})
+
+	t.Run("GetMissingFingerprint", func(t *testing.T) {
+		fingerprint, err := storage.GetFingerprint(ctx, "missing")
+		if err != nil || fingerprint != nil {
+			t.Errorf("Expected nil fingerprint and error, got %v, %v", fingerprint, err)
+		}
+	})
+}
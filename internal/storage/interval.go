@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+type IntervalKind string
+
+const (
+	IntervalSilence IntervalKind = "silence"
+	IntervalBlack   IntervalKind = "black"
+)
+
+// Interval is a detected range of silence or black frames within a video.
+type Interval struct {
+	VideoID string       `json:"video_id"`
+	Kind    IntervalKind `json:"kind"`
+	Start   float64      `json:"start"`
+	End     float64      `json:"end"`
+}
+
+type IntervalStorage interface {
+	ReplaceIntervals(ctx context.Context, videoID string, intervals []*Interval) error
+	ListIntervals(ctx context.Context, videoID string) ([]*Interval, error)
+}
+
+type SQLiteIntervalStorage struct {
+	db *sql.DB
+}
+
+func NewIntervalStorage(db *sql.DB) IntervalStorage {
+	return &SQLiteIntervalStorage{db: db}
+}
+
+// ReplaceIntervals stores the result of an analysis run, discarding the
+// intervals recorded by any previous run.
+func (s *SQLiteIntervalStorage) ReplaceIntervals(ctx context.Context, videoID string, intervals []*Interval) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM video_intervals WHERE video_id = ?`, videoID); err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO video_intervals (video_id, kind, start_time, end_time)
+        VALUES (?, ?, ?, ?)
+    `
+	for _, interval := range intervals {
+		if _, err := tx.ExecContext(ctx, query, videoID, interval.Kind, interval.Start, interval.End); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteIntervalStorage) ListIntervals(ctx context.Context, videoID string) ([]*Interval, error) {
+	query := `
+        SELECT video_id, kind, start_time, end_time
+        FROM video_intervals
+        WHERE video_id = ?
+        ORDER BY start_time
+    `
+	rows, err := s.db.QueryContext(ctx, query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var intervals []*Interval
+	for rows.Next() {
+		var interval Interval
+		if err := rows.Scan(&interval.VideoID, &interval.Kind, &interval.Start, &interval.End); err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, &interval)
+	}
+	return intervals, rows.Err()
+}
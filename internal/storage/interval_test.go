@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupIntervalTestDB(t *testing.T) (*sql.DB, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS video_intervals (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            video_id TEXT NOT NULL,
+            kind TEXT NOT NULL,
+            start_time REAL NOT NULL,
+            end_time REAL NOT NULL
+        )
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create video_intervals table: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+	}
+}
+
+func TestIntervalStorage(t *testing.T) {
+	db, cleanup := setupIntervalTestDB(t)
+	defer cleanup()
+
+	storage := NewIntervalStorage(db)
+	ctx := context.Background()
+
+	t.Run("ReplaceIntervals", func(t *testing.T) {
+		first := []*Interval{{Kind: IntervalSilence, Start: 0, End: 3}}
+		if err := storage.ReplaceIntervals(ctx, "test-video", first); err != nil {
+			t.Fatalf("ReplaceIntervals failed: %v", err)
+		}
+
+		second := []*Interval{
+			{Kind: IntervalSilence, Start: 12, End: 15},
+			{Kind: IntervalBlack, Start: 0, End: 1.5},
+		}
+		if err := storage.ReplaceIntervals(ctx, "test-video", second); err != nil {
+			t.Fatalf("ReplaceIntervals failed: %v", err)
+		}
+	})
+
+	t.Run("ListIntervals", func(t *testing.T) {
+		intervals, err := storage.ListIntervals(ctx, "test-video")
+		if err != nil {
+			t.Fatalf("ListIntervals failed: %v", err)
+		}
+		if len(intervals) != 2 {
+			t.Fatalf("Expected intervals from the latest run only, got %d", len(intervals))
+		}
+		if intervals[0].Kind != IntervalBlack || intervals[1].Kind != IntervalSilence {
+			t.Errorf("Expected intervals ordered by start time, got %s then %s", intervals[0].Kind, intervals[1].Kind)
+		}
+	})
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Operation types recorded for the processing requests that produce videos.
+const (
+	OperationTrim       = "trim"
+	OperationMerge      = "merge"
+	OperationLoudnorm   = "loudnorm"
+	OperationTransform  = "transform"
+	OperationSpeed      = "speed"
+	OperationReverse    = "reverse"
+	OperationLoop       = "loop"
+	OperationCompose    = "compose"
+	OperationCaptions   = "captions"
+	OperationMetadata   = "metadata"
+	OperationSplit      = "split"
+	OperationSceneSplit = "scene_split"
+	OperationEnhance    = "enhance"
+	OperationRedact     = "redact"
+)
+
+// OperationRole is the part a video plays in an operation.
+type OperationRole string
+
+const (
+	RoleInput  OperationRole = "input"
+	RoleOutput OperationRole = "output"
+)
+
+// Operation records one run of a processing request: what it was asked to
+// do, the videos it read and wrote, and how it went. Params holds the
+// options the processor was called with, after defaults and presets were
+// resolved, and Encoding the codec settings outputs were encoded with.
+// EncodingPreset names the preset those settings came from, if any. A failed
+// run has no outputs and keeps the processor's error output in Error.
+type Operation struct {
+	ID               string          `json:"id"`
+	Type             string          `json:"type"`
+	Params           json.RawMessage `json:"params,omitempty"`
+	Encoding         json.RawMessage `json:"encoding,omitempty"`
+	EncodingPreset   string          `json:"encoding_preset,omitempty"`
+	InputIDs         []string        `json:"input_ids"`
+	OutputIDs        []string        `json:"output_ids"`
+	ProcessorVersion string          `json:"processor_version"`
+	StartedAt        time.Time       `json:"started_at"`
+	Duration         float64         `json:"duration"`
+	Error            string          `json:"error,omitempty"`
+}
+
+type OperationStorage interface {
+	SaveOperation(ctx context.Context, op *Operation) error
+	GetOperation(ctx context.Context, id string) (*Operation, error)
+	// ListOperations returns the operations a video took part in with the
+	// given role, oldest first.
+	ListOperations(ctx context.Context, videoID string, role OperationRole) ([]*Operation, error)
+}
+
+type SQLiteOperationStorage struct {
+	db *sql.DB
+}
+
+func NewOperationStorage(db *sql.DB) OperationStorage {
+	return &SQLiteOperationStorage{db: db}
+}
+
+const operationColumns = `id, type, params, encoding, encoding_preset, processor_version, started_at, duration, error`
+
+func (s *SQLiteOperationStorage) SaveOperation(ctx context.Context, op *Operation) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO operations (` + operationColumns + `)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `
+	_, err = tx.ExecContext(ctx, query,
+		op.ID,
+		op.Type,
+		nullableJSON(op.Params),
+		nullableJSON(op.Encoding),
+		op.EncodingPreset,
+		op.ProcessorVersion,
+		op.StartedAt,
+		op.Duration,
+		op.Error,
+	)
+	if err != nil {
+		return err
+	}
+
+	query = `
+        INSERT INTO operation_videos (operation_id, video_id, role, position)
+        VALUES (?, ?, ?, ?)
+    `
+	for _, edge := range []struct {
+		role OperationRole
+		ids  []string
+	}{
+		{RoleInput, op.InputIDs},
+		{RoleOutput, op.OutputIDs},
+	} {
+		for i, id := range edge.ids {
+			if _, err := tx.ExecContext(ctx, query, op.ID, id, edge.role, i); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteOperationStorage) GetOperation(ctx context.Context, id string) (*Operation, error) {
+	query := `
+        SELECT ` + operationColumns + `
+        FROM operations
+        WHERE id = ?
+    `
+	op, err := scanOperation(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadVideoIDs(ctx, op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (s *SQLiteOperationStorage) ListOperations(ctx context.Context, videoID string, role OperationRole) ([]*Operation, error) {
+	query := `
+        SELECT ` + operationColumns + `
+        FROM operations
+        WHERE id IN (
+            SELECT operation_id FROM operation_videos WHERE video_id = ? AND role = ?
+        )
+        ORDER BY started_at, id
+    `
+	rows, err := s.db.QueryContext(ctx, query, videoID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []*Operation
+	for rows.Next() {
+		op, err := scanOperation(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The connection pool holds a single connection, so the edges are only
+	// read once the operations have been.
+	for _, op := range ops {
+		if err := s.loadVideoIDs(ctx, op); err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
+
+func (s *SQLiteOperationStorage) loadVideoIDs(ctx context.Context, op *Operation) error {
+	query := `
+        SELECT video_id, role
+        FROM operation_videos
+        WHERE operation_id = ?
+        ORDER BY role, position
+    `
+	rows, err := s.db.QueryContext(ctx, query, op.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	op.InputIDs, op.OutputIDs = []string{}, []string{}
+	for rows.Next() {
+		var id string
+		var role OperationRole
+		if err := rows.Scan(&id, &role); err != nil {
+			return err
+		}
+		if role == RoleInput {
+			op.InputIDs = append(op.InputIDs, id)
+		} else {
+			op.OutputIDs = append(op.OutputIDs, id)
+		}
+	}
+	return rows.Err()
+}
+
+func scanOperation(row rowScanner) (*Operation, error) {
+	var op Operation
+	var params, encoding sql.NullString
+	err := row.Scan(
+		&op.ID,
+		&op.Type,
+		&params,
+		&encoding,
+		&op.EncodingPreset,
+		&op.ProcessorVersion,
+		&op.StartedAt,
+		&op.Duration,
+		&op.Error,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if params.Valid {
+		op.Params = json.RawMessage(params.String)
+	}
+	if encoding.Valid {
+		op.Encoding = json.RawMessage(encoding.String)
+	}
+	return &op, nil
+}
+
+func nullableJSON(raw json.RawMessage) *string {
+	if len(raw) == 0 {
+		return nil
+	}
+	s := string(raw)
+	return &s
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupOperationTestDB(t *testing.T) (*sql.DB, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS operations (
+            id TEXT PRIMARY KEY,
+            type TEXT NOT NULL,
+            params TEXT,
+            encoding TEXT,
+            encoding_preset TEXT NOT NULL DEFAULT '',
+            processor_version TEXT NOT NULL DEFAULT '',
+            started_at DATETIME NOT NULL,
+            duration REAL NOT NULL,
+            error TEXT NOT NULL DEFAULT ''
+        );
+
+        CREATE TABLE IF NOT EXISTS operation_videos (
+            operation_id TEXT NOT NULL,
+            video_id TEXT NOT NULL,
+            role TEXT NOT NULL CHECK(role IN ('input', 'output')),
+            position INTEGER NOT NULL,
+            PRIMARY KEY(operation_id, role, position),
+            FOREIGN KEY(operation_id) REFERENCES operations(id) ON DELETE CASCADE
+        );
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create operations tables: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+	}
+}
+
+func TestOperationStorage(t *testing.T) {
+	db, cleanup := setupOperationTestDB(t)
+	defer cleanup()
+
+	storage := NewOperationStorage(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	merge := &Operation{
+		ID:               "op1",
+		Type:             OperationMerge,
+		Params:           json.RawMessage(`{"normalize_loudness":false}`),
+		Encoding:         json.RawMessage(`{"video_codec":"libx264"}`),
+		EncodingPreset:   "web",
+		InputIDs:         []string{"b", "a"},
+		OutputIDs:        []string{"merged"},
+		ProcessorVersion: "ffmpeg version 6.1",
+		StartedAt:        now,
+		Duration:         1.5,
+	}
+	failed := &Operation{
+		ID:        "op2",
+		Type:      OperationTrim,
+		InputIDs:  []string{"merged"},
+		OutputIDs: []string{},
+		StartedAt: now.Add(time.Second),
+		Error:     "failed to trim video: exit status 1",
+	}
+
+	t.Run("SaveAndGetOperation", func(t *testing.T) {
+		for _, op := range []*Operation{merge, failed} {
+			if err := storage.SaveOperation(ctx, op); err != nil {
+				t.Fatalf("SaveOperation failed: %v", err)
+			}
+		}
+
+		got, err := storage.GetOperation(ctx, "op1")
+		if err != nil {
+			t.Fatalf("GetOperation failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, merge) {
+			t.Errorf("Expected %+v, got %+v", merge, got)
+		}
+
+		got, err = storage.GetOperation(ctx, "missing")
+		if err != nil || got != nil {
+			t.Errorf("Expected nil, nil for a missing operation, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("ListOperations", func(t *testing.T) {
+		tests := []struct {
+			videoID string
+			role    OperationRole
+			want    []string
+		}{
+			{"a", RoleInput, []string{"op1"}},
+			{"a", RoleOutput, nil},
+			{"merged", RoleOutput, []string{"op1"}},
+			{"merged", RoleInput, []string{"op2"}},
+		}
+
+		for _, tt := range tests {
+			ops, err := storage.ListOperations(ctx, tt.videoID, tt.role)
+			if err != nil {
+				t.Fatalf("ListOperations failed: %v", err)
+			}
+			var ids []string
+			for _, op := range ops {
+				ids = append(ids, op.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ListOperations(%s, %s) = %v, want %v", tt.videoID, tt.role, ids, tt.want)
+			}
+		}
+
+		ops, _ := storage.ListOperations(ctx, "merged", RoleInput)
+		if len(ops) == 1 && !reflect.DeepEqual(ops[0], failed) {
+			t.Errorf("Expected %+v, got %+v", failed, ops[0])
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// EncodingPreset is a named set of codec settings that processing
+// operations can encode their outputs with. Empty fields leave the encoder's
+// default in place.
+type EncodingPreset struct {
+	Name         string    `json:"name"`
+	VideoCodec   string    `json:"video_codec"`
+	Preset       string    `json:"preset,omitempty"`
+	CRF          *int      `json:"crf,omitempty"`
+	MaxBitrate   string    `json:"max_bitrate,omitempty"`
+	AudioBitrate string    `json:"audio_bitrate,omitempty"`
+	PixelFormat  string    `json:"pixel_format,omitempty"`
+	GOPSize      int       `json:"gop_size,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type PresetStorage interface {
+	SavePreset(ctx context.Context, preset *EncodingPreset) error
+	GetPreset(ctx context.Context, name string) (*EncodingPreset, error)
+	ListPresets(ctx context.Context) ([]*EncodingPreset, error)
+	UpdatePreset(ctx context.Context, preset *EncodingPreset) error
+	DeletePreset(ctx context.Context, name string) error
+}
+
+type SQLitePresetStorage struct {
+	db *sql.DB
+}
+
+func NewPresetStorage(db *sql.DB) PresetStorage {
+	return &SQLitePresetStorage{db: db}
+}
+
+const presetColumns = `name, video_codec, preset, crf, max_bitrate, audio_bitrate, pixel_format, gop_size, created_at, updated_at`
+
+func (s *SQLitePresetStorage) SavePreset(ctx context.Context, preset *EncodingPreset) error {
+	query := `
+        INSERT INTO encoding_presets (` + presetColumns + `)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `
+	_, err := s.db.ExecContext(ctx, query,
+		preset.Name,
+		preset.VideoCodec,
+		preset.Preset,
+		preset.CRF,
+		preset.MaxBitrate,
+		preset.AudioBitrate,
+		preset.PixelFormat,
+		preset.GOPSize,
+		preset.CreatedAt,
+		preset.UpdatedAt,
+	)
+	return err
+}
+
+func (s *SQLitePresetStorage) GetPreset(ctx context.Context, name string) (*EncodingPreset, error) {
+	query := `
+        SELECT ` + presetColumns + `
+        FROM encoding_presets
+        WHERE name = ?
+    `
+	preset, err := scanPreset(s.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return preset, nil
+}
+
+func (s *SQLitePresetStorage) ListPresets(ctx context.Context) ([]*EncodingPreset, error) {
+	query := `
+        SELECT ` + presetColumns + `
+        FROM encoding_presets
+        ORDER BY name
+    `
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var presets []*EncodingPreset
+	for rows.Next() {
+		preset, err := scanPreset(rows)
+		if err != nil {
+			return nil, err
+		}
+		presets = append(presets, preset)
+	}
+	return presets, rows.Err()
+}
+
+// UpdatePreset replaces the settings of an existing preset, keeping its
+// creation time.
+func (s *SQLitePresetStorage) UpdatePreset(ctx context.Context, preset *EncodingPreset) error {
+	query := `
+        UPDATE encoding_presets
+        SET video_codec = ?, preset = ?, crf = ?, max_bitrate = ?, audio_bitrate = ?, pixel_format = ?, gop_size = ?, updated_at = ?
+        WHERE name = ?
+    `
+	_, err := s.db.ExecContext(ctx, query,
+		preset.VideoCodec,
+		preset.Preset,
+		preset.CRF,
+		preset.MaxBitrate,
+		preset.AudioBitrate,
+		preset.PixelFormat,
+		preset.GOPSize,
+		preset.UpdatedAt,
+		preset.Name,
+	)
+	return err
+}
+
+func (s *SQLitePresetStorage) DeletePreset(ctx context.Context, name string) error {
+	query := `DELETE FROM encoding_presets WHERE name = ?`
+	_, err := s.db.ExecContext(ctx, query, name)
+	return err
+}
+
+func scanPreset(row rowScanner) (*EncodingPreset, error) {
+	var preset EncodingPreset
+	var crf sql.NullInt64
+	err := row.Scan(
+		&preset.Name,
+		&preset.VideoCodec,
+		&preset.Preset,
+		&crf,
+		&preset.MaxBitrate,
+		&preset.AudioBitrate,
+		&preset.PixelFormat,
+		&preset.GOPSize,
+		&preset.CreatedAt,
+		&preset.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if crf.Valid {
+		value := int(crf.Int64)
+		preset.CRF = &value
+	}
+	return &preset, nil
+}
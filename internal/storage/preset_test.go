@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupPresetTestDB(t *testing.T) (*sql.DB, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS encoding_presets (
+            name TEXT PRIMARY KEY,
+            video_codec TEXT NOT NULL,
+            preset TEXT NOT NULL DEFAULT '',
+            crf INTEGER,
+            max_bitrate TEXT NOT NULL DEFAULT '',
+            audio_bitrate TEXT NOT NULL DEFAULT '',
+            pixel_format TEXT NOT NULL DEFAULT '',
+            gop_size INTEGER NOT NULL DEFAULT 0,
+            created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create encoding_presets table: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+	}
+}
+
+func TestPresetStorage(t *testing.T) {
+	db, cleanup := setupPresetTestDB(t)
+	defer cleanup()
+
+	storage := NewPresetStorage(db)
+	ctx := context.Background()
+
+	crf := 23
+	now := time.Now().UTC().Truncate(time.Second)
+	web := &EncodingPreset{
+		Name:         "web",
+		VideoCodec:   "libx264",
+		Preset:       "medium",
+		CRF:          &crf,
+		MaxBitrate:   "4M",
+		AudioBitrate: "128k",
+		PixelFormat:  "yuv420p",
+		GOPSize:      48,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	t.Run("SaveAndGetPreset", func(t *testing.T) {
+		if err := storage.SavePreset(ctx, web); err != nil {
+			t.Fatalf("SavePreset failed: %v", err)
+		}
+		if err := storage.SavePreset(ctx, web); err == nil {
+			t.Error("Expected saving a preset twice to fail")
+		}
+
+		got, err := storage.GetPreset(ctx, "web")
+		if err != nil {
+			t.Fatalf("GetPreset failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, web) {
+			t.Errorf("Expected %+v, got %+v", web, got)
+		}
+	})
+
+	t.Run("GetMissingPreset", func(t *testing.T) {
+		got, err := storage.GetPreset(ctx, "missing")
+		if err != nil || got != nil {
+			t.Errorf("Expected nil, nil for a missing preset, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("UpdatePreset", func(t *testing.T) {
+		updated := &EncodingPreset{Name: "web", VideoCodec: "libx265", CreatedAt: now, UpdatedAt: now.Add(time.Hour)}
+		if err := storage.UpdatePreset(ctx, updated); err != nil {
+			t.Fatalf("UpdatePreset failed: %v", err)
+		}
+
+		got, err := storage.GetPreset(ctx, "web")
+		if err != nil {
+			t.Fatalf("GetPreset failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, updated) {
+			t.Errorf("Expected %+v, got %+v", updated, got)
+		}
+	})
+
+	t.Run("ListAndDeletePresets", func(t *testing.T) {
+		archive := &EncodingPreset{Name: "archive", VideoCodec: "libx265", CreatedAt: now, UpdatedAt: now}
+		if err := storage.SavePreset(ctx, archive); err != nil {
+			t.Fatalf("SavePreset failed: %v", err)
+		}
+
+		presets, err := storage.ListPresets(ctx)
+		if err != nil {
+			t.Fatalf("ListPresets failed: %v", err)
+		}
+		if len(presets) != 2 || presets[0].Name != "archive" || presets[1].Name != "web" {
+			t.Errorf("Expected presets sorted by name, got %+v", presets)
+		}
+
+		if err := storage.DeletePreset(ctx, "archive"); err != nil {
+			t.Fatalf("DeletePreset failed: %v", err)
+		}
+		if got, _ := storage.GetPreset(ctx, "archive"); got != nil {
+			t.Error("Expected the preset to be deleted")
+		}
+	})
+}
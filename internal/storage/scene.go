@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SceneCut is a detected scene boundary within a video.
+type SceneCut struct {
+	VideoID string  `json:"video_id"`
+	Time    float64 `json:"time"`
+	Score   float64 `json:"score"`
+}
+
+type SceneStorage interface {
+	ReplaceSceneCuts(ctx context.Context, videoID string, cuts []*SceneCut) error
+	ListSceneCuts(ctx context.Context, videoID string) ([]*SceneCut, error)
+}
+
+type SQLiteSceneStorage struct {
+	db *sql.DB
+}
+
+func NewSceneStorage(db *sql.DB) SceneStorage {
+	return &SQLiteSceneStorage{db: db}
+}
+
+// ReplaceSceneCuts stores the result of a detection run, discarding the cuts
+// recorded by any previous run.
+func (s *SQLiteSceneStorage) ReplaceSceneCuts(ctx context.Context, videoID string, cuts []*SceneCut) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scene_cuts WHERE video_id = ?`, videoID); err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO scene_cuts (video_id, time, score)
+        VALUES (?, ?, ?)
+    `
+	for _, cut := range cuts {
+		if _, err := tx.ExecContext(ctx, query, videoID, cut.Time, cut.Score); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteSceneStorage) ListSceneCuts(ctx context.Context, videoID string) ([]*SceneCut, error) {
+	query := `
+        SELECT video_id, time, score
+        FROM scene_cuts
+        WHERE video_id = ?
+        ORDER BY time
+    `
+	rows, err := s.db.QueryContext(ctx, query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cuts []*SceneCut
+	for rows.Next() {
+		var cut SceneCut
+		if err := rows.Scan(&cut.VideoID, &cut.Time, &cut.Score); err != nil {
+			return nil, err
+		}
+		cuts = append(cuts, &cut)
+	}
+	return cuts, rows.Err()
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupSceneTestDB(t *testing.T) (*sql.DB, func()) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS scene_cuts (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            video_id TEXT NOT NULL,
+            time REAL NOT NULL,
+            score REAL NOT NULL
+        )
+    `)
+	if err != nil {
+		t.Fatalf("Failed to create scene_cuts table: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+	}
+}
+
+func TestSceneStorage(t *testing.T) {
+	db, cleanup := setupSceneTestDB(t)
+	defer cleanup()
+
+	storage := NewSceneStorage(db)
+	ctx := context.Background()
+
+	t.Run("ReplaceSceneCuts", func(t *testing.T) {
+		first := []*SceneCut{{Time: 2, Score: 0.5}, {Time: 6, Score: 0.6}, {Time: 9, Score: 0.9}}
+		if err := storage.ReplaceSceneCuts(ctx, "test-video", first); err != nil {
+			t.Fatalf("ReplaceSceneCuts failed: %v", err)
+		}
+
+		second := []*SceneCut{{Time: 7.5, Score: 0.8}, {Time: 3.25, Score: 0.45}}
+		if err := storage.ReplaceSceneCuts(ctx, "test-video", second); err != nil {
+			t.Fatalf("ReplaceSceneCuts failed: %v", err)
+		}
+	})
+
+	t.Run("ListSceneCuts", func(t *testing.T) {
+		cuts, err := storage.ListSceneCuts(ctx, "test-video")
+		if err != nil {
+			t.Fatalf("ListSceneCuts failed: %v", err)
+		}
+		if len(cuts) != 2 {
+			t.Fatalf("Expected cuts from the latest run only, got %d", len(cuts))
+		}
+		if cuts[0].Time != 3.25 || cuts[1].Time != 7.5 {
+			t.Errorf("Expected cuts ordered by time, got %v and %v", cuts[0].Time, cuts[1].Time)
+		}
+
+		other, err := storage.ListSceneCuts(ctx, "other-video")
+		if err != nil {
+			t.Fatalf("ListSceneCuts failed: %v", err)
+		}
+		if len(other) != 0 {
+			t.Errorf("Expected no cuts for another video, got %d", len(other))
+		}
+	})
+}
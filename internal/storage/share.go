@@ -7,8 +7,11 @@ import (
 )
 
 type ShareLink struct {
-	ID        string    `json:"id"`
-	VideoID   string    `json:"video_id"`
+	ID      string `json:"id"`
+	VideoID string `json:"video_id"`
+	// Version pins the link to one version of the video. Zero follows
+	// whichever version is current.
+	Version   int       `json:"version,omitempty"`
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -31,12 +34,17 @@ type ShareLinkStorage interface {
 
 func (s *SQLiteShareLinkStorage) SaveShareLink(ctx context.Context, link *ShareLink) error {
 	query := `
-        INSERT INTO share_links (id, video_id, expires_at, created_at)
-        VALUES (?, ?, ?, ?)
+        INSERT INTO share_links (id, video_id, version, expires_at, created_at)
+        VALUES (?, ?, ?, ?, ?)
     `
+	var version *int
+	if link.Version != 0 {
+		version = &link.Version
+	}
 	_, err := s.db.ExecContext(ctx, query,
 		link.ID,
 		link.VideoID,
+		version,
 		link.ExpiresAt,
 		link.CreatedAt,
 	)
@@ -45,29 +53,23 @@ func (s *SQLiteShareLinkStorage) SaveShareLink(ctx context.Context, link *ShareL
 
 func (s *SQLiteShareLinkStorage) GetShareLink(ctx context.Context, id string) (*ShareLink, error) {
 	query := `
-        SELECT id, video_id, expires_at, created_at
+        SELECT id, video_id, version, expires_at, created_at
         FROM share_links
         WHERE id = ?
     `
-	var link ShareLink
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&link.ID,
-		&link.VideoID,
-		&link.ExpiresAt,
-		&link.CreatedAt,
-	)
+	link, err := scanShareLink(s.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &link, nil
+	return link, nil
 }
 
 func (s *SQLiteShareLinkStorage) GetShareLinksByVideo(ctx context.Context, videoID string) ([]*ShareLink, error) {
 	query := `
-        SELECT id, video_id, expires_at, created_at
+        SELECT id, video_id, version, expires_at, created_at
         FROM share_links
         WHERE video_id = ?
         ORDER BY created_at DESC
@@ -80,24 +82,18 @@ func (s *SQLiteShareLinkStorage) GetShareLinksByVideo(ctx context.Context, video
 
 	var links []*ShareLink
 	for rows.Next() {
-		var link ShareLink
-		err := rows.Scan(
-			&link.ID,
-			&link.VideoID,
-			&link.ExpiresAt,
-			&link.CreatedAt,
-		)
+		link, err := scanShareLink(rows)
 		if err != nil {
 			return nil, err
 		}
-		links = append(links, &link)
+		links = append(links, link)
 	}
 	return links, rows.Err()
 }
 
 func (s *SQLiteShareLinkStorage) ListShareLinks(ctx context.Context) ([]*ShareLink, error) {
 	query := `
-        SELECT id, video_id, expires_at, created_at
+        SELECT id, video_id, version, expires_at, created_at
         FROM share_links
         ORDER BY created_at DESC
     `
@@ -109,17 +105,11 @@ func (s *SQLiteShareLinkStorage) ListShareLinks(ctx context.Context) ([]*ShareLi
 
 	var links []*ShareLink
 	for rows.Next() {
-		var link ShareLink
-		err := rows.Scan(
-			&link.ID,
-			&link.VideoID,
-			&link.ExpiresAt,
-			&link.CreatedAt,
-		)
+		link, err := scanShareLink(rows)
 		if err != nil {
 			return nil, err
 		}
-		links = append(links, &link)
+		links = append(links, link)
 	}
 	return links, rows.Err()
 }
@@ -129,3 +119,20 @@ func (s *SQLiteShareLinkStorage) DeleteShareLink(ctx context.Context, id string)
 	_, err := s.db.ExecContext(ctx, query, id)
 	return err
 }
+
+func scanShareLink(row rowScanner) (*ShareLink, error) {
+	var link ShareLink
+	var version sql.NullInt64
+	err := row.Scan(
+		&link.ID,
+		&link.VideoID,
+		&version,
+		&link.ExpiresAt,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	link.Version = int(version.Int64)
+	return &link, nil
+}
@@ -19,6 +19,7 @@ func setupShareTestDB(t *testing.T) (*sql.DB, func()) {
         CREATE TABLE IF NOT EXISTS share_links (
             id TEXT PRIMARY KEY,
             video_id TEXT NOT NULL,
+            version INTEGER,
             expires_at TIMESTAMP NOT NULL,
             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
         )
@@ -65,6 +66,32 @@ func TestShareLinkStorage(t *testing.T) {
 		if link.ID != testLink.ID {
 			t.Errorf("GetShareLink returned wrong link: got %v, want %v", link.ID, testLink.ID)
 		}
+		if link.Version != 0 {
+			t.Errorf("Expected an unpinned link, got version %d", link.Version)
+		}
+	})
+
+	t.Run("PinnedShareLink", func(t *testing.T) {
+		pinned := &ShareLink{
+			ID:        "pinned-share-id",
+			VideoID:   testLink.VideoID,
+			Version:   2,
+			ExpiresAt: time.Now().Add(time.Hour),
+			CreatedAt: time.Now(),
+		}
+		if err := storage.SaveShareLink(ctx, pinned); err != nil {
+			t.Fatalf("SaveShareLink failed: %v", err)
+		}
+		link, err := storage.GetShareLink(ctx, pinned.ID)
+		if err != nil || link == nil {
+			t.Fatalf("GetShareLink failed: %v", err)
+		}
+		if link.Version != 2 {
+			t.Errorf("Expected version 2, got %d", link.Version)
+		}
+		if err := storage.DeleteShareLink(ctx, pinned.ID); err != nil {
+			t.Errorf("DeleteShareLink failed: %v", err)
+		}
 	})
 
 	t.Run("GetShareLinksByVideo", func(t *testing.T) {
@@ -0,0 +1,33 @@
+package storage
+
+import "database/sql"
+
+// Stores groups the storage implementations that share one database, and
+// the blob store holding the files they describe.
+type Stores struct {
+	Videos       VideoStorage
+	Shares       ShareLinkStorage
+	Artifacts    ArtifactStorage
+	Scenes       SceneStorage
+	Intervals    IntervalStorage
+	Fingerprints FingerprintStorage
+	Presets      PresetStorage
+	Operations   OperationStorage
+	Versions     VersionStorage
+	Blobs        BlobStore
+}
+
+func NewStores(db *sql.DB, blobs BlobStore) Stores {
+	return Stores{
+		Videos:       NewVideoStorage(db),
+		Shares:       NewShareLinkStorage(db),
+		Artifacts:    NewArtifactStorage(db),
+		Scenes:       NewSceneStorage(db),
+		Intervals:    NewIntervalStorage(db),
+		Fingerprints: NewFingerprintStorage(db),
+		Presets:      NewPresetStorage(db),
+		Operations:   NewOperationStorage(db),
+		Versions:     NewVersionStorage(db),
+		Blobs:        blobs,
+	}
+}
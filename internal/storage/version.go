@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// VideoVersion is one rendering of a video. The videos row describes the
+// current version; every version, the current one included, keeps a row
+// here until it is pruned.
+type VideoVersion struct {
+	VideoID   string    `json:"video_id"`
+	Version   int       `json:"version"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	Duration  int       `json:"duration"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type VersionStorage interface {
+	// AddVersion records a new version of a video and makes it current,
+	// clearing the measurements taken of the previous version. The version
+	// number is allocated in the same transaction, following the current
+	// version, and set on version.
+	AddVersion(ctx context.Context, version *VideoVersion) error
+	GetVersion(ctx context.Context, videoID string, version int) (*VideoVersion, error)
+	ListVersions(ctx context.Context, videoID string) ([]*VideoVersion, error)
+	DeleteVersion(ctx context.Context, videoID string, version int) error
+}
+
+type SQLiteVersionStorage struct {
+	db *sql.DB
+}
+
+func NewVersionStorage(db *sql.DB) VersionStorage {
+	return &SQLiteVersionStorage{db: db}
+}
+
+func (s *SQLiteVersionStorage) AddVersion(ctx context.Context, version *VideoVersion) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current int
+	query := `SELECT version FROM videos WHERE id = ?`
+	if err := tx.QueryRowContext(ctx, query, version.VideoID).Scan(&current); err != nil {
+		return err
+	}
+	number := current + 1
+
+	query = `
+        INSERT INTO video_versions (video_id, version, filename, size, duration, created_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+    `
+	_, err = tx.ExecContext(ctx, query,
+		version.VideoID,
+		number,
+		version.Filename,
+		version.Size,
+		version.Duration,
+		version.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	query = `
+        UPDATE videos
+        SET filename = ?, size = ?, duration = ?, version = ?,
+            loudness_integrated = NULL, loudness_true_peak = NULL, loudness_range = NULL,
+            quality_source_id = NULL, quality_source_start = NULL,
+            psnr_avg = NULL, psnr_min = NULL, ssim_avg = NULL, ssim_min = NULL
+        WHERE id = ?
+    `
+	_, err = tx.ExecContext(ctx, query,
+		version.Filename,
+		version.Size,
+		version.Duration,
+		number,
+		version.VideoID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	version.Version = number
+	return nil
+}
+
+func (s *SQLiteVersionStorage) GetVersion(ctx context.Context, videoID string, version int) (*VideoVersion, error) {
+	query := `
+        SELECT video_id, version, filename, size, duration, created_at
+        FROM video_versions
+        WHERE video_id = ? AND version = ?
+    `
+	v, err := scanVersion(s.db.QueryRowContext(ctx, query, videoID, version))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListVersions returns the versions of a video that have not been pruned,
+// oldest first.
+func (s *SQLiteVersionStorage) ListVersions(ctx context.Context, videoID string) ([]*VideoVersion, error) {
+	query := `
+        SELECT video_id, version, filename, size, duration, created_at
+        FROM video_versions
+        WHERE video_id = ?
+        ORDER BY version
+    `
+	rows, err := s.db.QueryContext(ctx, query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*VideoVersion
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (s *SQLiteVersionStorage) DeleteVersion(ctx context.Context, videoID string, version int) error {
+	query := `DELETE FROM video_versions WHERE video_id = ? AND version = ?`
+	_, err := s.db.ExecContext(ctx, query, videoID, version)
+	return err
+}
+
+func scanVersion(row rowScanner) (*VideoVersion, error) {
+	var v VideoVersion
+	err := row.Scan(
+		&v.VideoID,
+		&v.Version,
+		&v.Filename,
+		&v.Size,
+		&v.Duration,
+		&v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
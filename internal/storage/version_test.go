@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVersionStorage(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	videos := NewVideoStorage(db)
+	versions := NewVersionStorage(db)
+	ctx := context.Background()
+
+	integrated := -14.0
+	video := &Video{
+		ID:       "video-id",
+		Filename: "video.mp4",
+		Size:     1000,
+		Duration: 60,
+		Status:   StatusCompleted,
+	}
+	if err := videos.SaveVideo(ctx, video); err != nil {
+		t.Fatalf("SaveVideo failed: %v", err)
+	}
+	if err := videos.UpdateVideoLoudness(ctx, video.ID, &Loudness{Integrated: integrated}); err != nil {
+		t.Fatalf("UpdateVideoLoudness failed: %v", err)
+	}
+
+	t.Run("SaveVideo records version 1", func(t *testing.T) {
+		v, err := versions.GetVersion(ctx, video.ID, 1)
+		if err != nil {
+			t.Fatalf("GetVersion failed: %v", err)
+		}
+		if v == nil || v.Filename != "video.mp4" || v.Size != 1000 || v.Duration != 60 {
+			t.Errorf("Unexpected first version %+v", v)
+		}
+	})
+
+	t.Run("AddVersion", func(t *testing.T) {
+		version := &VideoVersion{
+			VideoID:   video.ID,
+			Filename:  "video_v2.mp4",
+			Size:      2000,
+			Duration:  30,
+			CreatedAt: time.Now(),
+		}
+		if err := versions.AddVersion(ctx, version); err != nil {
+			t.Fatalf("AddVersion failed: %v", err)
+		}
+		if version.Version != 2 {
+			t.Errorf("Expected version 2 to be allocated, got %d", version.Version)
+		}
+
+		current, err := videos.GetVideo(ctx, video.ID)
+		if err != nil {
+			t.Fatalf("GetVideo failed: %v", err)
+		}
+		if current.Version != 2 || current.Filename != "video_v2.mp4" || current.Size != 2000 || current.Duration != 30 {
+			t.Errorf("Expected the video to describe version 2, got %+v", current)
+		}
+		if current.Loudness != nil {
+			t.Errorf("Expected the loudness of version 1 to be cleared, got %+v", current.Loudness)
+		}
+	})
+
+	t.Run("AddVersion rejects a missing video", func(t *testing.T) {
+		err := versions.AddVersion(ctx, &VideoVersion{VideoID: "missing", Filename: "other.mp4", CreatedAt: time.Now()})
+		if err == nil {
+			t.Error("Expected an error for a video that does not exist")
+		}
+	})
+
+	t.Run("ListVersions", func(t *testing.T) {
+		list, err := versions.ListVersions(ctx, video.ID)
+		if err != nil {
+			t.Fatalf("ListVersions failed: %v", err)
+		}
+		if len(list) != 2 || list[0].Version != 1 || list[1].Version != 2 {
+			t.Errorf("Expected versions 1 and 2, got %+v", list)
+		}
+	})
+
+	t.Run("DeleteVersion", func(t *testing.T) {
+		if err := versions.DeleteVersion(ctx, video.ID, 1); err != nil {
+			t.Fatalf("DeleteVersion failed: %v", err)
+		}
+		v, err := versions.GetVersion(ctx, video.ID, 1)
+		if err != nil {
+			t.Fatalf("GetVersion failed: %v", err)
+		}
+		if v != nil {
+			t.Errorf("Expected version 1 to be pruned, got %+v", v)
+		}
+	})
+
+	t.Run("AddVersion allocates distinct numbers concurrently", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				v := &VideoVersion{VideoID: video.ID, Filename: fmt.Sprintf("concurrent%d.mp4", i), CreatedAt: time.Now()}
+				if err := versions.AddVersion(ctx, v); err != nil {
+					t.Errorf("AddVersion failed: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		list, err := versions.ListVersions(ctx, video.ID)
+		if err != nil {
+			t.Fatalf("ListVersions failed: %v", err)
+		}
+		if len(list) != 5 || list[len(list)-1].Version != 6 {
+			t.Errorf("Expected versions 2 to 6, got %+v", list)
+		}
+		current, _ := videos.GetVideo(ctx, video.ID)
+		if current.Version != 6 || current.Filename != list[len(list)-1].Filename {
+			t.Errorf("Expected the last version to be current, got %+v", current)
+		}
+	})
+}
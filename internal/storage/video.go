@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -22,7 +24,43 @@ type Video struct {
 	Duration     int         `json:"duration"`
 	CreatedAt    time.Time   `json:"created_at"`
 	Status       VideoStatus `json:"status"`
+	Version      int         `json:"version"`
 	ErrorMessage *string     `json:"error_message,omitempty"`
+	Loudness     *Loudness   `json:"loudness,omitempty"`
+	Quality      *Quality    `json:"quality,omitempty"`
+
+	// ParentID and SegmentIndex are set on the pieces a video was split
+	// into. SegmentIndex counts from 1 in playback order.
+	ParentID     string `json:"parent_id,omitempty"`
+	SegmentIndex int    `json:"segment_index,omitempty"`
+
+	// Filters lists the ffmpeg filters an enhanced video was rendered with.
+	Filters []string `json:"filters,omitempty"`
+
+	// RedactedID is the redacted copy that share links created for this
+	// video point at instead.
+	RedactedID string `json:"redacted_id,omitempty"`
+
+	// SupersededBy is the video that re-rendering this one produced.
+	SupersededBy string `json:"superseded_by,omitempty"`
+}
+
+// Loudness is the EBU R128 measurement recorded for a video's audio track.
+type Loudness struct {
+	Integrated float64 `json:"integrated"`
+	TruePeak   float64 `json:"true_peak"`
+	LRA        float64 `json:"lra"`
+}
+
+// Quality is the PSNR and SSIM measured between a video and the segment of
+// the source it was derived from, starting at SourceStart.
+type Quality struct {
+	SourceID    string  `json:"source_id"`
+	SourceStart float64 `json:"source_start"`
+	PSNRAverage float64 `json:"psnr_avg"`
+	PSNRMin     float64 `json:"psnr_min"`
+	SSIMAverage float64 `json:"ssim_avg"`
+	SSIMMin     float64 `json:"ssim_min"`
 }
 
 type VideoStorage interface {
@@ -30,6 +68,89 @@ type VideoStorage interface {
 	GetVideo(ctx context.Context, id string) (*Video, error)
 	ListVideos(ctx context.Context) ([]*Video, error)
 	UpdateVideoStatus(ctx context.Context, id string, status VideoStatus, errorMsg *string) error
+	UpdateVideoLoudness(ctx context.Context, id string, loudness *Loudness) error
+	UpdateVideoQuality(ctx context.Context, id string, quality *Quality) error
+	ListSegments(ctx context.Context, parentID string) ([]*Video, error)
+	UpdateVideoRedaction(ctx context.Context, id, redactedID string) error
+	UpdateVideoSupersededBy(ctx context.Context, id, supersededBy string) error
+	DeleteVideo(ctx context.Context, id string) error
+}
+
+const videoColumns = `id, filename, size, duration, created_at, status, error_message,
+        loudness_integrated, loudness_true_peak, loudness_range,
+        quality_source_id, quality_source_start, psnr_avg, psnr_min, ssim_avg, ssim_min,
+        parent_id, segment_index, filters, redacted_id, superseded_by, version`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanVideo(row rowScanner) (*Video, error) {
+	var video Video
+	var errorMsg sql.NullString
+	var integrated, truePeak, lra sql.NullFloat64
+	var qualitySource sql.NullString
+	var qualityStart, psnrAvg, psnrMin, ssimAvg, ssimMin sql.NullFloat64
+	var parentID sql.NullString
+	var segmentIndex sql.NullInt64
+	var filters, redactedID, supersededBy sql.NullString
+	err := row.Scan(
+		&video.ID,
+		&video.Filename,
+		&video.Size,
+		&video.Duration,
+		&video.CreatedAt,
+		&video.Status,
+		&errorMsg,
+		&integrated,
+		&truePeak,
+		&lra,
+		&qualitySource,
+		&qualityStart,
+		&psnrAvg,
+		&psnrMin,
+		&ssimAvg,
+		&ssimMin,
+		&parentID,
+		&segmentIndex,
+		&filters,
+		&redactedID,
+		&supersededBy,
+		&video.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if errorMsg.Valid {
+		video.ErrorMessage = &errorMsg.String
+	}
+	if integrated.Valid {
+		video.Loudness = &Loudness{
+			Integrated: integrated.Float64,
+			TruePeak:   truePeak.Float64,
+			LRA:        lra.Float64,
+		}
+	}
+	if qualitySource.Valid {
+		video.Quality = &Quality{
+			SourceID:    qualitySource.String,
+			SourceStart: qualityStart.Float64,
+			PSNRAverage: psnrAvg.Float64,
+			PSNRMin:     psnrMin.Float64,
+			SSIMAverage: ssimAvg.Float64,
+			SSIMMin:     ssimMin.Float64,
+		}
+	}
+	video.ParentID = parentID.String
+	video.SegmentIndex = int(segmentIndex.Int64)
+	video.RedactedID = redactedID.String
+	video.SupersededBy = supersededBy.String
+	if filters.Valid {
+		if err := json.Unmarshal([]byte(filters.String), &video.Filters); err != nil {
+			return nil, fmt.Errorf("invalid filters for video %s: %w", video.ID, err)
+		}
+	}
+	return &video, nil
 }
 
 type SQLiteVideoStorage struct {
@@ -40,54 +161,80 @@ func NewVideoStorage(db *sql.DB) VideoStorage {
 	return &SQLiteVideoStorage{db: db}
 }
 
+// SaveVideo records a new video as version 1 of itself.
 func (s *SQLiteVideoStorage) SaveVideo(ctx context.Context, video *Video) error {
+	video.Version = 1
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-        INSERT INTO videos (id, filename, size, duration, status, error_message)
-        VALUES (?, ?, ?, ?, ?, ?)
+        INSERT INTO videos (id, filename, size, duration, status, error_message, parent_id, segment_index, filters, version)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
     `
-	_, err := s.db.ExecContext(ctx, query,
+	var parentID *string
+	var segmentIndex *int
+	if video.ParentID != "" {
+		parentID, segmentIndex = &video.ParentID, &video.SegmentIndex
+	}
+	var filters *string
+	if len(video.Filters) > 0 {
+		data, err := json.Marshal(video.Filters)
+		if err != nil {
+			return err
+		}
+		encoded := string(data)
+		filters = &encoded
+	}
+	_, err = tx.ExecContext(ctx, query,
 		video.ID,
 		video.Filename,
 		video.Size,
 		video.Duration,
 		video.Status,
 		video.ErrorMessage,
+		parentID,
+		segmentIndex,
+		filters,
+		video.Version,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	query = `
+        INSERT INTO video_versions (video_id, version, filename, size, duration)
+        VALUES (?, ?, ?, ?, ?)
+    `
+	if _, err := tx.ExecContext(ctx, query, video.ID, video.Version, video.Filename, video.Size, video.Duration); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *SQLiteVideoStorage) GetVideo(ctx context.Context, id string) (*Video, error) {
 	query := `
-        SELECT id, filename, size, duration, created_at, status, error_message
+        SELECT ` + videoColumns + `
         FROM videos
         WHERE id = ?
     `
-	var video Video
-	var errorMsg sql.NullString
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&video.ID,
-		&video.Filename,
-		&video.Size,
-		&video.Duration,
-		&video.CreatedAt,
-		&video.Status,
-		&errorMsg,
-	)
+	video, err := scanVideo(s.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	if errorMsg.Valid {
-		video.ErrorMessage = &errorMsg.String
-	}
-	return &video, nil
+	return video, nil
 }
 
 func (s *SQLiteVideoStorage) ListVideos(ctx context.Context) ([]*Video, error) {
 	query := `
-        SELECT id, filename, size, duration, created_at, status, error_message
+        SELECT ` + videoColumns + `
         FROM videos
         ORDER BY created_at DESC
     `
@@ -99,24 +246,11 @@ func (s *SQLiteVideoStorage) ListVideos(ctx context.Context) ([]*Video, error) {
 
 	var videos []*Video
 	for rows.Next() {
-		var video Video
-		var errorMsg sql.NullString
-		err := rows.Scan(
-			&video.ID,
-			&video.Filename,
-			&video.Size,
-			&video.Duration,
-			&video.CreatedAt,
-			&video.Status,
-			&errorMsg,
-		)
+		video, err := scanVideo(rows)
 		if err != nil {
 			return nil, err
 		}
-		if errorMsg.Valid {
-			video.ErrorMessage = &errorMsg.String
-		}
-		videos = append(videos, &video)
+		videos = append(videos, video)
 	}
 	return videos, rows.Err()
 }
@@ -130,3 +264,81 @@ func (s *SQLiteVideoStorage) UpdateVideoStatus(ctx context.Context, id string, s
 	_, err := s.db.ExecContext(ctx, query, status, errorMsg, id)
 	return err
 }
+
+func (s *SQLiteVideoStorage) UpdateVideoLoudness(ctx context.Context, id string, loudness *Loudness) error {
+	query := `
+        UPDATE videos
+        SET loudness_integrated = ?, loudness_true_peak = ?, loudness_range = ?
+        WHERE id = ?
+    `
+	_, err := s.db.ExecContext(ctx, query, loudness.Integrated, loudness.TruePeak, loudness.LRA, id)
+	return err
+}
+
+func (s *SQLiteVideoStorage) UpdateVideoQuality(ctx context.Context, id string, quality *Quality) error {
+	query := `
+        UPDATE videos
+        SET quality_source_id = ?, quality_source_start = ?, psnr_avg = ?, psnr_min = ?, ssim_avg = ?, ssim_min = ?
+        WHERE id = ?
+    `
+	_, err := s.db.ExecContext(ctx, query, quality.SourceID, quality.SourceStart,
+		quality.PSNRAverage, quality.PSNRMin, quality.SSIMAverage, quality.SSIMMin, id)
+	return err
+}
+
+// UpdateVideoRedaction records the redacted copy that replaces a video in
+// share links created from now on.
+func (s *SQLiteVideoStorage) UpdateVideoRedaction(ctx context.Context, id, redactedID string) error {
+	query := `
+        UPDATE videos
+        SET redacted_id = ?
+        WHERE id = ?
+    `
+	_, err := s.db.ExecContext(ctx, query, redactedID, id)
+	return err
+}
+
+// UpdateVideoSupersededBy records the video that replaced this one when it
+// was rendered again.
+func (s *SQLiteVideoStorage) UpdateVideoSupersededBy(ctx context.Context, id, supersededBy string) error {
+	query := `
+        UPDATE videos
+        SET superseded_by = ?
+        WHERE id = ?
+    `
+	_, err := s.db.ExecContext(ctx, query, supersededBy, id)
+	return err
+}
+
+// DeleteVideo removes a video along with the rows that cascade from it. Its
+// file is left to the caller.
+func (s *SQLiteVideoStorage) DeleteVideo(ctx context.Context, id string) error {
+	query := `DELETE FROM videos WHERE id = ?`
+	_, err := s.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// ListSegments returns the videos a parent was split into, in playback order.
+func (s *SQLiteVideoStorage) ListSegments(ctx context.Context, parentID string) ([]*Video, error) {
+	query := `
+        SELECT ` + videoColumns + `
+        FROM videos
+        WHERE parent_id = ?
+        ORDER BY segment_index
+    `
+	rows, err := s.db.QueryContext(ctx, query, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []*Video
+	for rows.Next() {
+		video, err := scanVideo(rows)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"reflect"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -13,6 +14,9 @@ func setupTestDB(t *testing.T) (*sql.DB, func()) {
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
+	// Each connection to :memory: opens its own database, and SaveVideo
+	// writes in a transaction.
+	db.SetMaxOpenConns(1)
 
 	_, err = db.Exec(`
         CREATE TABLE IF NOT EXISTS videos (
@@ -22,12 +26,38 @@ func setupTestDB(t *testing.T) (*sql.DB, func()) {
             duration INTEGER NOT NULL,
             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
             status TEXT NOT NULL,
-            error_message TEXT
+            error_message TEXT,
+            loudness_integrated REAL,
+            loudness_true_peak REAL,
+            loudness_range REAL,
+            quality_source_id TEXT,
+            quality_source_start REAL,
+            psnr_avg REAL,
+            psnr_min REAL,
+            ssim_avg REAL,
+            ssim_min REAL,
+            parent_id TEXT,
+            segment_index INTEGER,
+            filters TEXT,
+            redacted_id TEXT,
+            superseded_by TEXT,
+            version INTEGER NOT NULL DEFAULT 1
+        );
+
+        CREATE TABLE IF NOT EXISTS video_versions (
+            video_id TEXT NOT NULL,
+            version INTEGER NOT NULL,
+            filename TEXT NOT NULL,
+            size INTEGER NOT NULL,
+            duration INTEGER NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (video_id, version)
         );
 
         CREATE TABLE IF NOT EXISTS share_links (
             id TEXT PRIMARY KEY,
             video_id TEXT NOT NULL,
+            version INTEGER,
             expires_at TIMESTAMP NOT NULL,
             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
             FOREIGN KEY (video_id) REFERENCES videos(id)
@@ -152,6 +182,148 @@ func TestVideoStorage(t *testing.T) {
 		}
 	})
 
+	t.Run("UpdateVideoLoudness", func(t *testing.T) {
+		videoID := "test-loudness"
+		video := &Video{
+			ID:       videoID,
+			Filename: "test.mp4",
+			Size:     1000,
+			Duration: 60,
+			Status:   StatusCompleted,
+		}
+
+		if err := storage.SaveVideo(ctx, video); err != nil {
+			t.Fatalf("Failed to save test video: %v", err)
+		}
+
+		loudness := &Loudness{Integrated: -23.4, TruePeak: -2.1, LRA: 7.5}
+		if err := storage.UpdateVideoLoudness(ctx, videoID, loudness); err != nil {
+			t.Fatalf("UpdateVideoLoudness failed: %v", err)
+		}
+
+		updated, err := storage.GetVideo(ctx, videoID)
+		if err != nil {
+			t.Fatalf("Failed to get updated video: %v", err)
+		}
+		if updated.Loudness == nil || *updated.Loudness != *loudness {
+			t.Errorf("Expected loudness %+v, got %+v", loudness, updated.Loudness)
+		}
+	})
+
+	t.Run("UpdateVideoQuality", func(t *testing.T) {
+		videoID := "test-quality"
+		video := &Video{
+			ID:       videoID,
+			Filename: "test.mp4",
+			Size:     1000,
+			Duration: 60,
+			Status:   StatusCompleted,
+		}
+
+		if err := storage.SaveVideo(ctx, video); err != nil {
+			t.Fatalf("Failed to save test video: %v", err)
+		}
+
+		quality := &Quality{SourceID: "source", SourceStart: 2.5, PSNRAverage: 41.2, PSNRMin: 35.8, SSIMAverage: 0.982, SSIMMin: 0.951}
+		if err := storage.UpdateVideoQuality(ctx, videoID, quality); err != nil {
+			t.Fatalf("UpdateVideoQuality failed: %v", err)
+		}
+
+		updated, err := storage.GetVideo(ctx, videoID)
+		if err != nil {
+			t.Fatalf("Failed to get updated video: %v", err)
+		}
+		if updated.Quality == nil || *updated.Quality != *quality {
+			t.Errorf("Expected quality %+v, got %+v", quality, updated.Quality)
+		}
+	})
+
+	t.Run("UpdateVideoRedaction", func(t *testing.T) {
+		if err := storage.UpdateVideoRedaction(ctx, "test-quality", "redacted-id"); err != nil {
+			t.Fatalf("UpdateVideoRedaction failed: %v", err)
+		}
+
+		updated, err := storage.GetVideo(ctx, "test-quality")
+		if err != nil {
+			t.Fatalf("Failed to get updated video: %v", err)
+		}
+		if updated.RedactedID != "redacted-id" {
+			t.Errorf("Expected redacted ID redacted-id, got %q", updated.RedactedID)
+		}
+	})
+
+	t.Run("UpdateVideoSupersededBy", func(t *testing.T) {
+		if err := storage.UpdateVideoSupersededBy(ctx, "test-quality", "rerendered-id"); err != nil {
+			t.Fatalf("UpdateVideoSupersededBy failed: %v", err)
+		}
+
+		updated, err := storage.GetVideo(ctx, "test-quality")
+		if err != nil {
+			t.Fatalf("Failed to get updated video: %v", err)
+		}
+		if updated.SupersededBy != "rerendered-id" {
+			t.Errorf("Expected superseded by rerendered-id, got %q", updated.SupersededBy)
+		}
+	})
+
+	t.Run("ListSegments", func(t *testing.T) {
+		for _, video := range []*Video{
+			{ID: "segment-2", Filename: "b.mp4", Size: 10, Duration: 5, Status: StatusCompleted, ParentID: "test-id", SegmentIndex: 2},
+			{ID: "segment-1", Filename: "a.mp4", Size: 10, Duration: 5, Status: StatusCompleted, ParentID: "test-id", SegmentIndex: 1},
+		} {
+			if err := storage.SaveVideo(ctx, video); err != nil {
+				t.Fatalf("Failed to save segment: %v", err)
+			}
+		}
+
+		segments, err := storage.ListSegments(ctx, "test-id")
+		if err != nil {
+			t.Fatalf("ListSegments failed: %v", err)
+		}
+		if len(segments) != 2 || segments[0].ID != "segment-1" || segments[1].ID != "segment-2" {
+			t.Fatalf("Expected segments in order, got %+v", segments)
+		}
+		if segments[0].ParentID != "test-id" || segments[0].SegmentIndex != 1 {
+			t.Errorf("Expected parent reference on segment, got %+v", segments[0])
+		}
+
+		parent, err := storage.GetVideo(ctx, "test-id")
+		if err != nil || parent.ParentID != "" || parent.SegmentIndex != 0 {
+			t.Errorf("Expected no parent on the source video, got %+v, %v", parent, err)
+		}
+	})
+
+	t.Run("SaveWithFilters", func(t *testing.T) {
+		enhanced := &Video{
+			ID:       "enhanced-id",
+			Filename: "enhanced.mp4",
+			Size:     10,
+			Duration: 5,
+			Status:   StatusCompleted,
+			Filters:  []string{"deshake", "eq=brightness=0.1:gamma=1.2"},
+		}
+		if err := storage.SaveVideo(ctx, enhanced); err != nil {
+			t.Fatalf("Failed to save video: %v", err)
+		}
+
+		got, err := storage.GetVideo(ctx, "enhanced-id")
+		if err != nil {
+			t.Fatalf("Failed to get video: %v", err)
+		}
+		if !reflect.DeepEqual(got.Filters, enhanced.Filters) {
+			t.Errorf("Expected filters %v, got %v", enhanced.Filters, got.Filters)
+		}
+	})
+
+	t.Run("DeleteVideo", func(t *testing.T) {
+		if err := storage.DeleteVideo(ctx, "enhanced-id"); err != nil {
+			t.Fatalf("DeleteVideo failed: %v", err)
+		}
+		if got, err := storage.GetVideo(ctx, "enhanced-id"); err != nil || got != nil {
+			t.Errorf("Expected the video to be deleted, got %+v, %v", got, err)
+		}
+	})
+
 	t.Run("GetNonExistentVideo", func(t *testing.T) {
 		video, err := storage.GetVideo(ctx, "non-existent-id")
 		if err != nil {
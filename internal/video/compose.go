@@ -0,0 +1,239 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	LayoutPIP    = "pip"
+	LayoutHStack = "hstack"
+	LayoutVStack = "vstack"
+	LayoutGrid   = "grid"
+
+	AudioBase    = "base"
+	AudioOverlay = "overlay"
+	AudioMix     = "mix"
+	AudioNone    = "none"
+
+	DurationShortest = "shortest"
+	DurationLongest  = "longest"
+
+	MaxComposeInputs = 4
+)
+
+var ErrInvalidCompose = errors.New("invalid compose options")
+
+var pipCorners = map[string]string{
+	"top-left":     "%[1]d:%[1]d",
+	"top-right":    "W-w-%[1]d:%[1]d",
+	"bottom-left":  "%[1]d:H-h-%[1]d",
+	"bottom-right": "W-w-%[1]d:H-h-%[1]d",
+}
+
+// ComposeOptions describe how several videos are combined into one picture.
+// The first input is the base: it sets the output size and, for
+// picture-in-picture, is the background the second input is placed over.
+// Audio selects the base's audio, the first overlay's, a mix of all of them
+// or none, and Duration decides whether the output ends with the shortest
+// input or lasts as long as the longest, holding the last frame of the
+// others.
+type ComposeOptions struct {
+	Layout   string  `json:"layout"`
+	Corner   string  `json:"corner,omitempty"`
+	Scale    float64 `json:"scale,omitempty"`
+	Margin   int     `json:"margin,omitempty"`
+	Audio    string  `json:"audio,omitempty"`
+	Duration string  `json:"duration,omitempty"`
+}
+
+// ComposeInput is a source video together with the probed properties the
+// filtergraph depends on. Width and Height are the displayed size.
+type ComposeInput struct {
+	Path     string
+	Width    int
+	Height   int
+	Duration float64
+	HasAudio bool
+}
+
+// Validate checks the options for the given number of inputs and fills in
+// the defaults.
+func (o *ComposeOptions) Validate(inputs int) error {
+	if inputs < 2 || inputs > MaxComposeInputs {
+		return fmt.Errorf("%w: between 2 and %d videos are required", ErrInvalidCompose, MaxComposeInputs)
+	}
+
+	switch o.Layout {
+	case LayoutPIP:
+		if inputs != 2 {
+			return fmt.Errorf("%w: picture-in-picture takes exactly one overlay", ErrInvalidCompose)
+		}
+		if o.Corner == "" {
+			o.Corner = "bottom-right"
+		}
+		if _, ok := pipCorners[o.Corner]; !ok {
+			return fmt.Errorf("%w: corner must be top-left, top-right, bottom-left or bottom-right", ErrInvalidCompose)
+		}
+		if o.Scale == 0 {
+			o.Scale = 0.25
+		}
+		if o.Scale < 0.1 || o.Scale > 0.5 {
+			return fmt.Errorf("%w: scale must be between 0.1 and 0.5", ErrInvalidCompose)
+		}
+		if o.Margin < 0 || o.Margin > 200 {
+			return fmt.Errorf("%w: margin must be between 0 and 200", ErrInvalidCompose)
+		}
+	case LayoutHStack, LayoutVStack, LayoutGrid:
+		if o.Corner != "" || o.Scale != 0 || o.Margin != 0 {
+			return fmt.Errorf("%w: corner, scale and margin only apply to pip", ErrInvalidCompose)
+		}
+	default:
+		return fmt.Errorf("%w: layout must be pip, hstack, vstack or grid", ErrInvalidCompose)
+	}
+
+	switch o.Audio {
+	case "":
+		o.Audio = AudioBase
+	case AudioBase, AudioOverlay, AudioMix, AudioNone:
+	default:
+		return fmt.Errorf("%w: audio must be base, overlay, mix or none", ErrInvalidCompose)
+	}
+
+	switch o.Duration {
+	case "":
+		o.Duration = DurationShortest
+	case DurationShortest, DurationLongest:
+	default:
+		return fmt.Errorf("%w: duration must be shortest or longest", ErrInvalidCompose)
+	}
+
+	return nil
+}
+
+// OutputDuration returns how long the composed video will be.
+func (o ComposeOptions) OutputDuration(inputs []ComposeInput) float64 {
+	duration := inputs[0].Duration
+	for _, in := range inputs[1:] {
+		if o.Duration == DurationLongest {
+			duration = math.Max(duration, in.Duration)
+		} else {
+			duration = math.Min(duration, in.Duration)
+		}
+	}
+	return duration
+}
+
+func (p *FFmpegProcessor) Compose(ctx context.Context, inputs []ComposeInput, outputPath string, opts ComposeOptions) error {
+	var args []string
+	for _, in := range inputs {
+		args = append(args, "-i", in.Path)
+	}
+
+	filter, hasAudio := composeFilter(inputs, opts)
+	args = append(args, "-filter_complex", filter, "-map", "[v]")
+	if hasAudio {
+		args = append(args, "-map", "[a]")
+	}
+	args = append(args, "-t", fmt.Sprintf("%.3f", opts.OutputDuration(inputs)))
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to compose videos: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// composeFilter builds the filtergraph for validated options. Every input is
+// first held on its last frame until the output duration, so the layout
+// filters never end early; the output is then cut to length with -t. It
+// reports whether the graph has an audio output.
+func composeFilter(inputs []ComposeInput, opts ComposeOptions) (string, bool) {
+	duration := opts.OutputDuration(inputs)
+	base := inputs[0]
+
+	var graph []string
+	prepare := func(i int, scale string) string {
+		chain := []string{}
+		if hold := duration - inputs[i].Duration; hold > 0 {
+			chain = append(chain, fmt.Sprintf("tpad=stop_mode=clone:stop_duration=%.3f", hold))
+		}
+		if scale != "" {
+			chain = append(chain, scale)
+		}
+		chain = append(chain, "setsar=1")
+		graph = append(graph, fmt.Sprintf("[%d:v]%s[v%d]", i, strings.Join(chain, ","), i))
+		return fmt.Sprintf("[v%d]", i)
+	}
+
+	switch opts.Layout {
+	case LayoutPIP:
+		width := even(float64(base.Width) * opts.Scale)
+		background := prepare(0, "")
+		overlay := prepare(1, fmt.Sprintf("scale=%d:-2", width))
+		position := fmt.Sprintf(pipCorners[opts.Corner], opts.Margin)
+		graph = append(graph, fmt.Sprintf("%s%soverlay=%s[v]", background, overlay, position))
+	case LayoutHStack, LayoutVStack:
+		scale := fmt.Sprintf("scale=-2:%d", even(float64(base.Height)))
+		if opts.Layout == LayoutVStack {
+			scale = fmt.Sprintf("scale=%d:-2", even(float64(base.Width)))
+		}
+		var labels string
+		for i := range inputs {
+			labels += prepare(i, scale)
+		}
+		graph = append(graph, fmt.Sprintf("%s%s=inputs=%d[v]", labels, opts.Layout, len(inputs)))
+	case LayoutGrid:
+		// Each input is fitted into a cell half the size of the base, so the
+		// 2x2 grid has the base's dimensions. Unused cells are left black.
+		w, h := even(float64(base.Width)/2), even(float64(base.Height)/2)
+		fit := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease:force_divisible_by=2,pad=%d:%d:(ow-iw)/2:(oh-ih)/2", w, h, w, h)
+		var labels string
+		for i := range inputs {
+			labels += prepare(i, fit)
+		}
+		cells := []string{"0_0", "w0_0", "0_h0", "w0_h0"}[:len(inputs)]
+		graph = append(graph, fmt.Sprintf("%sxstack=inputs=%d:layout=%s:fill=black[v]", labels, len(inputs), strings.Join(cells, "|")))
+	}
+
+	var sources []int
+	switch opts.Audio {
+	case AudioBase:
+		sources = []int{0}
+	case AudioOverlay:
+		sources = []int{1}
+	case AudioMix:
+		for i := range inputs {
+			sources = append(sources, i)
+		}
+	}
+
+	var audio string
+	var withAudio int
+	for _, i := range sources {
+		if inputs[i].HasAudio {
+			audio += fmt.Sprintf("[%d:a]", i)
+			withAudio++
+		}
+	}
+
+	switch {
+	case withAudio == 0:
+		return strings.Join(graph, ";"), false
+	case withAudio == 1:
+		graph = append(graph, audio+"apad[a]")
+	default:
+		graph = append(graph, fmt.Sprintf("%samix=inputs=%d:duration=longest,apad[a]", audio, withAudio))
+	}
+	return strings.Join(graph, ";"), true
+}
+
+// even rounds a dimension down to the nearest even number, as required by
+// the H.264 encoder.
+func even(v float64) int {
+	return int(v) / 2 * 2
+}
@@ -0,0 +1,118 @@
+package video
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestComposeOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ComposeOptions
+		inputs  int
+		wantErr bool
+	}{
+		{"pip defaults", ComposeOptions{Layout: LayoutPIP}, 2, false},
+		{"hstack of four", ComposeOptions{Layout: LayoutHStack, Audio: AudioMix, Duration: DurationLongest}, 4, false},
+		{"grid of three", ComposeOptions{Layout: LayoutGrid}, 3, false},
+		{"single input", ComposeOptions{Layout: LayoutHStack}, 1, true},
+		{"five inputs", ComposeOptions{Layout: LayoutGrid}, 5, true},
+		{"pip with two overlays", ComposeOptions{Layout: LayoutPIP}, 3, true},
+		{"pip scale too large", ComposeOptions{Layout: LayoutPIP, Scale: 0.8}, 2, true},
+		{"unknown corner", ComposeOptions{Layout: LayoutPIP, Corner: "middle"}, 2, true},
+		{"scale on stack", ComposeOptions{Layout: LayoutVStack, Scale: 0.3}, 2, true},
+		{"unknown layout", ComposeOptions{Layout: "diagonal"}, 2, true},
+		{"unknown audio", ComposeOptions{Layout: LayoutHStack, Audio: "left"}, 2, true},
+		{"unknown duration", ComposeOptions{Layout: LayoutHStack, Duration: "average"}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate(tt.inputs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCompose) {
+				t.Errorf("Validate() error = %v, want ErrInvalidCompose", err)
+			}
+		})
+	}
+
+	pip := ComposeOptions{Layout: LayoutPIP}
+	pip.Validate(2)
+	if pip.Corner != "bottom-right" || pip.Scale != 0.25 || pip.Audio != AudioBase || pip.Duration != DurationShortest {
+		t.Errorf("Validate() defaults = %+v", pip)
+	}
+}
+
+func TestComposeFilter(t *testing.T) {
+	base := ComposeInput{Width: 1280, Height: 720, Duration: 10, HasAudio: true}
+	overlay := ComposeInput{Width: 640, Height: 360, Duration: 6, HasAudio: true}
+	silent := ComposeInput{Width: 720, Height: 1280, Duration: 8}
+
+	tests := []struct {
+		name      string
+		inputs    []ComposeInput
+		opts      ComposeOptions
+		want      string
+		wantAudio bool
+	}{
+		{
+			name:   "pip shortest",
+			inputs: []ComposeInput{base, overlay},
+			opts:   ComposeOptions{Layout: LayoutPIP, Corner: "top-right", Scale: 0.25, Margin: 20, Audio: AudioBase, Duration: DurationShortest},
+			want: "[0:v]setsar=1[v0];[1:v]scale=320:-2,setsar=1[v1];" +
+				"[v0][v1]overlay=W-w-20:20[v];[0:a]apad[a]",
+			wantAudio: true,
+		},
+		{
+			name:   "hstack longest holds shorter inputs",
+			inputs: []ComposeInput{base, overlay},
+			opts:   ComposeOptions{Layout: LayoutHStack, Audio: AudioOverlay, Duration: DurationLongest},
+			want: "[0:v]scale=-2:720,setsar=1[v0];" +
+				"[1:v]tpad=stop_mode=clone:stop_duration=4.000,scale=-2:720,setsar=1[v1];" +
+				"[v0][v1]hstack=inputs=2[v];[1:a]apad[a]",
+			wantAudio: true,
+		},
+		{
+			name:   "vstack mix skips silent inputs",
+			inputs: []ComposeInput{base, silent, overlay},
+			opts:   ComposeOptions{Layout: LayoutVStack, Audio: AudioMix, Duration: DurationShortest},
+			want: "[0:v]scale=1280:-2,setsar=1[v0];[1:v]scale=1280:-2,setsar=1[v1];[2:v]scale=1280:-2,setsar=1[v2];" +
+				"[v0][v1][v2]vstack=inputs=3[v];[0:a][2:a]amix=inputs=2:duration=longest,apad[a]",
+			wantAudio: true,
+		},
+		{
+			name:   "grid of three without audio",
+			inputs: []ComposeInput{base, overlay, silent},
+			opts:   ComposeOptions{Layout: LayoutGrid, Audio: AudioNone, Duration: DurationShortest},
+			want: "[0:v]scale=640:360:force_original_aspect_ratio=decrease:force_divisible_by=2,pad=640:360:(ow-iw)/2:(oh-ih)/2,setsar=1[v0];" +
+				"[1:v]scale=640:360:force_original_aspect_ratio=decrease:force_divisible_by=2,pad=640:360:(ow-iw)/2:(oh-ih)/2,setsar=1[v1];" +
+				"[2:v]scale=640:360:force_original_aspect_ratio=decrease:force_divisible_by=2,pad=640:360:(ow-iw)/2:(oh-ih)/2,setsar=1[v2];" +
+				"[v0][v1][v2]xstack=inputs=3:layout=0_0|w0_0|0_h0:fill=black[v]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hasAudio := composeFilter(tt.inputs, tt.opts)
+			if got != tt.want {
+				t.Errorf("composeFilter() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if hasAudio != tt.wantAudio {
+				t.Errorf("hasAudio = %v, want %v", hasAudio, tt.wantAudio)
+			}
+		})
+	}
+}
+
+func TestComposeOutputDuration(t *testing.T) {
+	inputs := []ComposeInput{{Duration: 10}, {Duration: 6}, {Duration: 12}}
+
+	if got := (ComposeOptions{Duration: DurationShortest}).OutputDuration(inputs); got != 6 {
+		t.Errorf("shortest = %v, want 6", got)
+	}
+	if got := (ComposeOptions{Duration: DurationLongest}).OutputDuration(inputs); got != 12 {
+		t.Errorf("longest = %v, want 12", got)
+	}
+}
@@ -0,0 +1,189 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// boundaryTolerance is how close to either end of a video an interval has to
+// be to count as leading or trailing.
+const boundaryTolerance = 0.1
+
+var ErrNoContent = errors.New("video contains no content outside silence and black frames")
+
+// Interval is a time range in seconds.
+type Interval struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// DeadAirOptions configures silencedetect and blackdetect.
+type DeadAirOptions struct {
+	NoiseDB        float64 `json:"noise_db"`
+	MinSilence     float64 `json:"min_silence"`
+	MinBlack       float64 `json:"min_black"`
+	PixelThreshold float64 `json:"pixel_threshold"`
+}
+
+var DefaultDeadAirOptions = DeadAirOptions{
+	NoiseDB:        -50,
+	MinSilence:     0.5,
+	MinBlack:       0.5,
+	PixelThreshold: 0.10,
+}
+
+func (o DeadAirOptions) Validate() error {
+	if o.NoiseDB < -90 || o.NoiseDB > 0 {
+		return fmt.Errorf("noise_db must be between -90 and 0")
+	}
+	if o.MinSilence <= 0 || o.MinBlack <= 0 {
+		return fmt.Errorf("minimum durations must be positive")
+	}
+	if o.PixelThreshold < 0 || o.PixelThreshold > 1 {
+		return fmt.Errorf("pixel_threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// DeadAir holds the silent and black intervals found in a video.
+type DeadAir struct {
+	Silence []Interval `json:"silence"`
+	Black   []Interval `json:"black"`
+}
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?[0-9.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: (-?[0-9.]+)`)
+	blackPattern        = regexp.MustCompile(`black_start:(-?[0-9.]+) black_end:(-?[0-9.]+)`)
+)
+
+// DetectDeadAir runs silencedetect and blackdetect in a single decoding pass.
+// Streams the video does not have are skipped.
+func (p *FFmpegProcessor) DetectDeadAir(ctx context.Context, inputPath string, duration float64, opts DeadAirOptions) (*DeadAir, error) {
+	args := []string{
+		"-hide_banner",
+		"-nostats",
+		"-i", inputPath,
+		"-map", "0:v:0?",
+		"-map", "0:a:0?",
+		"-vf", fmt.Sprintf("blackdetect=d=%g:pix_th=%g", opts.MinBlack, opts.PixelThreshold),
+		"-af", fmt.Sprintf("silencedetect=noise=%gdB:d=%g", opts.NoiseDB, opts.MinSilence),
+		"-f", "null",
+		"-",
+	}
+
+	output, err := p.runFFmpeg(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect dead air: %w, output: %s", err, string(output))
+	}
+
+	return parseDeadAirOutput(output, duration)
+}
+
+// parseDeadAirOutput reads silencedetect and blackdetect log lines. Silence
+// that is still open when the input ends is closed at duration.
+func parseDeadAirOutput(output []byte, duration float64) (*DeadAir, error) {
+	result := &DeadAir{}
+	silenceStart := -1.0
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := silenceStartPattern.FindStringSubmatch(line); m != nil {
+			start, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid silence_start %q: %w", m[1], err)
+			}
+			silenceStart = max(start, 0)
+		}
+
+		if m := silenceEndPattern.FindStringSubmatch(line); m != nil && silenceStart >= 0 {
+			end, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid silence_end %q: %w", m[1], err)
+			}
+			result.Silence = append(result.Silence, Interval{Start: silenceStart, End: end})
+			silenceStart = -1
+		}
+
+		if m := blackPattern.FindStringSubmatch(line); m != nil {
+			start, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid black_start %q: %w", m[1], err)
+			}
+			end, err := strconv.ParseFloat(m[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid black_end %q: %w", m[2], err)
+			}
+			result.Black = append(result.Black, Interval{Start: max(start, 0), End: end})
+		}
+	}
+
+	if silenceStart >= 0 && silenceStart < duration {
+		result.Silence = append(result.Silence, Interval{Start: silenceStart, End: duration})
+	}
+
+	return result, scanner.Err()
+}
+
+// ContentBounds returns the range left after removing leading and trailing
+// dead air: intervals that are both silent and black. A silent intro over a
+// picture, or a black screen with narration, is content.
+func ContentBounds(silence, black []Interval, duration float64) (start, end float64, err error) {
+	dead := intersectIntervals(mergeIntervals(silence), mergeIntervals(black))
+	start, end = 0, duration
+
+	if len(dead) > 0 && dead[0].Start <= boundaryTolerance {
+		start = dead[0].End
+	}
+	if len(dead) > 0 && dead[len(dead)-1].End >= duration-boundaryTolerance {
+		end = dead[len(dead)-1].Start
+	}
+
+	if start >= end {
+		return 0, 0, ErrNoContent
+	}
+	return start, end, nil
+}
+
+// mergeIntervals returns the union of intervals as a sorted list of
+// non-overlapping ranges.
+func mergeIntervals(intervals []Interval) []Interval {
+	sorted := append([]Interval(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var merged []Interval
+	for _, in := range sorted {
+		if n := len(merged); n > 0 && in.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, in.End)
+			continue
+		}
+		merged = append(merged, in)
+	}
+	return merged
+}
+
+// intersectIntervals returns the ranges covered by both a and b, which must
+// be sorted and non-overlapping as mergeIntervals returns them.
+func intersectIntervals(a, b []Interval) []Interval {
+	var result []Interval
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, end := max(a[i].Start, b[j].Start), min(a[i].End, b[j].End)
+		if start < end {
+			result = append(result, Interval{Start: start, End: end})
+		}
+		if a[i].End < b[j].End {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package video
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseDeadAirOutput(t *testing.T) {
+	output := []byte(`[blackdetect @ 0x55c8] black_start:0 black_end:2.002 black_duration:2.002
+[silencedetect @ 0x55c9] silence_start: -0.0123
+[silencedetect @ 0x55c9] silence_end: 1.5 | silence_duration: 1.5123
+[blackdetect @ 0x55c8] black_start:14.2 black_end:15 black_duration:0.8
+[silencedetect @ 0x55c9] silence_start: 12.75
+`)
+
+	dead, err := parseDeadAirOutput(output, 15)
+	if err != nil {
+		t.Fatalf("parseDeadAirOutput failed: %v", err)
+	}
+
+	wantSilence := []Interval{{Start: 0, End: 1.5}, {Start: 12.75, End: 15}}
+	wantBlack := []Interval{{Start: 0, End: 2.002}, {Start: 14.2, End: 15}}
+	if !reflect.DeepEqual(dead.Silence, wantSilence) {
+		t.Errorf("Silence = %+v, want %+v", dead.Silence, wantSilence)
+	}
+	if !reflect.DeepEqual(dead.Black, wantBlack) {
+		t.Errorf("Black = %+v, want %+v", dead.Black, wantBlack)
+	}
+}
+
+func TestContentBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		silence   []Interval
+		black     []Interval
+		wantStart float64
+		wantEnd   float64
+		wantErr   error
+	}{
+		{
+			name:      "no dead air",
+			wantStart: 0,
+			wantEnd:   20.4,
+		},
+		{
+			name:      "overlapping leading black and silence",
+			silence:   []Interval{{0, 2}, {1.5, 3}, {12.75, 20.4}},
+			black:     []Interval{{0, 2.5}, {18, 20.4}},
+			wantStart: 2.5,
+			wantEnd:   18,
+		},
+		{
+			name:      "silence in the middle is kept",
+			silence:   []Interval{{5, 8}},
+			black:     []Interval{{5, 8}},
+			wantStart: 0,
+			wantEnd:   20.4,
+		},
+		{
+			name:      "silent screen recording",
+			silence:   []Interval{{0, 20.4}},
+			wantStart: 0,
+			wantEnd:   20.4,
+		},
+		{
+			name:      "black screen with narration",
+			silence:   []Interval{{0, 1}},
+			black:     []Interval{{0, 20.4}},
+			wantStart: 1,
+			wantEnd:   20.4,
+		},
+		{
+			name:    "entirely dead",
+			silence: []Interval{{0, 12}, {10, 20.4}},
+			black:   []Interval{{0, 20.4}},
+			wantErr: ErrNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := ContentBounds(tt.silence, tt.black, 20.4)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ContentBounds() error = %v, want %v", err, tt.wantErr)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("ContentBounds() = (%v, %v), want (%v, %v)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidEncoding = errors.New("invalid encoding")
+
+// Encoding selects the codec settings of every operation that re-encodes
+// its output. Fields left empty use the encoder's defaults. MaxBitrate and
+// AudioBitrate take ffmpeg bitrates such as "4M" or "128k".
+type Encoding struct {
+	VideoCodec   string `json:"video_codec"`
+	Preset       string `json:"preset,omitempty"`
+	CRF          *int   `json:"crf,omitempty"`
+	MaxBitrate   string `json:"max_bitrate,omitempty"`
+	AudioBitrate string `json:"audio_bitrate,omitempty"`
+	PixelFormat  string `json:"pixel_format,omitempty"`
+	GOPSize      int    `json:"gop_size,omitempty"`
+}
+
+// DefaultEncoding is used when no encoding preset is configured or
+// requested.
+var DefaultEncoding = Encoding{VideoCodec: "libx264"}
+
+const MaxGOPSize = 600
+
+// videoEncoder describes what a supported encoder accepts.
+type videoEncoder struct {
+	presets []string
+	maxCRF  int
+}
+
+var (
+	x26xPresets   = []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow", "placebo"}
+	svtAV1Presets = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13"}
+
+	videoEncoders = map[string]videoEncoder{
+		"libx264":    {presets: x26xPresets, maxCRF: 51},
+		"libx265":    {presets: x26xPresets, maxCRF: 51},
+		"libvpx-vp9": {maxCRF: 63},
+		"libsvtav1":  {presets: svtAV1Presets, maxCRF: 63},
+	}
+
+	pixelFormats = map[string]bool{
+		"yuv420p": true, "yuv422p": true, "yuv444p": true,
+		"yuv420p10le": true, "yuv422p10le": true, "yuv444p10le": true,
+	}
+
+	bitratePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([kM]?)$`)
+)
+
+func (e *Encoding) Validate() error {
+	encoder, ok := videoEncoders[e.VideoCodec]
+	if !ok {
+		return fmt.Errorf("%w: video_codec must be libx264, libx265, libvpx-vp9 or libsvtav1", ErrInvalidEncoding)
+	}
+
+	if e.Preset != "" {
+		if len(encoder.presets) == 0 {
+			return fmt.Errorf("%w: %s does not take a preset", ErrInvalidEncoding, e.VideoCodec)
+		}
+		if !slices.Contains(encoder.presets, e.Preset) {
+			return fmt.Errorf("%w: preset for %s must be one of %s", ErrInvalidEncoding, e.VideoCodec, strings.Join(encoder.presets, ", "))
+		}
+	}
+
+	if e.CRF != nil && (*e.CRF < 0 || *e.CRF > encoder.maxCRF) {
+		return fmt.Errorf("%w: crf for %s must be between 0 and %d", ErrInvalidEncoding, e.VideoCodec, encoder.maxCRF)
+	}
+
+	for _, bitrate := range []struct{ name, value string }{
+		{"max_bitrate", e.MaxBitrate},
+		{"audio_bitrate", e.AudioBitrate},
+	} {
+		if bitrate.value == "" {
+			continue
+		}
+		if bits, err := parseBitrate(bitrate.value); err != nil || bits <= 0 {
+			return fmt.Errorf("%w: %s must be a bitrate such as 4M or 128k", ErrInvalidEncoding, bitrate.name)
+		}
+	}
+
+	if e.PixelFormat != "" && !pixelFormats[e.PixelFormat] {
+		return fmt.Errorf("%w: unsupported pixel_format %q", ErrInvalidEncoding, e.PixelFormat)
+	}
+
+	if e.GOPSize < 0 || e.GOPSize > MaxGOPSize {
+		return fmt.Errorf("%w: gop_size must be between 0 (encoder default) and %d", ErrInvalidEncoding, MaxGOPSize)
+	}
+	return nil
+}
+
+// Args returns the ffmpeg codec arguments for validated settings.
+func (e Encoding) Args() []string {
+	args := []string{"-c:v", e.VideoCodec}
+	if e.Preset != "" {
+		args = append(args, "-preset", e.Preset)
+	}
+	if e.CRF != nil {
+		args = append(args, "-crf", strconv.Itoa(*e.CRF))
+	}
+	if e.MaxBitrate != "" {
+		// The rate control buffer spans two seconds at the maximum rate.
+		bits, _ := parseBitrate(e.MaxBitrate)
+		args = append(args, "-maxrate", e.MaxBitrate, "-bufsize", strconv.FormatInt(2*bits, 10))
+	} else if e.CRF != nil && e.VideoCodec == "libvpx-vp9" {
+		// libvpx only encodes at constant quality without a target bitrate.
+		args = append(args, "-b:v", "0")
+	}
+	if e.PixelFormat != "" {
+		args = append(args, "-pix_fmt", e.PixelFormat)
+	}
+	if e.GOPSize != 0 {
+		args = append(args, "-g", strconv.Itoa(e.GOPSize))
+	}
+
+	args = append(args, "-c:a", "aac")
+	if e.AudioBitrate != "" {
+		args = append(args, "-b:a", e.AudioBitrate)
+	}
+	return args
+}
+
+// parseBitrate converts an ffmpeg bitrate with an optional k or M suffix to
+// bits per second.
+func parseBitrate(value string) (int64, error) {
+	match := bitratePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("invalid bitrate %q", value)
+	}
+	n, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	switch match[2] {
+	case "k":
+		n *= 1e3
+	case "M":
+		n *= 1e6
+	}
+	return int64(n), nil
+}
+
+type encodingKey struct{}
+
+// WithEncoding returns a context whose operations encode their outputs with
+// e instead of DefaultEncoding.
+func WithEncoding(ctx context.Context, e Encoding) context.Context {
+	return context.WithValue(ctx, encodingKey{}, e)
+}
+
+// EncodingFromContext returns the encoding set by WithEncoding, or
+// DefaultEncoding.
+func EncodingFromContext(ctx context.Context) Encoding {
+	if e, ok := ctx.Value(encodingKey{}).(Encoding); ok {
+		return e
+	}
+	return DefaultEncoding
+}
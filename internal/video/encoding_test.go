@@ -0,0 +1,83 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncodingValidate(t *testing.T) {
+	crf := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		enc     Encoding
+		wantErr bool
+	}{
+		{"default", DefaultEncoding, false},
+		{"full x264", Encoding{VideoCodec: "libx264", Preset: "slow", CRF: crf(20), MaxBitrate: "4M", AudioBitrate: "128k", PixelFormat: "yuv420p", GOPSize: 48}, false},
+		{"svt-av1 numeric preset", Encoding{VideoCodec: "libsvtav1", Preset: "8", CRF: crf(35)}, false},
+		{"fractional bitrate", Encoding{VideoCodec: "libx265", MaxBitrate: "2.5M"}, false},
+		{"unknown codec", Encoding{VideoCodec: "mpeg2video"}, true},
+		{"missing codec", Encoding{}, true},
+		{"unknown preset", Encoding{VideoCodec: "libx264", Preset: "ludicrous"}, true},
+		{"vp9 preset", Encoding{VideoCodec: "libvpx-vp9", Preset: "fast"}, true},
+		{"crf too high", Encoding{VideoCodec: "libx264", CRF: crf(52)}, true},
+		{"vp9 crf", Encoding{VideoCodec: "libvpx-vp9", CRF: crf(63)}, false},
+		{"bad bitrate", Encoding{VideoCodec: "libx264", MaxBitrate: "fast"}, true},
+		{"zero audio bitrate", Encoding{VideoCodec: "libx264", AudioBitrate: "0k"}, true},
+		{"bad pixel format", Encoding{VideoCodec: "libx264", PixelFormat: "rgb24"}, true},
+		{"gop too long", Encoding{VideoCodec: "libx264", GOPSize: 601}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.enc.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidEncoding) {
+				t.Errorf("Validate() error = %v, want ErrInvalidEncoding", err)
+			}
+		})
+	}
+}
+
+func TestEncodingArgs(t *testing.T) {
+	crf := func(v int) *int { return &v }
+
+	tests := []struct {
+		name string
+		enc  Encoding
+		want []string
+	}{
+		{"default", DefaultEncoding, []string{"-c:v", "libx264", "-c:a", "aac"}},
+		{
+			"full",
+			Encoding{VideoCodec: "libx264", Preset: "slow", CRF: crf(20), MaxBitrate: "4M", AudioBitrate: "128k", PixelFormat: "yuv420p", GOPSize: 48},
+			[]string{"-c:v", "libx264", "-preset", "slow", "-crf", "20", "-maxrate", "4M", "-bufsize", "8000000",
+				"-pix_fmt", "yuv420p", "-g", "48", "-c:a", "aac", "-b:a", "128k"},
+		},
+		{"vp9 constant quality", Encoding{VideoCodec: "libvpx-vp9", CRF: crf(31)}, []string{"-c:v", "libvpx-vp9", "-crf", "31", "-b:v", "0", "-c:a", "aac"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.enc.Args(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodingFromContext(t *testing.T) {
+	if got := EncodingFromContext(context.Background()); !reflect.DeepEqual(got, DefaultEncoding) {
+		t.Errorf("EncodingFromContext() = %+v, want the default", got)
+	}
+
+	enc := Encoding{VideoCodec: "libx265", Preset: "fast"}
+	if got := EncodingFromContext(WithEncoding(context.Background(), enc)); !reflect.DeepEqual(got, enc) {
+		t.Errorf("EncodingFromContext() = %+v, want %+v", got, enc)
+	}
+}
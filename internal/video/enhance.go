@@ -0,0 +1,151 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DenoiseHQDN3D  = "hqdn3d"
+	DenoiseNLMeans = "nlmeans"
+)
+
+var ErrInvalidEnhance = errors.New("invalid enhance options")
+
+// EnhanceOptions select the clean-up filters applied to a video, in the
+// order stabilise, denoise, colour correct. DenoiseStrength is the luma
+// spatial strength for hqdn3d (default 4) or the strength s for nlmeans
+// (default 3). Colour fields left unset keep the picture unchanged.
+type EnhanceOptions struct {
+	Deshake         bool     `json:"deshake,omitempty"`
+	Denoise         string   `json:"denoise,omitempty"`
+	DenoiseStrength float64  `json:"denoise_strength,omitempty"`
+	Brightness      *float64 `json:"brightness,omitempty"`
+	Contrast        *float64 `json:"contrast,omitempty"`
+	Saturation      *float64 `json:"saturation,omitempty"`
+	Gamma           *float64 `json:"gamma,omitempty"`
+}
+
+// enhanceRange is the accepted range of an eq parameter. The eq filter
+// accepts wider ranges, but values beyond these only destroy the picture.
+type enhanceRange struct {
+	name     string
+	min, max float64
+}
+
+var (
+	brightnessRange = enhanceRange{"brightness", -1, 1}
+	contrastRange   = enhanceRange{"contrast", 0, 3}
+	saturationRange = enhanceRange{"saturation", 0, 3}
+	gammaRange      = enhanceRange{"gamma", 0.1, 10}
+)
+
+func (r enhanceRange) check(value *float64) error {
+	if value != nil && (*value < r.min || *value > r.max) {
+		return fmt.Errorf("%w: %s must be between %g and %g", ErrInvalidEnhance, r.name, r.min, r.max)
+	}
+	return nil
+}
+
+// Validate checks the parameter ranges and fills in the default denoise
+// strength.
+func (o *EnhanceOptions) Validate() error {
+	if !o.Deshake && o.Denoise == "" && !o.hasColor() {
+		return fmt.Errorf("%w: no enhancement requested", ErrInvalidEnhance)
+	}
+
+	switch o.Denoise {
+	case "":
+		if o.DenoiseStrength != 0 {
+			return fmt.Errorf("%w: denoise_strength requires denoise", ErrInvalidEnhance)
+		}
+	case DenoiseHQDN3D:
+		if o.DenoiseStrength == 0 {
+			o.DenoiseStrength = 4
+		}
+		if o.DenoiseStrength < 0 || o.DenoiseStrength > 20 {
+			return fmt.Errorf("%w: hqdn3d strength must be between 0 and 20", ErrInvalidEnhance)
+		}
+	case DenoiseNLMeans:
+		if o.DenoiseStrength == 0 {
+			o.DenoiseStrength = 3
+		}
+		if o.DenoiseStrength < 1 || o.DenoiseStrength > 30 {
+			return fmt.Errorf("%w: nlmeans strength must be between 1 and 30", ErrInvalidEnhance)
+		}
+	default:
+		return fmt.Errorf("%w: denoise must be hqdn3d or nlmeans", ErrInvalidEnhance)
+	}
+
+	for _, check := range []error{
+		brightnessRange.check(o.Brightness),
+		contrastRange.check(o.Contrast),
+		saturationRange.check(o.Saturation),
+		gammaRange.check(o.Gamma),
+	} {
+		if check != nil {
+			return check
+		}
+	}
+	return nil
+}
+
+func (o *EnhanceOptions) hasColor() bool {
+	return o.Brightness != nil || o.Contrast != nil || o.Saturation != nil || o.Gamma != nil
+}
+
+// Filters returns the ffmpeg filters for validated options in the order they
+// are applied. They are also what is recorded on the enhanced video.
+func (o *EnhanceOptions) Filters() []string {
+	var filters []string
+
+	if o.Deshake {
+		filters = append(filters, "deshake")
+	}
+
+	switch o.Denoise {
+	case DenoiseHQDN3D:
+		filters = append(filters, "hqdn3d=luma_spatial="+formatFilterNumber(o.DenoiseStrength))
+	case DenoiseNLMeans:
+		filters = append(filters, "nlmeans=s="+formatFilterNumber(o.DenoiseStrength))
+	}
+
+	if o.hasColor() {
+		var params []string
+		add := func(name string, value *float64) {
+			if value != nil {
+				params = append(params, name+"="+formatFilterNumber(*value))
+			}
+		}
+		add("brightness", o.Brightness)
+		add("contrast", o.Contrast)
+		add("saturation", o.Saturation)
+		add("gamma", o.Gamma)
+		filters = append(filters, "eq="+strings.Join(params, ":"))
+	}
+
+	return filters
+}
+
+func formatFilterNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Enhance re-encodes a video through the filters selected by validated
+// options. The audio is re-encoded without filtering.
+func (p *FFmpegProcessor) Enhance(ctx context.Context, inputPath, outputPath string, opts EnhanceOptions) error {
+	args := []string{
+		"-i", inputPath,
+		"-vf", strings.Join(opts.Filters(), ","),
+	}
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to enhance video: %w, output: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package video
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEnhanceOptions(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		opts    EnhanceOptions
+		want    []string
+		wantErr bool
+	}{
+		{"deshake", EnhanceOptions{Deshake: true}, []string{"deshake"}, false},
+		{"hqdn3d default", EnhanceOptions{Denoise: DenoiseHQDN3D}, []string{"hqdn3d=luma_spatial=4"}, false},
+		{"nlmeans strength", EnhanceOptions{Denoise: DenoiseNLMeans, DenoiseStrength: 6.5}, []string{"nlmeans=s=6.5"}, false},
+		{
+			"all filters in order",
+			EnhanceOptions{Deshake: true, Denoise: DenoiseHQDN3D, Brightness: value(0.1), Saturation: value(0), Gamma: value(1.4)},
+			[]string{"deshake", "hqdn3d=luma_spatial=4", "eq=brightness=0.1:saturation=0:gamma=1.4"},
+			false,
+		},
+		{"nothing", EnhanceOptions{}, nil, true},
+		{"unknown denoise", EnhanceOptions{Denoise: "median"}, nil, true},
+		{"strength without denoise", EnhanceOptions{Deshake: true, DenoiseStrength: 2}, nil, true},
+		{"hqdn3d too strong", EnhanceOptions{Denoise: DenoiseHQDN3D, DenoiseStrength: 25}, nil, true},
+		{"nlmeans too weak", EnhanceOptions{Denoise: DenoiseNLMeans, DenoiseStrength: 0.5}, nil, true},
+		{"brightness out of range", EnhanceOptions{Brightness: value(1.5)}, nil, true},
+		{"negative contrast", EnhanceOptions{Contrast: value(-1)}, nil, true},
+		{"gamma too low", EnhanceOptions{Gamma: value(0)}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			err := opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidEnhance) {
+					t.Errorf("Validate() error = %v, want ErrInvalidEnhance", err)
+				}
+				return
+			}
+			if got := opts.Filters(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Properties of the files FakeProcessor writes and of inputs it cannot parse.
+const (
+	fakeDuration   = 10.0
+	fakeWidth      = 1280
+	fakeHeight     = 720
+	fakeFrameRate  = 30
+	fakeSceneEvery = 5.0
+	fakeTimescale  = 1000
+)
+
+// fakeWebP is a 1x1 lossless WebP image.
+var fakeWebP, _ = base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+// FakeProcessor stands in for ffmpeg where it is not installed, such as in
+// the end-to-end tests. Video outputs are MP4 files holding only a movie
+// header and track descriptions, timed and sized as ffmpeg's output would
+// be, so they probe like real videos; images are blank and analyses return
+// fixed results. Everything it produces depends only on its inputs.
+type FakeProcessor struct{}
+
+func NewFakeProcessor() *FakeProcessor {
+	return &FakeProcessor{}
+}
+
+// FakeMP4 returns a minimal MP4 file that ProbeMP4 reads as a video with the
+// duration, dimensions, rotation and, when info.AudioCodec is set, the audio
+// track of info.
+func FakeMP4(info VideoInfo) []byte {
+	duration := uint32(max(1, math.Round(info.Duration*fakeTimescale)))
+
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], fakeTimescale)
+	binary.BigEndian.PutUint32(mvhd[16:], duration)
+
+	traks := [][]byte{fakeTrak("vide", "avc1", fakeTkhd(info.Width, info.Height, info.Rotation), fakeVisualEntry(info.Width, info.Height))}
+	if info.AudioCodec != "" {
+		traks = append(traks, fakeTrak("soun", "mp4a", fakeTkhd(0, 0, 0), make([]byte, 28)))
+	}
+
+	ftyp := newMP4Box("ftyp", []byte("isom"), binary.BigEndian.AppendUint32(nil, 512), []byte("isomiso2avc1mp41"))
+	moov := newMP4Box("moov", append([][]byte{newMP4Box("mvhd", mvhd)}, traks...)...)
+	return bytes.Join([][]byte{ftyp, moov, newMP4Box("mdat", make([]byte, 16))}, nil)
+}
+
+func newMP4Box(typ string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	box = append(box, typ...)
+	return append(box, body...)
+}
+
+func fakeTrak(handler, entry string, tkhd, entryBody []byte) []byte {
+	hdlr := newMP4Box("hdlr", make([]byte, 8), []byte(handler), make([]byte, 13))
+	stsd := newMP4Box("stsd", make([]byte, 4), binary.BigEndian.AppendUint32(nil, 1), newMP4Box(entry, entryBody))
+	return newMP4Box("trak", tkhd, newMP4Box("mdia", hdlr, newMP4Box("minf", newMP4Box("stbl", stsd))))
+}
+
+// fakeTkhd builds a version 0 track header rotated clockwise by a multiple
+// of 90 degrees.
+func fakeTkhd(width, height, rotation int) []byte {
+	cos := map[int]int32{0: 1, 90: 0, 180: -1, 270: 0}[rotation]
+	sin := map[int]int32{0: 0, 90: 1, 180: 0, 270: -1}[rotation]
+
+	body := make([]byte, 40, 84)
+	for _, v := range []int32{cos << 16, sin << 16, 0, -sin << 16, cos << 16, 0, 0, 0, 1 << 30} {
+		body = binary.BigEndian.AppendUint32(body, uint32(v))
+	}
+	body = binary.BigEndian.AppendUint32(body, uint32(width)<<16)
+	body = binary.BigEndian.AppendUint32(body, uint32(height)<<16)
+	return newMP4Box("tkhd", body)
+}
+
+func fakeVisualEntry(width, height int) []byte {
+	body := make([]byte, 78)
+	binary.BigEndian.PutUint16(body[24:], uint16(width))
+	binary.BigEndian.PutUint16(body[26:], uint16(height))
+	return body
+}
+
+func (p *FakeProcessor) Version(ctx context.Context) (string, error) {
+	return ProcessorFake, nil
+}
+
+// GetVideoInfo reads MP4 and MOV files natively and describes any other
+// file as a ten second 1280x720 video with audio.
+func (p *FakeProcessor) GetVideoInfo(ctx context.Context, path string) (*VideoInfo, error) {
+	info, err := ProbeMP4(path)
+	if err == nil {
+		return info, nil
+	}
+	if !errors.Is(err, ErrNotMP4) && !errors.Is(err, ErrMalformedMP4) {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+	return &VideoInfo{
+		Duration:   fakeDuration,
+		Format:     strings.TrimPrefix(filepath.Ext(path), "."),
+		Size:       stat.Size(),
+		Width:      fakeWidth,
+		Height:     fakeHeight,
+		VideoCodec: "h264",
+		AudioCodec: "aac",
+	}, nil
+}
+
+// derive writes a video to outputPath that describes the input as changed
+// by change. Like ffmpeg, it applies the input's rotation to the picture.
+func (p *FakeProcessor) derive(ctx context.Context, inputPath, outputPath string, change func(info *VideoInfo)) error {
+	info, err := p.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+	info.Width, info.Height = info.DisplaySize()
+	info.Rotation = 0
+	if change != nil {
+		change(info)
+	}
+	return os.WriteFile(outputPath, FakeMP4(*info), 0644)
+}
+
+func (p *FakeProcessor) Trim(ctx context.Context, inputPath, outputPath string, start, end float64) error {
+	return p.derive(ctx, inputPath, outputPath, func(info *VideoInfo) {
+		info.Duration = math.Min(end, info.Duration) - start
+	})
+}
+
+func (p *FakeProcessor) Merge(ctx context.Context, inputPaths []string, outputPath string) error {
+	var duration float64
+	for _, path := range inputPaths {
+		info, err := p.GetVideoInfo(ctx, path)
+		if err != nil {
+			return err
+		}
+		duration += info.Duration
+	}
+	return p.derive(ctx, inputPaths[0], outputPath, func(info *VideoInfo) {
+		info.Duration = duration
+	})
+}
+
+func (p *FakeProcessor) MeasureLoudness(ctx context.Context, inputPath string, target LoudnessTarget) (*LoudnessStats, error) {
+	return &LoudnessStats{
+		Integrated:   -23,
+		TruePeak:     -4,
+		LRA:          6,
+		Threshold:    -33.5,
+		TargetOffset: 0,
+	}, nil
+}
+
+func (p *FakeProcessor) NormalizeLoudness(ctx context.Context, inputPath, outputPath string, target LoudnessTarget, measured *LoudnessStats) error {
+	return p.derive(ctx, inputPath, outputPath, nil)
+}
+
+func (p *FakeProcessor) RenderAnimation(ctx context.Context, inputPath, outputPath string, opts AnimationOptions) error {
+	if opts.Format == AnimationWebP {
+		return os.WriteFile(outputPath, fakeWebP, 0644)
+	}
+	info, err := p.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+	width, height := info.DisplaySize()
+	return writeFakeImage(outputPath, opts.Width, max(1, height*opts.Width/max(1, width)))
+}
+
+func (p *FakeProcessor) GenerateStoryboard(ctx context.Context, inputPath, outputPath string, duration float64, opts StoryboardOptions) error {
+	return writeFakeImage(outputPath, opts.TileWidth*opts.Columns, opts.TileHeight*opts.Rows(duration))
+}
+
+// DetectScenes reports a cut every five seconds.
+func (p *FakeProcessor) DetectScenes(ctx context.Context, inputPath string, threshold float64) ([]SceneCut, error) {
+	info, err := p.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+	cuts := []SceneCut{}
+	for t := fakeSceneEvery; t < info.Duration; t += fakeSceneEvery {
+		cuts = append(cuts, SceneCut{Time: t, Score: 1})
+	}
+	return cuts, nil
+}
+
+func (p *FakeProcessor) DetectDeadAir(ctx context.Context, inputPath string, duration float64, opts DeadAirOptions) (*DeadAir, error) {
+	return &DeadAir{Silence: []Interval{}, Black: []Interval{}}, nil
+}
+
+func (p *FakeProcessor) MuxSubtitles(ctx context.Context, inputPath, subtitlePath, outputPath, language string) error {
+	return p.derive(ctx, inputPath, outputPath, nil)
+}
+
+func (p *FakeProcessor) BurnSubtitles(ctx context.Context, inputPath, subtitlePath, outputPath string) error {
+	return p.derive(ctx, inputPath, outputPath, nil)
+}
+
+func (p *FakeProcessor) Transform(ctx context.Context, inputPath, outputPath string, opts TransformOptions) error {
+	return p.derive(ctx, inputPath, outputPath, func(info *VideoInfo) {
+		if c := opts.Crop; c != nil {
+			info.Width, info.Height = c.Width, c.Height
+		}
+		if opts.Rotate == 90 || opts.Rotate == 270 {
+			info.Width, info.Height = info.Height, info.Width
+		}
+		switch {
+		case opts.Width != 0 && opts.Height != 0:
+			info.Width, info.Height = opts.Width, opts.Height
+		case opts.Width != 0:
+			info.Width, info.Height = opts.Width, even(float64(info.Height*opts.Width)/float64(info.Width))
+		case opts.Height != 0:
+			info.Width, info.Height = even(float64(info.Width*opts.Height)/float64(info.Height)), opts.Height
+		}
+	})
+}
+
+func (p *FakeProcessor) ChangeSpeed(ctx context.Context, inputPath, outputPath string, factor float64) error {
+	return p.derive(ctx, inputPath, outputPath, func(info *VideoInfo) {
+		info.Duration /= factor
+	})
+}
+
+func (p *FakeProcessor) Reverse(ctx context.Context, inputPath, outputPath string) error {
+	return p.derive(ctx, inputPath, outputPath, nil)
+}
+
+func (p *FakeProcessor) Loop(ctx context.Context, inputPath, outputPath string, opts LoopOptions) error {
+	return p.derive(ctx, inputPath, outputPath, func(info *VideoInfo) {
+		info.Duration = opts.OutputDuration(info.Duration)
+	})
+}
+
+func (p *FakeProcessor) Compose(ctx context.Context, inputs []ComposeInput, outputPath string, opts ComposeOptions) error {
+	return p.derive(ctx, inputs[0].Path, outputPath, func(info *VideoInfo) {
+		info.Duration = opts.OutputDuration(inputs)
+		switch opts.Layout {
+		case LayoutHStack:
+			info.Width *= len(inputs)
+		case LayoutVStack:
+			info.Height *= len(inputs)
+		}
+		if opts.Audio == AudioNone {
+			info.AudioCodec = ""
+		}
+	})
+}
+
+func (p *FakeProcessor) ExtractFrame(ctx context.Context, inputPath, outputPath string, timestamp float64) error {
+	info, err := p.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+	width, height := info.DisplaySize()
+	return writeFakeImage(outputPath, width, height)
+}
+
+func (p *FakeProcessor) RenderWaveform(ctx context.Context, inputPath, outputPath string, opts WaveformOptions) error {
+	return writeFakeImage(outputPath, opts.Width, opts.Height)
+}
+
+// ComputePeaks reduces a one hertz sine wave lasting as long as the input.
+func (p *FakeProcessor) ComputePeaks(ctx context.Context, inputPath, scratchPath string, opts PeaksOptions) (*Peaks, error) {
+	info, err := p.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+	samples := int(info.Duration * PeaksSampleRate)
+	raw := make([]byte, 0, samples*2)
+	for i := 0; i < samples; i++ {
+		v := int16(math.Sin(2*math.Pi*float64(i)/PeaksSampleRate) * math.MaxInt16 / 2)
+		raw = binary.LittleEndian.AppendUint16(raw, uint16(v))
+	}
+	return computePeaks(raw, opts), nil
+}
+
+func (p *FakeProcessor) MeasureQuality(ctx context.Context, inputPath string, ref QualityReference) (*QualityStats, error) {
+	return &QualityStats{
+		Frames:      max(1, int(ref.Duration*fakeFrameRate)),
+		PSNRAverage: 42,
+		PSNRMin:     38,
+		SSIMAverage: 0.98,
+		SSIMMin:     0.95,
+	}, nil
+}
+
+// Fingerprint hashes frames generated from the contents of the input, so
+// identical files have identical fingerprints.
+func (p *FakeProcessor) Fingerprint(ctx context.Context, inputPath string, duration float64) ([]uint64, error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fingerprint video: %w", err)
+	}
+	defer file.Close()
+
+	h := fnv.New64a()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, fmt.Errorf("failed to fingerprint video: %w", err)
+	}
+
+	raw := make([]byte, FingerprintFrames*dHashWidth*dHashHeight)
+	rand.New(rand.NewSource(int64(h.Sum64()))).Read(raw)
+	return dHashFrames(raw), nil
+}
+
+func (p *FakeProcessor) SetMetadata(ctx context.Context, inputPath, outputPath string, meta Metadata) error {
+	return p.derive(ctx, inputPath, outputPath, nil)
+}
+
+func (p *FakeProcessor) Split(ctx context.Context, inputPath, outputDir string, segmentTime float64) ([]string, error) {
+	info, err := p.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(inputPath)
+	var paths []string
+	// Stop short of a sliver left over by rounding.
+	for start := 0.0; start < info.Duration-0.001; start += segmentTime {
+		path := filepath.Join(outputDir, fmt.Sprintf("segment%03d%s", len(paths), ext))
+		if err := p.Trim(ctx, inputPath, path, start, start+segmentTime); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (p *FakeProcessor) Enhance(ctx context.Context, inputPath, outputPath string, opts EnhanceOptions) error {
+	return p.derive(ctx, inputPath, outputPath, nil)
+}
+
+func (p *FakeProcessor) Redact(ctx context.Context, inputPath, outputPath string, opts RedactOptions) error {
+	return p.derive(ctx, inputPath, outputPath, nil)
+}
+
+// writeFakeImage writes a black image in the format given by the extension
+// of path: JPEG, GIF or otherwise PNG.
+func writeFakeImage(path string, width, height int) error {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+
+	var buf bytes.Buffer
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case ".gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
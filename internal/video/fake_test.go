@@ -0,0 +1,160 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFakeMP4(t *testing.T) {
+	tests := []VideoInfo{
+		{Duration: 5, Width: 1280, Height: 720, AudioCodec: "aac"},
+		{Duration: 0.5, Width: 640, Height: 360},
+		{Duration: 12.345, Width: 1920, Height: 1080, Rotation: 90, AudioCodec: "aac"},
+	}
+
+	for _, want := range tests {
+		data := FakeMP4(want)
+		got, err := ParseMP4(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("ParseMP4() error = %v", err)
+		}
+
+		want.Format = mp4FormatName
+		want.Size = int64(len(data))
+		want.VideoCodec = "h264"
+		want.Timescale = fakeTimescale
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("ParseMP4() = %+v, want %+v", *got, want)
+		}
+	}
+}
+
+func TestFakeProcessor(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	p := NewFakeProcessor()
+
+	input := filepath.Join(dir, "input.mp4")
+	if err := os.WriteFile(input, FakeMP4(VideoInfo{Duration: 10, Width: 1920, Height: 1080, Rotation: 90, AudioCodec: "aac"}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	probe := func(path string) *VideoInfo {
+		t.Helper()
+		info, err := p.GetVideoInfo(ctx, path)
+		if err != nil {
+			t.Fatalf("GetVideoInfo(%s) error = %v", filepath.Base(path), err)
+		}
+		return info
+	}
+
+	trimmed := filepath.Join(dir, "trimmed.mp4")
+	if err := p.Trim(ctx, input, trimmed, 2, 6); err != nil {
+		t.Fatalf("Trim() error = %v", err)
+	}
+	if info := probe(trimmed); info.Duration != 4 || info.Width != 1080 || info.Height != 1920 || info.Rotation != 0 {
+		t.Errorf("Trim() wrote %+v, want a 4 second 1080x1920 video", info)
+	}
+
+	transformed := filepath.Join(dir, "transformed.mp4")
+	if err := p.Transform(ctx, input, transformed, TransformOptions{Rotate: 90, Width: 960}); err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	if info := probe(transformed); info.Width != 960 || info.Height != 540 {
+		t.Errorf("Transform() wrote %dx%d, want 960x540", info.Width, info.Height)
+	}
+
+	segments, err := p.Split(ctx, input, dir, 4)
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	var durations []float64
+	for _, segment := range segments {
+		durations = append(durations, probe(segment).Duration)
+	}
+	if want := []float64{4, 4, 2}; !reflect.DeepEqual(durations, want) {
+		t.Errorf("Split() durations = %v, want %v", durations, want)
+	}
+	if filepath.Base(segments[0]) != "segment000.mp4" {
+		t.Errorf("Split() first segment = %s, want segment000.mp4", filepath.Base(segments[0]))
+	}
+
+	for _, tt := range []struct {
+		name          string
+		width, height int
+		render        func(path string) error
+	}{
+		{"frame.png", 1080, 1920, func(path string) error { return p.ExtractFrame(ctx, input, path, 1) }},
+		{"frame.jpg", 1080, 1920, func(path string) error { return p.ExtractFrame(ctx, input, path, 1) }},
+		{"storyboard.jpg", 480, 180, func(path string) error {
+			return p.GenerateStoryboard(ctx, input, path, 10, StoryboardOptions{Interval: 2, TileWidth: 160, TileHeight: 90, Columns: 3})
+		}},
+		{"preview.gif", 108, 192, func(path string) error {
+			return p.RenderAnimation(ctx, input, path, AnimationOptions{Format: AnimationGIF, Duration: 2, FPS: 10, Width: 108})
+		}},
+		{"waveform.png", 200, 40, func(path string) error {
+			return p.RenderWaveform(ctx, input, path, WaveformOptions{Width: 200, Height: 40})
+		}},
+	} {
+		path := filepath.Join(dir, tt.name)
+		if err := tt.render(path); err != nil {
+			t.Fatalf("%s: error = %v", tt.name, err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, _, err := image.DecodeConfig(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s: invalid image: %v", tt.name, err)
+		}
+		if config.Width != tt.width || config.Height != tt.height {
+			t.Errorf("%s: size = %dx%d, want %dx%d", tt.name, config.Width, config.Height, tt.width, tt.height)
+		}
+	}
+
+	first, err := p.Fingerprint(ctx, input, 10)
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	second, _ := p.Fingerprint(ctx, input, 10)
+	other, _ := p.Fingerprint(ctx, trimmed, 4)
+	if len(first) != FingerprintFrames || !reflect.DeepEqual(first, second) {
+		t.Errorf("Fingerprint() = %v then %v, want %d equal hashes", first, second, FingerprintFrames)
+	}
+	if reflect.DeepEqual(first, other) {
+		t.Error("Fingerprint() is the same for different files")
+	}
+
+	peaks, err := p.ComputePeaks(ctx, input, filepath.Join(dir, "peaks.raw"), DefaultPeaksOptions)
+	if err != nil {
+		t.Fatalf("ComputePeaks() error = %v", err)
+	}
+	if want := 10 * PeaksSampleRate / DefaultPeaksOptions.SamplesPerPixel; peaks.Length != want+1 && peaks.Length != want {
+		t.Errorf("ComputePeaks() length = %d, want about %d", peaks.Length, want)
+	}
+}
+
+func TestFakeProcessorUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.avi")
+	if err := os.WriteFile(path, []byte("not parsed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := NewFakeProcessor().GetVideoInfo(context.Background(), path)
+	if err != nil {
+		t.Fatalf("GetVideoInfo() error = %v", err)
+	}
+	if info.Duration != fakeDuration || info.Width != fakeWidth || info.Height != fakeHeight || info.Format != "avi" || info.Size != 10 {
+		t.Errorf("GetVideoInfo() = %+v", info)
+	}
+}
@@ -0,0 +1,98 @@
+package video
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"os"
+)
+
+const (
+	// FingerprintFrames is the number of frames hashed per video. They are
+	// spread evenly over the video, so re-encodes and copies of different
+	// lengths are sampled at the same relative positions.
+	FingerprintFrames = 16
+
+	// MaxHashDistance is the distance between completely different hashes.
+	MaxHashDistance = 64
+
+	dHashWidth  = 9
+	dHashHeight = 8
+)
+
+// Fingerprint samples frames evenly across a video and returns the
+// difference hash of each. Frames are shrunk to 9x8 greyscale, so the hash
+// survives re-encoding, scaling and small colour changes.
+func (p *FFmpegProcessor) Fingerprint(ctx context.Context, inputPath string, duration float64) ([]uint64, error) {
+	scratch, err := os.CreateTemp("", "fingerprint-*.gray")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fingerprint file: %w", err)
+	}
+	scratch.Close()
+	defer os.Remove(scratch.Name())
+
+	args := []string{
+		"-i", inputPath,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%.6f,scale=%d:%d:flags=area,format=gray", FingerprintFrames/duration, dHashWidth, dHashHeight),
+		"-frames:v", fmt.Sprintf("%d", FingerprintFrames),
+		"-f", "rawvideo",
+		"-y", scratch.Name(),
+	}
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return nil, fmt.Errorf("failed to fingerprint video: %w, output: %s", err, string(output))
+	}
+
+	raw, err := os.ReadFile(scratch.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fingerprint frames: %w", err)
+	}
+
+	return dHashFrames(raw), nil
+}
+
+// dHashFrames hashes consecutive 9x8 greyscale frames. Each bit records
+// whether a pixel is brighter than its right-hand neighbour.
+func dHashFrames(raw []byte) []uint64 {
+	frameSize := dHashWidth * dHashHeight
+	var hashes []uint64
+	for offset := 0; offset+frameSize <= len(raw); offset += frameSize {
+		frame := raw[offset : offset+frameSize]
+
+		var hash uint64
+		for y := 0; y < dHashHeight; y++ {
+			for x := 0; x < dHashWidth-1; x++ {
+				hash <<= 1
+				if frame[y*dHashWidth+x] > frame[y*dHashWidth+x+1] {
+					hash |= 1
+				}
+			}
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// FingerprintDistance returns the mean Hamming distance between the hashes
+// of two fingerprints, frame by frame. Frames only one of them has count as
+// completely different, and empty fingerprints are MaxHashDistance apart.
+func FingerprintDistance(a, b []uint64) float64 {
+	frames := len(a)
+	if len(b) > frames {
+		frames = len(b)
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return MaxHashDistance
+	}
+
+	total := 0
+	for i := 0; i < frames; i++ {
+		if i >= len(a) || i >= len(b) {
+			total += MaxHashDistance
+			continue
+		}
+		total += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return float64(total) / float64(frames)
+}
@@ -0,0 +1,50 @@
+package video
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDHashFrames(t *testing.T) {
+	// A frame that darkens left to right sets every bit, one that brightens
+	// sets none, and a trailing partial frame is ignored.
+	var raw []byte
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 9; x++ {
+			raw = append(raw, byte(200-x*10))
+		}
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 9; x++ {
+			raw = append(raw, byte(x*10))
+		}
+	}
+	raw = append(raw, 1, 2, 3)
+
+	want := []uint64{0xffffffffffffffff, 0}
+	if got := dHashFrames(raw); !reflect.DeepEqual(got, want) {
+		t.Errorf("dHashFrames() = %x, want %x", got, want)
+	}
+}
+
+func TestFingerprintDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []uint64
+		want float64
+	}{
+		{"identical", []uint64{0xf0f0, 0x1234}, []uint64{0xf0f0, 0x1234}, 0},
+		{"few bits", []uint64{0x0, 0xff}, []uint64{0x3, 0xfe}, 1.5},
+		{"inverted", []uint64{0}, []uint64{0xffffffffffffffff}, 64},
+		{"extra frame", []uint64{0, 0}, []uint64{0}, 32},
+		{"empty", nil, []uint64{0}, MaxHashDistance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FingerprintDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("FingerprintDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package video
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFrameOptionsResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    FrameOptions
+		want    []float64
+		wantErr bool
+	}{
+		{"timestamps sorted", FrameOptions{Format: FramePNG, Timestamps: []float64{4.5, 0, 2}}, []float64{0, 2, 4.5}, false},
+		{"interval to end", FrameOptions{Format: FrameJPEG, Interval: 3}, []float64{0, 3, 6, 9}, false},
+		{"interval range", FrameOptions{Format: FramePNG, Interval: 1, Start: 2, End: 4}, []float64{2, 3}, false},
+		{"bad format", FrameOptions{Format: "bmp", Timestamps: []float64{1}}, nil, true},
+		{"nothing selected", FrameOptions{Format: FramePNG}, nil, true},
+		{"both selected", FrameOptions{Format: FramePNG, Timestamps: []float64{1}, Interval: 1}, nil, true},
+		{"timestamp at end", FrameOptions{Format: FramePNG, Timestamps: []float64{10}}, nil, true},
+		{"negative timestamp", FrameOptions{Format: FramePNG, Timestamps: []float64{-1}}, nil, true},
+		{"end past video", FrameOptions{Format: FramePNG, Interval: 1, End: 12}, nil, true},
+		{"too many frames", FrameOptions{Format: FramePNG, Interval: 0.05}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.Resolve(10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidFrames) {
+					t.Errorf("Resolve() error = %v, want ErrInvalidFrames", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// LoudnessTarget describes the EBU R128 values loudnorm normalizes towards.
+type LoudnessTarget struct {
+	Integrated float64 `json:"integrated"`
+	TruePeak   float64 `json:"true_peak"`
+	LRA        float64 `json:"lra"`
+}
+
+// LoudnessStats holds the values measured by the first loudnorm pass.
+type LoudnessStats struct {
+	Integrated   float64 `json:"integrated"`
+	TruePeak     float64 `json:"true_peak"`
+	LRA          float64 `json:"lra"`
+	Threshold    float64 `json:"threshold"`
+	TargetOffset float64 `json:"target_offset"`
+}
+
+const (
+	DefaultTruePeak = -1.5
+	DefaultLRA      = 11.0
+)
+
+var (
+	ErrInvalidLoudnessTarget = errors.New("invalid loudness target")
+	ErrSilentAudio           = errors.New("audio is silent")
+)
+
+// NewLoudnessTarget returns a target for the given integrated loudness using
+// the default true peak and loudness range.
+func NewLoudnessTarget(integrated float64) LoudnessTarget {
+	return LoudnessTarget{
+		Integrated: integrated,
+		TruePeak:   DefaultTruePeak,
+		LRA:        DefaultLRA,
+	}
+}
+
+// Validate checks the target against the ranges accepted by loudnorm.
+func (t LoudnessTarget) Validate() error {
+	if t.Integrated < -70 || t.Integrated > -5 {
+		return fmt.Errorf("%w: integrated loudness must be between -70 and -5 LUFS", ErrInvalidLoudnessTarget)
+	}
+	if t.TruePeak < -9 || t.TruePeak > 0 {
+		return fmt.Errorf("%w: true peak must be between -9 and 0 dBTP", ErrInvalidLoudnessTarget)
+	}
+	if t.LRA < 1 || t.LRA > 50 {
+		return fmt.Errorf("%w: loudness range must be between 1 and 50 LU", ErrInvalidLoudnessTarget)
+	}
+	return nil
+}
+
+func (t LoudnessTarget) filter() string {
+	return fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=%.1f", t.Integrated, t.TruePeak, t.LRA)
+}
+
+func (p *FFmpegProcessor) MeasureLoudness(ctx context.Context, inputPath string, target LoudnessTarget) (*LoudnessStats, error) {
+	args := []string{
+		"-hide_banner",
+		"-nostats",
+		"-i", inputPath,
+		"-af", target.filter() + ":print_format=json",
+		"-vn",
+		"-f", "null",
+		"-",
+	}
+
+	output, err := p.runFFmpeg(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure loudness: %w, output: %s", err, string(output))
+	}
+
+	return parseLoudnormOutput(output)
+}
+
+func (p *FFmpegProcessor) NormalizeLoudness(ctx context.Context, inputPath, outputPath string, target LoudnessTarget, measured *LoudnessStats) error {
+	filter := target.filter()
+	if measured != nil {
+		filter += fmt.Sprintf(":measured_I=%.2f:measured_TP=%.2f:measured_LRA=%.2f:measured_thresh=%.2f:offset=%.2f:linear=true",
+			measured.Integrated, measured.TruePeak, measured.LRA, measured.Threshold, measured.TargetOffset)
+	}
+
+	args := []string{
+		"-i", inputPath,
+		"-af", filter,
+		"-ar", "48000",
+	}
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to normalize loudness: %w, output: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// parseLoudnormOutput extracts the JSON block loudnorm prints at the end of
+// the first pass.
+func parseLoudnormOutput(output []byte) (*LoudnessStats, error) {
+	start := bytes.LastIndexByte(output, '{')
+	end := bytes.LastIndexByte(output, '}')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("loudnorm output not found")
+	}
+
+	var raw struct {
+		InputI       string `json:"input_i"`
+		InputTP      string `json:"input_tp"`
+		InputLRA     string `json:"input_lra"`
+		InputThresh  string `json:"input_thresh"`
+		TargetOffset string `json:"target_offset"`
+	}
+	if err := json.Unmarshal(output[start:end+1], &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse loudnorm output: %w", err)
+	}
+
+	values := []string{raw.InputI, raw.InputTP, raw.InputLRA, raw.InputThresh, raw.TargetOffset}
+	parsed := make([]float64, len(values))
+	for i, v := range values {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid loudnorm value %q: %w", v, err)
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, ErrSilentAudio
+		}
+		parsed[i] = f
+	}
+
+	return &LoudnessStats{
+		Integrated:   parsed[0],
+		TruePeak:     parsed[1],
+		LRA:          parsed[2],
+		Threshold:    parsed[3],
+		TargetOffset: parsed[4],
+	}, nil
+}
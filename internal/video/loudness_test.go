@@ -0,0 +1,66 @@
+package video
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseLoudnormOutput(t *testing.T) {
+	output := []byte(`[Parsed_loudnorm_0 @ 0x55d1c8a0] 
+{
+	"input_i" : "-27.61",
+	"input_tp" : "-4.47",
+	"input_lra" : "18.06",
+	"input_thresh" : "-39.20",
+	"output_i" : "-16.58",
+	"output_tp" : "-1.50",
+	"output_lra" : "14.78",
+	"output_thresh" : "-27.71",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.58"
+}
+`)
+
+	stats, err := parseLoudnormOutput(output)
+	if err != nil {
+		t.Fatalf("parseLoudnormOutput failed: %v", err)
+	}
+
+	if stats.Integrated != -27.61 || stats.TruePeak != -4.47 || stats.LRA != 18.06 ||
+		stats.Threshold != -39.20 || stats.TargetOffset != 0.58 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	silent := []byte(`{"input_i" : "-inf", "input_tp" : "-inf", "input_lra" : "0.00", "input_thresh" : "-70.00", "target_offset" : "inf"}`)
+	if _, err := parseLoudnormOutput(silent); !errors.Is(err, ErrSilentAudio) {
+		t.Errorf("expected ErrSilentAudio for silent input, got %v", err)
+	}
+
+	if _, err := parseLoudnormOutput([]byte("no json here")); err == nil {
+		t.Error("expected error for output without loudnorm block")
+	}
+}
+
+func TestLoudnessTargetValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  LoudnessTarget
+		wantErr bool
+	}{
+		{"streaming target", NewLoudnessTarget(-16), false},
+		{"broadcast target", NewLoudnessTarget(-23), false},
+		{"too loud", NewLoudnessTarget(-2), true},
+		{"too quiet", NewLoudnessTarget(-80), true},
+		{"positive true peak", LoudnessTarget{Integrated: -16, TruePeak: 1, LRA: 11}, true},
+		{"loudness range too small", LoudnessTarget{Integrated: -16, TruePeak: -1, LRA: 0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.target.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
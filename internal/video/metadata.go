@@ -0,0 +1,127 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxTitleLength       = 256
+	maxDescriptionLength = 4096
+	maxTagValueLength    = 1024
+	MaxMetadataTags      = 32
+)
+
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+
+// reservedTags are set through the named fields of Metadata.
+var reservedTags = map[string]bool{"title": true, "description": true, "creation_time": true}
+
+// Metadata is written to the container of an output. Fields left empty keep
+// whatever the input carried; a tag with an empty value removes that tag.
+type Metadata struct {
+	Title        string            `json:"title,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	CreationTime *time.Time        `json:"creation_time,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// IsZero reports whether the metadata would change nothing.
+func (m *Metadata) IsZero() bool {
+	return m == nil || (m.Title == "" && m.Description == "" && m.CreationTime == nil && len(m.Tags) == 0)
+}
+
+func (m *Metadata) Validate() error {
+	if len(m.Title) > maxTitleLength {
+		return fmt.Errorf("%w: title must be at most %d bytes", ErrInvalidMetadata, maxTitleLength)
+	}
+	if len(m.Description) > maxDescriptionLength {
+		return fmt.Errorf("%w: description must be at most %d bytes", ErrInvalidMetadata, maxDescriptionLength)
+	}
+	if len(m.Tags) > MaxMetadataTags {
+		return fmt.Errorf("%w: at most %d tags can be set", ErrInvalidMetadata, MaxMetadataTags)
+	}
+	for key, value := range m.Tags {
+		if !tagKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: tag %q must start with a letter and contain only letters, digits and underscores", ErrInvalidMetadata, key)
+		}
+		if reservedTags[strings.ToLower(key)] {
+			return fmt.Errorf("%w: set %s through its own field rather than as a tag", ErrInvalidMetadata, strings.ToLower(key))
+		}
+		if len(value) > maxTagValueLength {
+			return fmt.Errorf("%w: tag %q must be at most %d bytes", ErrInvalidMetadata, key, maxTagValueLength)
+		}
+	}
+	return nil
+}
+
+// SetMetadata remuxes a video into outputPath with its streams copied and
+// the given metadata merged over the existing container metadata.
+func (p *FFmpegProcessor) SetMetadata(ctx context.Context, inputPath, outputPath string, meta Metadata) error {
+	args := []string{
+		"-i", inputPath,
+		"-map", "0",
+		"-map_metadata", "0",
+		"-c", "copy",
+	}
+	args = append(args, metadataArgs(meta)...)
+
+	// MP4 only keeps the standard keys unless asked to write custom ones.
+	var movflags []string
+	if len(meta.Tags) > 0 {
+		movflags = append(movflags, "use_metadata_tags")
+	}
+	args = append(args, outputArgs(outputPath, movflags...)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to set metadata: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+func metadataArgs(meta Metadata) []string {
+	var args []string
+	set := func(key, value string) {
+		args = append(args, "-metadata", key+"="+value)
+	}
+
+	if meta.Title != "" {
+		set("title", meta.Title)
+	}
+	if meta.Description != "" {
+		set("description", meta.Description)
+	}
+	if meta.CreationTime != nil {
+		set("creation_time", meta.CreationTime.UTC().Format(time.RFC3339Nano))
+	}
+
+	keys := make([]string, 0, len(meta.Tags))
+	for key := range meta.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		set(key, meta.Tags[key])
+	}
+	return args
+}
+
+// outputArgs ends an ffmpeg command that writes a video. MP4 and MOV outputs
+// are written with the moov atom at the front so that browsers can start
+// playback before the download completes.
+func outputArgs(outputPath string, movflags ...string) []string {
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".mp4", ".mov", ".m4v":
+		flags := append([]string{"faststart"}, movflags...)
+		return []string{"-movflags", "+" + strings.Join(flags, "+"), "-y", outputPath}
+	}
+	return []string{"-y", outputPath}
+}
@@ -0,0 +1,85 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadataValidate(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxMetadataTags; i++ {
+		tooMany[fmt.Sprintf("tag%d", i)] = "v"
+	}
+
+	tests := []struct {
+		name    string
+		meta    Metadata
+		wantErr bool
+	}{
+		{"title and tags", Metadata{Title: "Launch", Tags: map[string]string{"project": "apollo", "take_2": ""}}, false},
+		{"long title", Metadata{Title: strings.Repeat("t", 257)}, true},
+		{"bad key", Metadata{Tags: map[string]string{"bad-key": "v"}}, true},
+		{"key starting with digit", Metadata{Tags: map[string]string{"2nd": "v"}}, true},
+		{"reserved key", Metadata{Tags: map[string]string{"Title": "v"}}, true},
+		{"long value", Metadata{Tags: map[string]string{"notes": strings.Repeat("v", 1025)}}, true},
+		{"too many tags", Metadata{Tags: tooMany}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.meta.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidMetadata) {
+				t.Errorf("Validate() error = %v, want ErrInvalidMetadata", err)
+			}
+		})
+	}
+}
+
+func TestMetadataArgs(t *testing.T) {
+	created := time.Date(2024, 3, 9, 14, 30, 0, 0, time.FixedZone("CET", 3600))
+	meta := Metadata{
+		Title:        "Launch: day 1",
+		Description:  "Raw footage",
+		CreationTime: &created,
+		Tags:         map[string]string{"project": "apollo", "camera": "b"},
+	}
+
+	want := []string{
+		"-metadata", "title=Launch: day 1",
+		"-metadata", "description=Raw footage",
+		"-metadata", "creation_time=2024-03-09T13:30:00Z",
+		"-metadata", "camera=b",
+		"-metadata", "project=apollo",
+	}
+	if got := metadataArgs(meta); !reflect.DeepEqual(got, want) {
+		t.Errorf("metadataArgs() = %v, want %v", got, want)
+	}
+	if !(&Metadata{}).IsZero() || meta.IsZero() {
+		t.Error("IsZero() reported the wrong result")
+	}
+}
+
+func TestOutputArgs(t *testing.T) {
+	tests := []struct {
+		path     string
+		movflags []string
+		want     []string
+	}{
+		{"out.mp4", nil, []string{"-movflags", "+faststart", "-y", "out.mp4"}},
+		{"out.MOV", []string{"use_metadata_tags"}, []string{"-movflags", "+faststart+use_metadata_tags", "-y", "out.MOV"}},
+		{"out.mkv", []string{"use_metadata_tags"}, []string{"-y", "out.mkv"}},
+	}
+
+	for _, tt := range tests {
+		if got := outputArgs(tt.path, tt.movflags...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("outputArgs(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package video
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// mp4FormatName is the format ffprobe reports for the ISO-BMFF family, so
+// natively probed files are indistinguishable from probed ones.
+const mp4FormatName = "mov,mp4,m4a,3gp,3g2,mj2"
+
+// maxMoovSize bounds the metadata read into memory. Real moov boxes are a
+// few megabytes even for long recordings.
+const maxMoovSize = 64 << 20
+
+var (
+	ErrNotMP4       = errors.New("not an MP4 or MOV file")
+	ErrMalformedMP4 = errors.New("malformed MP4 file")
+)
+
+// topLevelBoxes are the box types an MP4 or MOV file may start with.
+var topLevelBoxes = map[string]bool{
+	"ftyp": true, "moov": true, "mdat": true, "free": true,
+	"skip": true, "wide": true, "pnot": true, "uuid": true,
+}
+
+// sampleEntryCodecs maps stsd sample entry types to ffprobe codec names.
+var sampleEntryCodecs = map[string]string{
+	"avc1": "h264", "avc3": "h264",
+	"hvc1": "hevc", "hev1": "hevc",
+	"vp08": "vp8", "vp09": "vp9",
+	"av01": "av1",
+	"mp4v": "mpeg4",
+	"jpeg": "mjpeg",
+	"apch": "prores", "apcn": "prores", "apcs": "prores", "apco": "prores", "ap4h": "prores",
+	"mp4a": "aac",
+	"Opus": "opus",
+	"fLaC": "flac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	".mp3": "mp3",
+	"alac": "alac",
+	"lpcm": "pcm_s16le", "sowt": "pcm_s16le", "twos": "pcm_s16be",
+}
+
+// ProbeMP4 reads the duration, dimensions and codecs of an MP4 or MOV file
+// from its moov box without decoding any media. It returns ErrNotMP4 for
+// other containers.
+func ProbeMP4(path string) (*VideoInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseMP4(file, stat.Size())
+}
+
+// ParseMP4 walks the top-level boxes of an ISO-BMFF file of the given size
+// and parses its moov box. Fragmented files, whose movie header carries no
+// duration, are reported as malformed so callers fall back to ffprobe.
+func ParseMP4(r io.ReadSeeker, size int64) (*VideoInfo, error) {
+	for offset := int64(0); offset < size; {
+		typ, headerSize, boxSize, err := readBoxHeader(r, offset, size)
+		if err != nil {
+			if offset == 0 {
+				return nil, ErrNotMP4
+			}
+			return nil, err
+		}
+		if offset == 0 && !topLevelBoxes[typ] {
+			return nil, ErrNotMP4
+		}
+
+		if typ == "moov" {
+			bodySize := boxSize - headerSize
+			if bodySize > maxMoovSize {
+				return nil, fmt.Errorf("%w: moov box of %d bytes is too large", ErrMalformedMP4, bodySize)
+			}
+			body := make([]byte, bodySize)
+			if _, err := r.Seek(offset+headerSize, io.SeekStart); err != nil {
+				return nil, err
+			}
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrMalformedMP4, err)
+			}
+
+			info, err := parseMoov(body)
+			if err != nil {
+				return nil, err
+			}
+			info.Size = size
+			return info, nil
+		}
+
+		offset += boxSize
+	}
+
+	return nil, fmt.Errorf("%w: no moov box", ErrMalformedMP4)
+}
+
+// readBoxHeader reads the header of the box at offset, returning its type,
+// the length of the header and the size of the whole box.
+func readBoxHeader(r io.ReadSeeker, offset, fileSize int64) (string, int64, int64, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return "", 0, 0, err
+	}
+
+	var header [16]byte
+	if _, err := io.ReadFull(r, header[:8]); err != nil {
+		return "", 0, 0, fmt.Errorf("%w: truncated box header", ErrMalformedMP4)
+	}
+
+	typ := string(header[4:8])
+	headerSize := int64(8)
+	boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+
+	switch boxSize {
+	case 0:
+		boxSize = fileSize - offset
+	case 1:
+		if _, err := io.ReadFull(r, header[8:16]); err != nil {
+			return "", 0, 0, fmt.Errorf("%w: truncated box header", ErrMalformedMP4)
+		}
+		headerSize = 16
+		large := binary.BigEndian.Uint64(header[8:16])
+		if large > math.MaxInt64 {
+			return "", 0, 0, fmt.Errorf("%w: box %q is too large", ErrMalformedMP4, typ)
+		}
+		boxSize = int64(large)
+	}
+
+	if boxSize < headerSize || boxSize > fileSize-offset {
+		return "", 0, 0, fmt.Errorf("%w: box %q has invalid size %d", ErrMalformedMP4, typ, boxSize)
+	}
+	return typ, headerSize, boxSize, nil
+}
+
+type mp4Box struct {
+	typ  string
+	body []byte
+}
+
+// parseBoxes splits data into the boxes it contains.
+func parseBoxes(data []byte) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("%w: truncated box header", ErrMalformedMP4)
+		}
+
+		typ := string(data[4:8])
+		headerSize := uint64(8)
+		boxSize := uint64(binary.BigEndian.Uint32(data[:4]))
+
+		switch boxSize {
+		case 0:
+			boxSize = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("%w: truncated box header", ErrMalformedMP4)
+			}
+			headerSize = 16
+			boxSize = binary.BigEndian.Uint64(data[8:16])
+		}
+
+		if boxSize < headerSize || boxSize > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: box %q has invalid size %d", ErrMalformedMP4, typ, boxSize)
+		}
+
+		boxes = append(boxes, mp4Box{typ: typ, body: data[headerSize:boxSize]})
+		data = data[boxSize:]
+	}
+	return boxes, nil
+}
+
+// findBox descends through nested boxes by type and returns the body of the
+// first match.
+func findBox(data []byte, path ...string) ([]byte, error) {
+	for _, typ := range path {
+		boxes, err := parseBoxes(data)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, box := range boxes {
+			if box.typ == typ {
+				data, found = box.body, true
+				break
+			}
+		}
+		if !found {
+			return nil, nil
+		}
+	}
+	return data, nil
+}
+
+func parseMoov(moov []byte) (*VideoInfo, error) {
+	boxes, err := parseBoxes(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &VideoInfo{Format: mp4FormatName}
+	var sawHeader bool
+	for _, box := range boxes {
+		switch box.typ {
+		case "mvhd":
+			if err := parseMvhd(box.body, info); err != nil {
+				return nil, err
+			}
+			sawHeader = true
+		case "trak":
+			if err := parseTrak(box.body, info); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !sawHeader {
+		return nil, fmt.Errorf("%w: no movie header", ErrMalformedMP4)
+	}
+	return info, nil
+}
+
+// parseMvhd reads the movie timescale and duration.
+func parseMvhd(body []byte, info *VideoInfo) error {
+	var timescale uint32
+	var duration uint64
+
+	switch {
+	case len(body) >= 32 && body[0] == 1:
+		timescale = binary.BigEndian.Uint32(body[20:24])
+		duration = binary.BigEndian.Uint64(body[24:32])
+	case len(body) >= 20 && body[0] == 0:
+		timescale = binary.BigEndian.Uint32(body[12:16])
+		duration = uint64(binary.BigEndian.Uint32(body[16:20]))
+	default:
+		return fmt.Errorf("%w: invalid movie header", ErrMalformedMP4)
+	}
+
+	if timescale == 0 {
+		return fmt.Errorf("%w: zero timescale", ErrMalformedMP4)
+	}
+	// Fragmented files leave the duration unset or all ones and describe
+	// it in the fragments instead.
+	if duration == 0 || duration == math.MaxUint32 || duration == math.MaxUint64 {
+		return fmt.Errorf("%w: movie header has no duration", ErrMalformedMP4)
+	}
+
+	info.Timescale = int(timescale)
+	info.Duration = float64(duration) / float64(timescale)
+	return nil
+}
+
+// parseTrak records the codec of the first video and first audio track,
+// along with the coded size and rotation of the video.
+func parseTrak(trak []byte, info *VideoInfo) error {
+	hdlr, err := findBox(trak, "mdia", "hdlr")
+	if err != nil {
+		return err
+	}
+	if len(hdlr) < 12 {
+		return nil
+	}
+	handler := string(hdlr[8:12])
+
+	stsd, err := findBox(trak, "mdia", "minf", "stbl", "stsd")
+	if err != nil {
+		return err
+	}
+	if len(stsd) < 8 {
+		return nil
+	}
+	entries, err := parseBoxes(stsd[8:])
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	entry := entries[0]
+
+	switch {
+	case handler == "vide" && info.VideoCodec == "":
+		info.VideoCodec = sampleEntryCodec(entry.typ)
+
+		// VisualSampleEntry: 8 bytes of SampleEntry, 16 reserved, then the
+		// coded width and height.
+		if len(entry.body) >= 28 {
+			info.Width = int(binary.BigEndian.Uint16(entry.body[24:26]))
+			info.Height = int(binary.BigEndian.Uint16(entry.body[26:28]))
+		}
+
+		tkhd, err := findBox(trak, "tkhd")
+		if err != nil {
+			return err
+		}
+		if width, height, rotation, ok := parseTkhd(tkhd); ok {
+			if info.Width == 0 || info.Height == 0 {
+				info.Width, info.Height = width, height
+			}
+			info.Rotation = rotation
+		}
+	case handler == "soun" && info.AudioCodec == "":
+		info.AudioCodec = sampleEntryCodec(entry.typ)
+	}
+	return nil
+}
+
+// parseTkhd reads the presentation size and the clockwise rotation encoded
+// in the transformation matrix of a track header.
+func parseTkhd(body []byte) (width, height, rotation int, ok bool) {
+	matrixOffset := 40
+	if len(body) > 0 && body[0] == 1 {
+		matrixOffset = 52
+	}
+	if len(body) < matrixOffset+44 {
+		return 0, 0, 0, false
+	}
+
+	// The matrix is stored as {a, b, u, c, d, v, x, y, w} with a and b in
+	// 16.16 fixed point; a rotation by θ has a = cos θ and b = sin θ.
+	a := float64(int32(binary.BigEndian.Uint32(body[matrixOffset:])))
+	b := float64(int32(binary.BigEndian.Uint32(body[matrixOffset+4:])))
+	if a != 0 || b != 0 {
+		degrees := math.Round(math.Atan2(b, a) * 180 / math.Pi)
+		rotation = normalizeRotation(int(degrees))
+	}
+
+	width = int(binary.BigEndian.Uint32(body[matrixOffset+36:]) >> 16)
+	height = int(binary.BigEndian.Uint32(body[matrixOffset+40:]) >> 16)
+	return width, height, rotation, true
+}
+
+func sampleEntryCodec(typ string) string {
+	if codec, ok := sampleEntryCodecs[typ]; ok {
+		return codec
+	}
+	return strings.ToLower(strings.TrimSpace(typ))
+}
@@ -0,0 +1,161 @@
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+func testBox(typ string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	box := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(box, uint32(8+len(body)))
+	copy(box[4:], typ)
+	return append(box, body...)
+}
+
+func be32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func testMvhd(version byte, timescale uint32, duration uint64) []byte {
+	body := []byte{version, 0, 0, 0}
+	if version == 1 {
+		body = append(body, make([]byte, 16)...)
+		body = append(body, be32(timescale)...)
+		body = binary.BigEndian.AppendUint64(body, duration)
+	} else {
+		body = append(body, make([]byte, 8)...)
+		body = append(body, be32(timescale)...)
+		body = append(body, be32(uint32(duration))...)
+	}
+	return testBox("mvhd", body, make([]byte, 80))
+}
+
+// testTkhd builds a version 0 track header rotated clockwise by a multiple
+// of 90 degrees.
+func testTkhd(width, height uint32, rotation int) []byte {
+	cos := map[int]int32{0: 1, 90: 0, 180: -1, 270: 0}[rotation]
+	sin := map[int]int32{0: 0, 90: 1, 180: 0, 270: -1}[rotation]
+
+	body := append([]byte{0, 0, 0, 0}, make([]byte, 36)...)
+	for _, v := range []int32{cos << 16, sin << 16, 0, -sin << 16, cos << 16, 0, 0, 0, 1 << 30} {
+		body = append(body, be32(uint32(v))...)
+	}
+	body = append(body, be32(width<<16)...)
+	body = append(body, be32(height<<16)...)
+	return testBox("tkhd", body)
+}
+
+func testTrak(handler, entry string, tkhd []byte, entryBody []byte) []byte {
+	hdlr := testBox("hdlr", make([]byte, 8), []byte(handler), make([]byte, 13))
+	stsd := testBox("stsd", make([]byte, 4), be32(1), testBox(entry, entryBody))
+	stbl := testBox("stbl", stsd)
+	return testBox("trak", tkhd, testBox("mdia", hdlr, testBox("minf", stbl)))
+}
+
+func testVisualEntry(width, height uint16) []byte {
+	body := make([]byte, 78)
+	binary.BigEndian.PutUint16(body[24:], width)
+	binary.BigEndian.PutUint16(body[26:], height)
+	return body
+}
+
+func testMP4(mvhd []byte, traks ...[]byte) []byte {
+	ftyp := testBox("ftyp", []byte("isom"), be32(512), []byte("isomiso2avc1mp41"))
+	moov := testBox("moov", append([][]byte{mvhd}, traks...)...)
+	return bytes.Join([][]byte{ftyp, testBox("mdat", make([]byte, 32)), moov}, nil)
+}
+
+func TestParseMP4(t *testing.T) {
+	video := testTrak("vide", "avc1", testTkhd(1920, 1080, 90), testVisualEntry(1920, 1080))
+	audio := testTrak("soun", "mp4a", testTkhd(0, 0, 0), make([]byte, 28))
+
+	// A 64-bit size on the mdat box, as written for files over 4 GB.
+	large := testBox("ftyp", []byte("qt  "), be32(0))
+	mdat := append(be32(1), []byte("mdat")...)
+	mdat = binary.BigEndian.AppendUint64(mdat, 24)
+	large = append(large, append(mdat, make([]byte, 8)...)...)
+	large = append(large, testBox("moov", testMvhd(1, 600, 3000), testTrak("vide", "hvc1", testTkhd(1280, 720, 0), testVisualEntry(0, 0)))...)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    VideoInfo
+		wantErr error
+	}{
+		{
+			name: "rotated video with audio",
+			data: testMP4(testMvhd(0, 1000, 12500), video, audio),
+			want: VideoInfo{Duration: 12.5, Format: mp4FormatName, Width: 1920, Height: 1080, Rotation: 90, VideoCodec: "h264", AudioCodec: "aac", Timescale: 1000},
+		},
+		{
+			name: "64-bit sizes with size from track header",
+			data: large,
+			want: VideoInfo{Duration: 5, Format: mp4FormatName, Width: 1280, Height: 720, VideoCodec: "hevc", Timescale: 600},
+		},
+		{
+			name: "upside down",
+			data: testMP4(testMvhd(0, 90000, 90000), testTrak("vide", "av01", testTkhd(640, 480, 180), testVisualEntry(640, 480))),
+			want: VideoInfo{Duration: 1, Format: mp4FormatName, Width: 640, Height: 480, Rotation: 180, VideoCodec: "av1", Timescale: 90000},
+		},
+		{name: "not mp4", data: []byte("RIFF\x24\x00\x00\x00AVI LIST"), wantErr: ErrNotMP4},
+		{name: "empty", data: nil, wantErr: ErrMalformedMP4},
+		{name: "fragmented", data: testMP4(testMvhd(0, 1000, 0), video), wantErr: ErrMalformedMP4},
+		{name: "zero timescale", data: testMP4(testMvhd(0, 0, 1000), video), wantErr: ErrMalformedMP4},
+		{name: "no moov", data: testBox("ftyp", []byte("isom")), wantErr: ErrMalformedMP4},
+		{name: "truncated moov", data: testMP4(testMvhd(0, 1000, 1000), video)[:120], wantErr: ErrMalformedMP4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMP4(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseMP4() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMP4() error = %v", err)
+			}
+
+			tt.want.Size = int64(len(tt.data))
+			if *got != tt.want {
+				t.Errorf("ParseMP4() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzParseMP4(f *testing.F) {
+	video := testTrak("vide", "avc1", testTkhd(1920, 1080, 90), testVisualEntry(1920, 1080))
+	audio := testTrak("soun", "mp4a", testTkhd(0, 0, 0), make([]byte, 28))
+	f.Add(testMP4(testMvhd(0, 1000, 12500), video, audio))
+	f.Add(testMP4(testMvhd(1, 600, 3000), video))
+	f.Add(testBox("moov", testMvhd(0, 1, 1)))
+	f.Add([]byte("\x00\x00\x00\x01moov\xff\xff\xff\xff\xff\xff\xff\xff"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := ParseMP4(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			if !errors.Is(err, ErrNotMP4) && !errors.Is(err, ErrMalformedMP4) {
+				t.Fatalf("ParseMP4() returned unexpected error %v", err)
+			}
+			return
+		}
+
+		if info.Duration <= 0 || math.IsInf(info.Duration, 0) || math.IsNaN(info.Duration) {
+			t.Errorf("ParseMP4() duration = %v", info.Duration)
+		}
+		if info.Width < 0 || info.Height < 0 || info.Rotation < 0 || info.Rotation >= 360 {
+			t.Errorf("ParseMP4() = %+v", info)
+		}
+		if info.Size != int64(len(data)) {
+			t.Errorf("ParseMP4() size = %d, want %d", info.Size, len(data))
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrUnsupportedOperation = errors.New("operation not supported by this processor")
+
+// NativeProbeProcessor reads MP4 and MOV metadata in Go and runs no external
+// tools. It suits deployments that only store and share uploads, and fails
+// every operation other than GetVideoInfo with ErrUnsupportedOperation.
+type NativeProbeProcessor struct{}
+
+func NewNativeProbeProcessor() *NativeProbeProcessor {
+	return &NativeProbeProcessor{}
+}
+
+func (p *NativeProbeProcessor) Version(ctx context.Context) (string, error) {
+	return ProcessorNativeProbe, nil
+}
+
+func (p *NativeProbeProcessor) GetVideoInfo(ctx context.Context, filepath string) (*VideoInfo, error) {
+	info, err := ProbeMP4(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+	return info, nil
+}
+
+func (p *NativeProbeProcessor) Trim(ctx context.Context, inputPath, outputPath string, start, end float64) error {
+	return unsupported("Trim")
+}
+
+func (p *NativeProbeProcessor) Merge(ctx context.Context, inputPaths []string, outputPath string) error {
+	return unsupported("Merge")
+}
+
+func (p *NativeProbeProcessor) MeasureLoudness(ctx context.Context, inputPath string, target LoudnessTarget) (*LoudnessStats, error) {
+	return nil, unsupported("MeasureLoudness")
+}
+
+func (p *NativeProbeProcessor) NormalizeLoudness(ctx context.Context, inputPath, outputPath string, target LoudnessTarget, measured *LoudnessStats) error {
+	return unsupported("NormalizeLoudness")
+}
+
+func (p *NativeProbeProcessor) RenderAnimation(ctx context.Context, inputPath, outputPath string, opts AnimationOptions) error {
+	return unsupported("RenderAnimation")
+}
+
+func (p *NativeProbeProcessor) GenerateStoryboard(ctx context.Context, inputPath, outputPath string, duration float64, opts StoryboardOptions) error {
+	return unsupported("GenerateStoryboard")
+}
+
+func (p *NativeProbeProcessor) DetectScenes(ctx context.Context, inputPath string, threshold float64) ([]SceneCut, error) {
+	return nil, unsupported("DetectScenes")
+}
+
+func (p *NativeProbeProcessor) DetectDeadAir(ctx context.Context, inputPath string, duration float64, opts DeadAirOptions) (*DeadAir, error) {
+	return nil, unsupported("DetectDeadAir")
+}
+
+func (p *NativeProbeProcessor) MuxSubtitles(ctx context.Context, inputPath, subtitlePath, outputPath, language string) error {
+	return unsupported("MuxSubtitles")
+}
+
+func (p *NativeProbeProcessor) BurnSubtitles(ctx context.Context, inputPath, subtitlePath, outputPath string) error {
+	return unsupported("BurnSubtitles")
+}
+
+func (p *NativeProbeProcessor) Transform(ctx context.Context, inputPath, outputPath string, opts TransformOptions) error {
+	return unsupported("Transform")
+}
+
+func (p *NativeProbeProcessor) ChangeSpeed(ctx context.Context, inputPath, outputPath string, factor float64) error {
+	return unsupported("ChangeSpeed")
+}
+
+func (p *NativeProbeProcessor) Reverse(ctx context.Context, inputPath, outputPath string) error {
+	return unsupported("Reverse")
+}
+
+func (p *NativeProbeProcessor) Loop(ctx context.Context, inputPath, outputPath string, opts LoopOptions) error {
+	return unsupported("Loop")
+}
+
+func (p *NativeProbeProcessor) Compose(ctx context.Context, inputs []ComposeInput, outputPath string, opts ComposeOptions) error {
+	return unsupported("Compose")
+}
+
+func (p *NativeProbeProcessor) ExtractFrame(ctx context.Context, inputPath, outputPath string, timestamp float64) error {
+	return unsupported("ExtractFrame")
+}
+
+func (p *NativeProbeProcessor) RenderWaveform(ctx context.Context, inputPath, outputPath string, opts WaveformOptions) error {
+	return unsupported("RenderWaveform")
+}
+
+func (p *NativeProbeProcessor) ComputePeaks(ctx context.Context, inputPath, scratchPath string, opts PeaksOptions) (*Peaks, error) {
+	return nil, unsupported("ComputePeaks")
+}
+
+func (p *NativeProbeProcessor) MeasureQuality(ctx context.Context, inputPath string, ref QualityReference) (*QualityStats, error) {
+	return nil, unsupported("MeasureQuality")
+}
+
+func (p *NativeProbeProcessor) Fingerprint(ctx context.Context, inputPath string, duration float64) ([]uint64, error) {
+	return nil, unsupported("Fingerprint")
+}
+
+func (p *NativeProbeProcessor) SetMetadata(ctx context.Context, inputPath, outputPath string, meta Metadata) error {
+	return unsupported("SetMetadata")
+}
+
+func (p *NativeProbeProcessor) Split(ctx context.Context, inputPath, outputDir string, segmentTime float64) ([]string, error) {
+	return nil, unsupported("Split")
+}
+
+func (p *NativeProbeProcessor) Enhance(ctx context.Context, inputPath, outputPath string, opts EnhanceOptions) error {
+	return unsupported("Enhance")
+}
+
+func (p *NativeProbeProcessor) Redact(ctx context.Context, inputPath, outputPath string, opts RedactOptions) error {
+	return unsupported("Redact")
+}
+
+func unsupported(operation string) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedOperation, operation)
+}
@@ -0,0 +1,105 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	AnimationGIF  = "gif"
+	AnimationWebP = "webp"
+
+	MaxAnimationDuration = 30.0
+
+	// MaxAnimationLoop is the largest loop count either format can store.
+	MaxAnimationLoop = 65535
+)
+
+var ErrInvalidAnimation = errors.New("invalid animation options")
+
+// AnimationOptions describes a looping preview rendered from a time range of
+// a video. Loop follows the GIF convention: 0 loops forever, -1 plays once and
+// N repeats the animation N more times. WebP has no way to play once, so -1
+// is only accepted for GIF.
+type AnimationOptions struct {
+	Format   string  `json:"format"`
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+	FPS      int     `json:"fps"`
+	Width    int     `json:"width"`
+	Loop     int     `json:"loop"`
+}
+
+// Validate checks the options against the length of the source video.
+func (o AnimationOptions) Validate(sourceDuration float64) error {
+	if o.Format != AnimationGIF && o.Format != AnimationWebP {
+		return fmt.Errorf("%w: format must be gif or webp", ErrInvalidAnimation)
+	}
+	if o.Start < 0 || o.Duration <= 0 || o.Start+o.Duration > sourceDuration {
+		return fmt.Errorf("%w: time range must lie within the video", ErrInvalidAnimation)
+	}
+	if o.Duration > MaxAnimationDuration {
+		return fmt.Errorf("%w: duration must not exceed %.0f seconds", ErrInvalidAnimation, MaxAnimationDuration)
+	}
+	if o.FPS < 1 || o.FPS > 30 {
+		return fmt.Errorf("%w: fps must be between 1 and 30", ErrInvalidAnimation)
+	}
+	if o.Width < 16 || o.Width > 1920 {
+		return fmt.Errorf("%w: width must be between 16 and 1920", ErrInvalidAnimation)
+	}
+	if o.Format == AnimationWebP && (o.Loop < 0 || o.Loop > MaxAnimationLoop) {
+		return fmt.Errorf("%w: loop must be between 0 and %d for webp", ErrInvalidAnimation, MaxAnimationLoop)
+	}
+	if o.Loop < -1 || o.Loop > MaxAnimationLoop {
+		return fmt.Errorf("%w: loop must be between -1 and %d", ErrInvalidAnimation, MaxAnimationLoop)
+	}
+	return nil
+}
+
+// ContentType returns the MIME type of the rendered animation.
+func (o AnimationOptions) ContentType() string {
+	if o.Format == AnimationWebP {
+		return "image/webp"
+	}
+	return "image/gif"
+}
+
+func (p *FFmpegProcessor) RenderAnimation(ctx context.Context, inputPath, outputPath string, opts AnimationOptions) error {
+	if output, err := p.runFFmpeg(ctx, animationArgs(inputPath, outputPath, opts)); err != nil {
+		return fmt.Errorf("failed to render animation: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+func animationArgs(inputPath, outputPath string, opts AnimationOptions) []string {
+	scale := fmt.Sprintf("fps=%d,scale=%d:-2:flags=lanczos", opts.FPS, opts.Width)
+
+	args := []string{
+		"-ss", fmt.Sprintf("%.3f", opts.Start),
+		"-t", fmt.Sprintf("%.3f", opts.Duration),
+		"-i", inputPath,
+		"-an",
+	}
+
+	if opts.Format == AnimationWebP {
+		args = append(args,
+			"-vf", scale,
+			"-c:v", "libwebp",
+			"-lossless", "0",
+			"-q:v", "75",
+			"-compression_level", "6",
+		)
+	} else {
+		// A palette generated from the clip itself keeps GIF colour banding low.
+		args = append(args,
+			"-filter_complex", scale+",split[a][b];[a]palettegen=stats_mode=diff[p];[b][p]paletteuse=dither=bayer:bayer_scale=5:diff_mode=rectangle",
+		)
+	}
+
+	return append(args,
+		"-loop", fmt.Sprintf("%d", opts.Loop),
+		"-y",
+		outputPath,
+	)
+}
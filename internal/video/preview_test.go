@@ -0,0 +1,64 @@
+package video
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnimationOptionsValidate(t *testing.T) {
+	valid := AnimationOptions{Format: AnimationGIF, Start: 1, Duration: 3, FPS: 12, Width: 480}
+
+	tests := []struct {
+		name    string
+		modify  func(o *AnimationOptions)
+		wantErr bool
+	}{
+		{"valid gif", func(o *AnimationOptions) {}, false},
+		{"valid gif played once", func(o *AnimationOptions) { o.Loop = -1 }, false},
+		{"valid webp looping", func(o *AnimationOptions) { o.Format = AnimationWebP; o.Loop = 3 }, false},
+		{"webp played once", func(o *AnimationOptions) { o.Format = AnimationWebP; o.Loop = -1 }, true},
+		{"webp loop too high", func(o *AnimationOptions) { o.Format = AnimationWebP; o.Loop = 65536 }, true},
+		{"gif highest loop", func(o *AnimationOptions) { o.Loop = 65535 }, false},
+		{"gif loop too high", func(o *AnimationOptions) { o.Loop = 65536 }, true},
+		{"unknown format", func(o *AnimationOptions) { o.Format = "apng" }, true},
+		{"range past end", func(o *AnimationOptions) { o.Start = 8 }, true},
+		{"zero duration", func(o *AnimationOptions) { o.Duration = 0 }, true},
+		{"fps too high", func(o *AnimationOptions) { o.FPS = 60 }, true},
+		{"width too small", func(o *AnimationOptions) { o.Width = 8 }, true},
+		{"negative loop", func(o *AnimationOptions) { o.Loop = -2 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid
+			tt.modify(&opts)
+			err := opts.Validate(10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAnimationArgs(t *testing.T) {
+	gif := strings.Join(animationArgs("in.mp4", "out.gif", AnimationOptions{
+		Format: AnimationGIF, Start: 2, Duration: 3, FPS: 10, Width: 320, Loop: 0,
+	}), " ")
+	for _, want := range []string{"-ss 2.000", "-t 3.000", "fps=10,scale=320:-2", "palettegen", "paletteuse", "-loop 0"} {
+		if !strings.Contains(gif, want) {
+			t.Errorf("gif args %q missing %q", gif, want)
+		}
+	}
+
+	webp := strings.Join(animationArgs("in.mp4", "out.webp", AnimationOptions{
+		Format: AnimationWebP, Start: 0, Duration: 2, FPS: 15, Width: 480, Loop: 3,
+	}), " ")
+	for _, want := range []string{"-c:v libwebp", "fps=15,scale=480:-2", "-loop 3"} {
+		if !strings.Contains(webp, want) {
+			t.Errorf("webp args %q missing %q", webp, want)
+		}
+	}
+	if strings.Contains(webp, "palettegen") {
+		t.Errorf("webp args should not use a palette: %q", webp)
+	}
+}
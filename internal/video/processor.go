@@ -9,23 +9,70 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 )
 
+// VideoInfo describes a media file. Timescale is the number of time units
+// per second of an MP4 or MOV movie header, and zero for other containers.
 type VideoInfo struct {
-	Duration float64
-	Format   string
-	Size     int64
+	Duration   float64
+	Format     string
+	Size       int64
+	Width      int
+	Height     int
+	Rotation   int
+	VideoCodec string
+	AudioCodec string
+	Timescale  int
+}
+
+// DisplaySize returns the dimensions of the picture as shown, with width and
+// height swapped when the rotation metadata turns it on its side.
+func (i *VideoInfo) DisplaySize() (int, int) {
+	if i.Rotation == 90 || i.Rotation == 270 {
+		return i.Height, i.Width
+	}
+	return i.Width, i.Height
 }
 
 type Processor interface {
+	// Version identifies the backend and the version of the tools it runs,
+	// so that outputs can be traced to what produced them.
+	Version(ctx context.Context) (string, error)
 	GetVideoInfo(ctx context.Context, filepath string) (*VideoInfo, error)
 	Trim(ctx context.Context, inputPath, outputPath string, start, end float64) error
 	Merge(ctx context.Context, inputPaths []string, outputPath string) error
+	MeasureLoudness(ctx context.Context, inputPath string, target LoudnessTarget) (*LoudnessStats, error)
+	NormalizeLoudness(ctx context.Context, inputPath, outputPath string, target LoudnessTarget, measured *LoudnessStats) error
+	RenderAnimation(ctx context.Context, inputPath, outputPath string, opts AnimationOptions) error
+	GenerateStoryboard(ctx context.Context, inputPath, outputPath string, duration float64, opts StoryboardOptions) error
+	DetectScenes(ctx context.Context, inputPath string, threshold float64) ([]SceneCut, error)
+	DetectDeadAir(ctx context.Context, inputPath string, duration float64, opts DeadAirOptions) (*DeadAir, error)
+	MuxSubtitles(ctx context.Context, inputPath, subtitlePath, outputPath, language string) error
+	BurnSubtitles(ctx context.Context, inputPath, subtitlePath, outputPath string) error
+	Transform(ctx context.Context, inputPath, outputPath string, opts TransformOptions) error
+	ChangeSpeed(ctx context.Context, inputPath, outputPath string, factor float64) error
+	Reverse(ctx context.Context, inputPath, outputPath string) error
+	Loop(ctx context.Context, inputPath, outputPath string, opts LoopOptions) error
+	Compose(ctx context.Context, inputs []ComposeInput, outputPath string, opts ComposeOptions) error
+	ExtractFrame(ctx context.Context, inputPath, outputPath string, timestamp float64) error
+	RenderWaveform(ctx context.Context, inputPath, outputPath string, opts WaveformOptions) error
+	ComputePeaks(ctx context.Context, inputPath, scratchPath string, opts PeaksOptions) (*Peaks, error)
+	MeasureQuality(ctx context.Context, inputPath string, ref QualityReference) (*QualityStats, error)
+	Fingerprint(ctx context.Context, inputPath string, duration float64) ([]uint64, error)
+	SetMetadata(ctx context.Context, inputPath, outputPath string, meta Metadata) error
+	Split(ctx context.Context, inputPath, outputDir string, segmentTime float64) ([]string, error)
+	Enhance(ctx context.Context, inputPath, outputPath string, opts EnhanceOptions) error
+	Redact(ctx context.Context, inputPath, outputPath string, opts RedactOptions) error
 }
 
 type FFmpegProcessor struct {
 	ffmpegPath  string
 	ffprobePath string
+	scheduler   *Scheduler
+
+	versionMu sync.Mutex
+	version   string
 }
 
 func NewFFmpegProcessor() *FFmpegProcessor {
@@ -35,11 +82,46 @@ func NewFFmpegProcessor() *FFmpegProcessor {
 	}
 }
 
+// NewScheduledFFmpegProcessor returns a processor that runs every ffmpeg
+// process through scheduler. Probing is cheap and is not scheduled.
+func NewScheduledFFmpegProcessor(scheduler *Scheduler) *FFmpegProcessor {
+	p := NewFFmpegProcessor()
+	p.scheduler = scheduler
+	return p
+}
+
+// Version returns the first line of ffmpeg -version, such as "ffmpeg version
+// 6.1.1 Copyright (c) 2000-2023 the FFmpeg developers". It is read once and
+// cached; a failed attempt is retried on the next call.
+func (p *FFmpegProcessor) Version(ctx context.Context) (string, error) {
+	p.versionMu.Lock()
+	defer p.versionMu.Unlock()
+	if p.version != "" {
+		return p.version, nil
+	}
+
+	output, err := exec.CommandContext(ctx, p.ffmpegPath, "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get ffmpeg version: %w", err)
+	}
+
+	line, _, _ := strings.Cut(string(output), "\n")
+	p.version = strings.TrimSpace(line)
+	return p.version, nil
+}
+
+// GetVideoInfo reads MP4 and MOV files natively and falls back to ffprobe
+// for other containers and for files the native parser cannot handle.
 func (p *FFmpegProcessor) GetVideoInfo(ctx context.Context, filepath string) (*VideoInfo, error) {
+	if info, err := ProbeMP4(filepath); err == nil {
+		return info, nil
+	}
+
 	args := []string{
 		"-v", "quiet",
 		"-print_format", "json",
 		"-show_format",
+		"-show_streams",
 		filepath,
 	}
 
@@ -49,12 +131,28 @@ func (p *FFmpegProcessor) GetVideoInfo(ctx context.Context, filepath string) (*V
 		return nil, fmt.Errorf("failed to get video info: %w", err)
 	}
 
+	return parseProbeOutput(output)
+}
+
+func parseProbeOutput(output []byte) (*VideoInfo, error) {
 	var result struct {
 		Format struct {
 			Duration string `json:"duration"`
 			Size     string `json:"size"`
 			Format   string `json:"format_name"`
 		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+			Tags      struct {
+				Rotate string `json:"rotate"`
+			} `json:"tags"`
+			SideData []struct {
+				Rotation *float64 `json:"rotation"`
+			} `json:"side_data_list"`
+		} `json:"streams"`
 	}
 
 	if err := json.Unmarshal(output, &result); err != nil {
@@ -71,11 +169,40 @@ func (p *FFmpegProcessor) GetVideoInfo(ctx context.Context, filepath string) (*V
 		return nil, fmt.Errorf("invalid size format: %w", err)
 	}
 
-	return &VideoInfo{
+	info := &VideoInfo{
 		Duration: duration,
 		Format:   result.Format.Format,
 		Size:     size,
-	}, nil
+	}
+
+	for _, stream := range result.Streams {
+		switch {
+		case stream.CodecType == "video" && info.VideoCodec == "":
+			info.VideoCodec = stream.CodecName
+			info.Width = stream.Width
+			info.Height = stream.Height
+
+			// Older files carry a clockwise "rotate" tag; newer ffprobe
+			// reports a display matrix whose rotation is counter-clockwise.
+			if stream.Tags.Rotate != "" {
+				rotate, _ := strconv.Atoi(stream.Tags.Rotate)
+				info.Rotation = normalizeRotation(rotate)
+			}
+			for _, side := range stream.SideData {
+				if side.Rotation != nil {
+					info.Rotation = normalizeRotation(-int(*side.Rotation))
+				}
+			}
+		case stream.CodecType == "audio" && info.AudioCodec == "":
+			info.AudioCodec = stream.CodecName
+		}
+	}
+
+	return info, nil
+}
+
+func normalizeRotation(degrees int) int {
+	return ((degrees % 360) + 360) % 360
 }
 
 func (p *FFmpegProcessor) Trim(ctx context.Context, inputPath, outputPath string, start, end float64) error {
@@ -83,26 +210,20 @@ func (p *FFmpegProcessor) Trim(ctx context.Context, inputPath, outputPath string
 		"-i", inputPath,
 		"-ss", fmt.Sprintf("%.3f", start),
 		"-t", fmt.Sprintf("%.3f", end-start),
-		"-c:v", "libx264",
-		"-c:a", "aac",
-		"-y",
-		outputPath,
 	}
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
 
-	cmd := exec.CommandContext(ctx, p.ffmpegPath, args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
 		return fmt.Errorf("failed to trim video: %w, output: %s", err, string(output))
 	}
 
 	return nil
 }
 
+// Merge concatenates inputPaths. The concat list gets a unique name next to
+// the output, since several merges may run at once.
 func (p *FFmpegProcessor) Merge(ctx context.Context, inputPaths []string, outputPath string) error {
-
-	tmpDir := filepath.Dir(outputPath)
-	listPath := filepath.Join(tmpDir, "filelist.txt")
-
 	var fileContent string
 	for _, path := range inputPaths {
 
@@ -115,26 +236,57 @@ func (p *FFmpegProcessor) Merge(ctx context.Context, inputPaths []string, output
 		fileContent += fmt.Sprintf("file '%s'\n", escapedPath)
 	}
 
-	if err := os.WriteFile(listPath, []byte(fileContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file list: %w", err)
+	list, err := os.CreateTemp(filepath.Dir(outputPath), "concat-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create file list: %w", err)
 	}
+	listPath := list.Name()
 	defer os.Remove(listPath)
 
+	_, err = list.WriteString(fileContent)
+	if closeErr := list.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file list: %w", err)
+	}
+
 	args := []string{
 		"-f", "concat",
 		"-safe", "0",
 		"-i", listPath,
-		"-c:v", "libx264",
-		"-c:a", "aac",
-		"-y",
-		outputPath,
 	}
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
 
-	cmd := exec.CommandContext(ctx, p.ffmpegPath, args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
 		return fmt.Errorf("failed to merge videos: %w, output: %s", err, string(output))
 	}
 
 	return nil
 }
+
+// encodeArgs returns the codec arguments used by every operation that
+// re-encodes its output, taken from the encoding carried by ctx, followed by
+// the scheduler's encoder thread limit.
+func (p *FFmpegProcessor) encodeArgs(ctx context.Context) []string {
+	return append(EncodingFromContext(ctx).Args(), p.scheduler.threadArgs()...)
+}
+
+// runFFmpeg executes ffmpeg with args and returns its combined output. With
+// a scheduler it first waits for a slot, failing with a QueueFullError when
+// the backlog is full.
+func (p *FFmpegProcessor) runFFmpeg(ctx context.Context, args []string) ([]byte, error) {
+	if p.scheduler == nil {
+		cmd := exec.CommandContext(ctx, p.ffmpegPath, args...)
+		return cmd.CombinedOutput()
+	}
+
+	var output []byte
+	err := p.scheduler.Run(ctx, func() error {
+		var err error
+		output, err = p.scheduler.Command(ctx, p.ffmpegPath, args).CombinedOutput()
+		return err
+	})
+	return output, err
+}
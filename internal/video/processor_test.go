@@ -104,4 +104,74 @@ func TestFFmpegProcessor(t *testing.T) {
 			t.Errorf("Expected duration around %.1fs, got %.1fs", expectedDuration, info.Duration)
 		}
 	})
+
+	// The test video has no audio stream, which the audio filters of speed
+	// changes and reversal must not be applied to.
+	t.Run("ChangeSpeed without audio", func(t *testing.T) {
+		outputPath := filepath.Join(tmpDir, "fast.mp4")
+		if err := processor.ChangeSpeed(ctx, testVideoPath, outputPath, 2); err != nil {
+			t.Fatalf("Failed to change speed: %v", err)
+		}
+	})
+
+	t.Run("Reverse without audio", func(t *testing.T) {
+		outputPath := filepath.Join(tmpDir, "reversed.mp4")
+		if err := processor.Reverse(ctx, testVideoPath, outputPath); err != nil {
+			t.Fatalf("Failed to reverse video: %v", err)
+		}
+	})
+}
+
+func TestParseProbeOutput(t *testing.T) {
+	tests := []struct {
+		name         string
+		streams      string
+		wantRotation int
+		wantDisplay  [2]int
+	}{
+		{
+			name:        "no rotation",
+			streams:     `{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080}`,
+			wantDisplay: [2]int{1920, 1080},
+		},
+		{
+			name:         "rotate tag",
+			streams:      `{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080, "tags": {"rotate": "90"}}`,
+			wantRotation: 90,
+			wantDisplay:  [2]int{1080, 1920},
+		},
+		{
+			name:         "display matrix",
+			streams:      `{"codec_type": "video", "codec_name": "hevc", "width": 1920, "height": 1080, "side_data_list": [{"side_data_type": "Display Matrix", "rotation": 90}]}`,
+			wantRotation: 270,
+			wantDisplay:  [2]int{1080, 1920},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := fmt.Sprintf(`{
+				"streams": [%s, {"codec_type": "audio", "codec_name": "aac"}],
+				"format": {"duration": "12.500", "size": "2048", "format_name": "mov,mp4,m4a,3gp,3g2,mj2"}
+			}`, tt.streams)
+
+			info, err := parseProbeOutput([]byte(output))
+			if err != nil {
+				t.Fatalf("parseProbeOutput() unexpected error: %v", err)
+			}
+			if info.Duration != 12.5 || info.Size != 2048 || info.AudioCodec != "aac" {
+				t.Errorf("unexpected format info: %+v", info)
+			}
+			if info.Rotation != tt.wantRotation {
+				t.Errorf("Rotation = %d, want %d", info.Rotation, tt.wantRotation)
+			}
+			if w, h := info.DisplaySize(); [2]int{w, h} != tt.wantDisplay {
+				t.Errorf("DisplaySize() = %dx%d, want %v", w, h, tt.wantDisplay)
+			}
+		})
+	}
+
+	if _, err := parseProbeOutput([]byte(`{"format": {"duration": "N/A", "size": "1"}}`)); err == nil {
+		t.Error("Expected error for missing duration")
+	}
 }
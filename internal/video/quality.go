@@ -0,0 +1,135 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxPSNR caps the PSNR of identical frames, which ffmpeg reports as inf.
+const MaxPSNR = 100
+
+var ErrNoFramesCompared = errors.New("no frames were compared")
+
+// QualityReference is the segment of a source video a derived video is
+// compared against. Width and Height are the displayed size of the derived
+// video; the source is scaled to match when they differ.
+type QualityReference struct {
+	Path     string
+	Start    float64
+	Duration float64
+	Width    int
+	Height   int
+}
+
+// QualityStats summarize the per-frame PSNR, in dB, and SSIM of a comparison.
+type QualityStats struct {
+	Frames      int
+	PSNRAverage float64
+	PSNRMin     float64
+	SSIMAverage float64
+	SSIMMin     float64
+}
+
+// MeasureQuality compares every frame of the video at inputPath with the
+// frame of the reference segment at the same timestamp using ffmpeg's psnr
+// and ssim filters.
+func (p *FFmpegProcessor) MeasureQuality(ctx context.Context, inputPath string, ref QualityReference) (*QualityStats, error) {
+	dir, err := os.MkdirTemp("", "quality-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stats directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	psnrPath := filepath.Join(dir, "psnr.log")
+	ssimPath := filepath.Join(dir, "ssim.log")
+
+	args := []string{
+		"-i", inputPath,
+		"-ss", fmt.Sprintf("%.3f", ref.Start),
+		"-t", fmt.Sprintf("%.3f", ref.Duration),
+		"-i", ref.Path,
+		"-filter_complex", qualityFilter(ref, psnrPath, ssimPath),
+		"-f", "null",
+		"-",
+	}
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return nil, fmt.Errorf("failed to measure quality: %w, output: %s", err, string(output))
+	}
+
+	psnrLog, err := os.ReadFile(psnrPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read psnr stats: %w", err)
+	}
+	ssimLog, err := os.ReadFile(ssimPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssim stats: %w", err)
+	}
+
+	return summarizeQuality(parseStatsLog(psnrLog, "psnr_avg"), parseStatsLog(ssimLog, "All"))
+}
+
+// qualityFilter scales the reference to the size of the derived video, then
+// feeds both into psnr and ssim, which write one line per frame to their
+// stats files.
+func qualityFilter(ref QualityReference, psnrPath, ssimPath string) string {
+	return strings.Join([]string{
+		"[0:v]setsar=1,split[d1][d2]",
+		fmt.Sprintf("[1:v]scale=%d:%d,setsar=1,split[r1][r2]", ref.Width, ref.Height),
+		fmt.Sprintf("[d1][r1]psnr=stats_file=%s", escapeFilterValue(psnrPath)),
+		fmt.Sprintf("[d2][r2]ssim=stats_file=%s", escapeFilterValue(ssimPath)),
+	}, ";")
+}
+
+// parseStatsLog extracts the value of key from every line of a psnr or ssim
+// stats file. Lines are space-separated key:value pairs, such as
+// "n:1 mse_avg:2.41 ... psnr_avg:44.31" or "n:1 Y:0.98 U:0.99 V:0.99 All:0.98 (17.6)".
+func parseStatsLog(data []byte, key string) []float64 {
+	var values []float64
+	prefix := key + ":"
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		for _, field := range strings.Fields(scanner.Text()) {
+			if !strings.HasPrefix(field, prefix) {
+				continue
+			}
+			raw := strings.TrimPrefix(field, prefix)
+			if raw == "inf" {
+				values = append(values, math.Inf(1))
+			} else if v, err := strconv.ParseFloat(raw, 64); err == nil {
+				values = append(values, v)
+			}
+			break
+		}
+	}
+	return values
+}
+
+func summarizeQuality(psnr, ssim []float64) (*QualityStats, error) {
+	if len(psnr) == 0 || len(ssim) == 0 {
+		return nil, ErrNoFramesCompared
+	}
+
+	stats := &QualityStats{Frames: len(psnr), PSNRMin: MaxPSNR, SSIMMin: 1}
+	for _, v := range psnr {
+		v = math.Min(v, MaxPSNR)
+		stats.PSNRAverage += v
+		stats.PSNRMin = math.Min(stats.PSNRMin, v)
+	}
+	for _, v := range ssim {
+		stats.SSIMAverage += v
+		stats.SSIMMin = math.Min(stats.SSIMMin, v)
+	}
+	stats.PSNRAverage /= float64(len(psnr))
+	stats.SSIMAverage /= float64(len(ssim))
+	return stats, nil
+}
@@ -0,0 +1,54 @@
+package video
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStatsLog(t *testing.T) {
+	psnr := []byte("n:1 mse_avg:2.41 mse_y:2.90 mse_u:1.20 mse_v:1.44 psnr_avg:44.31 psnr_y:43.51 psnr_u:47.34 psnr_v:46.55\n" +
+		"n:2 mse_avg:0.00 mse_y:0.00 mse_u:0.00 mse_v:0.00 psnr_avg:inf psnr_y:inf psnr_u:inf psnr_v:inf\n")
+	ssim := []byte("n:1 Y:0.981234 U:0.990000 V:0.991000 All:0.984567 (18.119)\n" +
+		"n:2 Y:1.000000 U:1.000000 V:1.000000 All:1.000000 (inf)\n")
+
+	if got, want := parseStatsLog(psnr, "psnr_avg"), []float64{44.31, math.Inf(1)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStatsLog(psnr) = %v, want %v", got, want)
+	}
+	if got, want := parseStatsLog(ssim, "All"), []float64{0.984567, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStatsLog(ssim) = %v, want %v", got, want)
+	}
+}
+
+func TestSummarizeQuality(t *testing.T) {
+	stats, err := summarizeQuality([]float64{40, math.Inf(1), 30}, []float64{0.9, 1, 0.95})
+	if err != nil {
+		t.Fatalf("summarizeQuality() error = %v", err)
+	}
+
+	want := &QualityStats{Frames: 3, PSNRAverage: 170.0 / 3, PSNRMin: 30, SSIMAverage: 2.85 / 3, SSIMMin: 0.9}
+	if stats.Frames != want.Frames || stats.PSNRMin != want.PSNRMin || stats.SSIMMin != want.SSIMMin ||
+		math.Abs(stats.PSNRAverage-want.PSNRAverage) > 1e-9 || math.Abs(stats.SSIMAverage-want.SSIMAverage) > 1e-9 {
+		t.Errorf("summarizeQuality() = %+v, want %+v", stats, want)
+	}
+
+	if _, err := summarizeQuality(nil, nil); !errors.Is(err, ErrNoFramesCompared) {
+		t.Errorf("summarizeQuality(nil) error = %v, want ErrNoFramesCompared", err)
+	}
+}
+
+func TestQualityFilter(t *testing.T) {
+	filter := qualityFilter(QualityReference{Width: 1280, Height: 720}, "/tmp/a:b/psnr.log", "/tmp/ssim.log")
+
+	for _, want := range []string{
+		"[1:v]scale=1280:720,setsar=1,split[r1][r2]",
+		`psnr=stats_file=/tmp/a\\:b/psnr.log`,
+		"[d2][r2]ssim=stats_file=/tmp/ssim.log",
+	} {
+		if !strings.Contains(filter, want) {
+			t.Errorf("qualityFilter() = %q, missing %q", filter, want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	RedactBlur     = "blur"
+	RedactPixelate = "pixelate"
+
+	MaxRedactRegions = 20
+
+	// pixelBlockSize is the edge of the blocks a pixelated region is made of.
+	pixelBlockSize = 16
+)
+
+var ErrInvalidRedaction = errors.New("invalid redaction")
+
+// RedactKeyframe moves a region to X, Y at Time. Between keyframes the
+// position is interpolated linearly.
+type RedactKeyframe struct {
+	Time float64 `json:"time"`
+	X    int     `json:"x"`
+	Y    int     `json:"y"`
+}
+
+// RedactRegion is a rectangle hidden from Start to End, or to the end of the
+// video when End is zero. X and Y give its position at Start; Keyframes move
+// it from there and it stays at the last one. The size is fixed, because
+// ffmpeg's crop filter only evaluates the size once.
+type RedactRegion struct {
+	Rect
+	Start     float64          `json:"start"`
+	End       float64          `json:"end,omitempty"`
+	Keyframes []RedactKeyframe `json:"keyframes,omitempty"`
+}
+
+// RedactOptions hide regions of the picture by blurring or pixelating them.
+// Coordinates refer to the picture as displayed.
+type RedactOptions struct {
+	Mode    string         `json:"mode"`
+	Regions []RedactRegion `json:"regions"`
+}
+
+// Validate checks the regions against the displayed size and duration of
+// the source and fills in the default mode.
+func (o *RedactOptions) Validate(width, height int, duration float64) error {
+	switch o.Mode {
+	case "":
+		o.Mode = RedactBlur
+	case RedactBlur, RedactPixelate:
+	default:
+		return fmt.Errorf("%w: mode must be blur or pixelate", ErrInvalidRedaction)
+	}
+
+	if len(o.Regions) == 0 || len(o.Regions) > MaxRedactRegions {
+		return fmt.Errorf("%w: between 1 and %d regions must be given", ErrInvalidRedaction, MaxRedactRegions)
+	}
+
+	for i, region := range o.Regions {
+		if region.Width < 2 || region.Height < 2 {
+			return fmt.Errorf("%w: region %d must be at least 2x2 pixels", ErrInvalidRedaction, i+1)
+		}
+		end := region.End
+		if end == 0 {
+			end = duration
+		}
+		if region.Start < 0 || region.Start >= end || end > duration {
+			return fmt.Errorf("%w: region %d must start before it ends and lie within the video", ErrInvalidRedaction, i+1)
+		}
+
+		inside := func(x, y int) bool {
+			return x >= 0 && y >= 0 && x+region.Width <= width && y+region.Height <= height
+		}
+		if !inside(region.X, region.Y) {
+			return fmt.Errorf("%w: region %d must lie within the %dx%d picture", ErrInvalidRedaction, i+1, width, height)
+		}
+
+		previous := region.Start
+		for _, kf := range region.Keyframes {
+			if kf.Time <= previous || kf.Time > end {
+				return fmt.Errorf("%w: keyframes of region %d must be in ascending order within its time range", ErrInvalidRedaction, i+1)
+			}
+			if !inside(kf.X, kf.Y) {
+				return fmt.Errorf("%w: keyframe at %.3f of region %d must keep it within the picture", ErrInvalidRedaction, kf.Time, i+1)
+			}
+			previous = kf.Time
+		}
+	}
+	return nil
+}
+
+// Redact re-encodes a video with the regions of validated options hidden.
+func (p *FFmpegProcessor) Redact(ctx context.Context, inputPath, outputPath string, opts RedactOptions) error {
+	args := []string{
+		"-i", inputPath,
+		"-filter_complex", redactFilter(opts),
+		"-map", "[v]",
+		"-map", "0:a?",
+	}
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to redact video: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// redactFilter splits the picture into a base and one copy per region. Each
+// copy is cropped to its region, blurred or pixelated and overlaid back at
+// the same, possibly moving, position while the region is active.
+func redactFilter(opts RedactOptions) string {
+	n := len(opts.Regions)
+
+	var split strings.Builder
+	split.WriteString("[0:v]split=" + fmt.Sprint(n+1) + "[base]")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&split, "[c%d]", i)
+	}
+	graph := []string{split.String()}
+
+	previous := "base"
+	for i, region := range opts.Regions {
+		x, y := positionExprs(region)
+		graph = append(graph, fmt.Sprintf("[c%d]crop=w=%d:h=%d:x='%s':y='%s',%s[r%d]",
+			i, region.Width, region.Height, x, y, redactEffect(opts.Mode, region.Rect), i))
+
+		enable := fmt.Sprintf("gte(t,%s)", formatFilterNumber(region.Start))
+		if region.End != 0 {
+			enable = fmt.Sprintf("between(t,%s,%s)", formatFilterNumber(region.Start), formatFilterNumber(region.End))
+		}
+
+		out := fmt.Sprintf("o%d", i)
+		if i == n-1 {
+			out = "v"
+		}
+		graph = append(graph, fmt.Sprintf("[%s][r%d]overlay=x='%s':y='%s':enable='%s'[%s]", previous, i, x, y, enable, out))
+		previous = out
+	}
+
+	return strings.Join(graph, ";")
+}
+
+func redactEffect(mode string, r Rect) string {
+	if mode == RedactPixelate {
+		w := max(1, r.Width/pixelBlockSize)
+		h := max(1, r.Height/pixelBlockSize)
+		return fmt.Sprintf("scale=%d:%d:flags=area,scale=%d:%d:flags=neighbor", w, h, r.Width, r.Height)
+	}
+	// A sigma of a quarter of the region hides detail regardless of size.
+	sigma := math.Max(4, float64(max(r.Width, r.Height))/4)
+	return "gblur=sigma=" + formatFilterNumber(sigma)
+}
+
+// positionExprs returns ffmpeg expressions in t for the position of a
+// region, interpolating linearly between its keyframes.
+func positionExprs(region RedactRegion) (string, string) {
+	if len(region.Keyframes) == 0 {
+		return fmt.Sprint(region.X), fmt.Sprint(region.Y)
+	}
+
+	points := append([]RedactKeyframe{{Time: region.Start, X: region.X, Y: region.Y}}, region.Keyframes...)
+	expr := func(coord func(RedactKeyframe) int) string {
+		last := points[len(points)-1]
+		e := fmt.Sprint(coord(last))
+		for i := len(points) - 2; i >= 0; i-- {
+			a, b := points[i], points[i+1]
+			lerp := fmt.Sprintf("%d+(%d)*(t-%s)/%s", coord(a), coord(b)-coord(a),
+				formatFilterNumber(a.Time), formatFilterNumber(b.Time-a.Time))
+			e = fmt.Sprintf("if(lt(t,%s),%s,%s)", formatFilterNumber(b.Time), lerp, e)
+		}
+		return e
+	}
+	return expr(func(k RedactKeyframe) int { return k.X }), expr(func(k RedactKeyframe) int { return k.Y })
+}
@@ -0,0 +1,101 @@
+package video
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRedactOptionsValidate(t *testing.T) {
+	face := Rect{X: 100, Y: 100, Width: 200, Height: 200}
+
+	tests := []struct {
+		name     string
+		opts     RedactOptions
+		wantErr  bool
+		wantMode string
+	}{
+		{"default mode", RedactOptions{Regions: []RedactRegion{{Rect: face}}}, false, RedactBlur},
+		{"pixelate range", RedactOptions{Mode: RedactPixelate, Regions: []RedactRegion{{Rect: face, Start: 2, End: 5}}}, false, RedactPixelate},
+		{"keyframes", RedactOptions{Regions: []RedactRegion{{Rect: face, Start: 1, Keyframes: []RedactKeyframe{{Time: 2, X: 300, Y: 120}, {Time: 9, X: 0, Y: 0}}}}}, false, RedactBlur},
+		{"unknown mode", RedactOptions{Mode: "mosaic", Regions: []RedactRegion{{Rect: face}}}, true, ""},
+		{"no regions", RedactOptions{}, true, ""},
+		{"outside picture", RedactOptions{Regions: []RedactRegion{{Rect: Rect{X: 1800, Y: 0, Width: 200, Height: 100}}}}, true, ""},
+		{"too small", RedactOptions{Regions: []RedactRegion{{Rect: Rect{Width: 1, Height: 10}}}}, true, ""},
+		{"ends before start", RedactOptions{Regions: []RedactRegion{{Rect: face, Start: 5, End: 3}}}, true, ""},
+		{"past the end", RedactOptions{Regions: []RedactRegion{{Rect: face, End: 11}}}, true, ""},
+		{"keyframe out of order", RedactOptions{Regions: []RedactRegion{{Rect: face, Keyframes: []RedactKeyframe{{Time: 4}, {Time: 3}}}}}, true, ""},
+		{"keyframe after end", RedactOptions{Regions: []RedactRegion{{Rect: face, End: 5, Keyframes: []RedactKeyframe{{Time: 6}}}}}, true, ""},
+		{"keyframe leaves picture", RedactOptions{Regions: []RedactRegion{{Rect: face, Keyframes: []RedactKeyframe{{Time: 2, X: 1900}}}}}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			err := opts.Validate(1920, 1080, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidRedaction) {
+					t.Errorf("Validate() error = %v, want ErrInvalidRedaction", err)
+				}
+				return
+			}
+			if opts.Mode != tt.wantMode {
+				t.Errorf("Mode = %q, want %q", opts.Mode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestRedactFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RedactOptions
+		want string
+	}{
+		{
+			"static blur",
+			RedactOptions{Mode: RedactBlur, Regions: []RedactRegion{{Rect: Rect{X: 10, Y: 20, Width: 64, Height: 32}, Start: 1, End: 4}}},
+			"[0:v]split=2[base][c0];" +
+				"[c0]crop=w=64:h=32:x='10':y='20',gblur=sigma=16[r0];" +
+				"[base][r0]overlay=x='10':y='20':enable='between(t,1,4)'[v]",
+		},
+		{
+			"two pixelated regions",
+			RedactOptions{Mode: RedactPixelate, Regions: []RedactRegion{
+				{Rect: Rect{Width: 64, Height: 32}},
+				{Rect: Rect{X: 100, Y: 100, Width: 8, Height: 8}, Start: 2},
+			}},
+			"[0:v]split=3[base][c0][c1];" +
+				"[c0]crop=w=64:h=32:x='0':y='0',scale=4:2:flags=area,scale=64:32:flags=neighbor[r0];" +
+				"[base][r0]overlay=x='0':y='0':enable='gte(t,0)'[o0];" +
+				"[c1]crop=w=8:h=8:x='100':y='100',scale=1:1:flags=area,scale=8:8:flags=neighbor[r1];" +
+				"[o0][r1]overlay=x='100':y='100':enable='gte(t,2)'[v]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactFilter(tt.opts); got != tt.want {
+				t.Errorf("redactFilter() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositionExprs(t *testing.T) {
+	region := RedactRegion{
+		Rect:      Rect{X: 0, Y: 50, Width: 10, Height: 10},
+		Start:     1,
+		Keyframes: []RedactKeyframe{{Time: 3, X: 100, Y: 50}, {Time: 5, X: 100, Y: 0}},
+	}
+
+	x, y := positionExprs(region)
+	if want := "if(lt(t,3),0+(100)*(t-1)/2,if(lt(t,5),100+(0)*(t-3)/2,100))"; x != want {
+		t.Errorf("x = %s, want %s", x, want)
+	}
+	if want := "if(lt(t,3),50+(0)*(t-1)/2,if(lt(t,5),50+(-50)*(t-3)/2,0))"; y != want {
+		t.Errorf("y = %s, want %s", y, want)
+	}
+}
@@ -0,0 +1,64 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	ProcessorFFmpeg      = "ffmpeg"
+	ProcessorNativeProbe = "native-probe"
+	ProcessorFake        = "fake"
+)
+
+var ErrUnknownProcessor = errors.New("unknown processor")
+
+// ProcessorFactory creates the backend registered under a name. Backends
+// that run external processes schedule them according to opts.
+type ProcessorFactory func(opts SchedulerOptions) (Processor, error)
+
+var (
+	processorsMu sync.RWMutex
+	processors   = map[string]ProcessorFactory{
+		ProcessorFFmpeg: func(opts SchedulerOptions) (Processor, error) {
+			return NewScheduledFFmpegProcessor(NewScheduler(opts)), nil
+		},
+		ProcessorNativeProbe: func(SchedulerOptions) (Processor, error) { return NewNativeProbeProcessor(), nil },
+		ProcessorFake:        func(SchedulerOptions) (Processor, error) { return NewFakeProcessor(), nil },
+	}
+)
+
+// RegisterProcessor makes a backend available to NewProcessor, replacing
+// any backend already registered under the same name.
+func RegisterProcessor(name string, factory ProcessorFactory) {
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	processors[name] = factory
+}
+
+// NewProcessor creates the backend registered under name.
+func NewProcessor(name string, opts SchedulerOptions) (Processor, error) {
+	processorsMu.RLock()
+	factory, ok := processors[name]
+	processorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must be one of %s", ErrUnknownProcessor, name, strings.Join(ProcessorNames(), ", "))
+	}
+	return factory(opts)
+}
+
+// ProcessorNames returns the registered backend names in alphabetical order.
+func ProcessorNames() []string {
+	processorsMu.RLock()
+	defer processorsMu.RUnlock()
+
+	names := make([]string, 0, len(processors))
+	for name := range processors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,65 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewProcessor(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Processor
+		wantErr error
+	}{
+		{ProcessorFFmpeg, &FFmpegProcessor{}, nil},
+		{ProcessorNativeProbe, &NativeProbeProcessor{}, nil},
+		{ProcessorFake, &FakeProcessor{}, nil},
+		{"gstreamer", nil, ErrUnknownProcessor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewProcessor(tt.name, SchedulerOptions{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewProcessor() error = %v, want %v", err, tt.wantErr)
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+				t.Errorf("NewProcessor() = %T, want %T", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterProcessor(t *testing.T) {
+	custom := NewFakeProcessor()
+	RegisterProcessor("custom", func(SchedulerOptions) (Processor, error) { return custom, nil })
+	defer func() {
+		processorsMu.Lock()
+		delete(processors, "custom")
+		processorsMu.Unlock()
+	}()
+
+	got, err := NewProcessor("custom", SchedulerOptions{})
+	if err != nil {
+		t.Fatalf("NewProcessor() error = %v", err)
+	}
+	if got != custom {
+		t.Error("NewProcessor() did not use the registered factory")
+	}
+
+	if want := []string{"custom", "fake", "ffmpeg", "native-probe"}; !reflect.DeepEqual(ProcessorNames(), want) {
+		t.Errorf("ProcessorNames() = %v, want %v", ProcessorNames(), want)
+	}
+}
+
+func TestNativeProbeProcessorUnsupported(t *testing.T) {
+	p := NewNativeProbeProcessor()
+	if err := p.Trim(context.Background(), "in.mp4", "out.mp4", 0, 1); !errors.Is(err, ErrUnsupportedOperation) {
+		t.Errorf("Trim() error = %v, want %v", err, ErrUnsupportedOperation)
+	}
+	if _, err := p.GetVideoInfo(context.Background(), "missing.mp4"); err == nil {
+		t.Error("GetVideoInfo() of a missing file succeeded")
+	}
+}
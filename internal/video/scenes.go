@@ -0,0 +1,100 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const DefaultSceneThreshold = 0.4
+
+// SceneCut is a frame whose scene change score exceeded the detection
+// threshold, marking the start of a new scene.
+type SceneCut struct {
+	Time  float64 `json:"time"`
+	Score float64 `json:"score"`
+}
+
+// Scene is the range between two consecutive cuts, usable as a chapter.
+type Scene struct {
+	Index int     `json:"index"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Score float64 `json:"score"`
+}
+
+var (
+	ptsTimePattern    = regexp.MustCompile(`pts_time:(-?[0-9.]+)`)
+	sceneScorePattern = regexp.MustCompile(`lavfi\.scene_score=([0-9.]+)`)
+)
+
+func (p *FFmpegProcessor) DetectScenes(ctx context.Context, inputPath string, threshold float64) ([]SceneCut, error) {
+	args := []string{
+		"-hide_banner",
+		"-nostats",
+		"-i", inputPath,
+		"-an",
+		"-vf", fmt.Sprintf("select='gt(scene,%g)',metadata=print", threshold),
+		"-f", "null",
+		"-",
+	}
+
+	output, err := p.runFFmpeg(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect scenes: %w, output: %s", err, string(output))
+	}
+
+	return parseSceneOutput(output)
+}
+
+// parseSceneOutput reads the frame and score pairs printed by the metadata
+// filter for every selected frame.
+func parseSceneOutput(output []byte) ([]SceneCut, error) {
+	var cuts []SceneCut
+	var pending *SceneCut
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := ptsTimePattern.FindStringSubmatch(line); m != nil {
+			t, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pts_time %q: %w", m[1], err)
+			}
+			pending = &SceneCut{Time: t}
+			continue
+		}
+
+		if m := sceneScorePattern.FindStringSubmatch(line); m != nil && pending != nil {
+			score, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid scene score %q: %w", m[1], err)
+			}
+			pending.Score = score
+			cuts = append(cuts, *pending)
+			pending = nil
+		}
+	}
+
+	return cuts, scanner.Err()
+}
+
+// ScenesFromCuts turns cut timestamps into consecutive scenes covering the
+// whole video. Cuts at or beyond the ends of the video are ignored.
+func ScenesFromCuts(cuts []SceneCut, duration float64) []Scene {
+	scenes := []Scene{{Index: 0, Start: 0}}
+	for _, cut := range cuts {
+		last := &scenes[len(scenes)-1]
+		if cut.Time <= last.Start || cut.Time >= duration {
+			continue
+		}
+		last.End = cut.Time
+		scenes = append(scenes, Scene{Index: len(scenes), Start: cut.Time, Score: cut.Score})
+	}
+	scenes[len(scenes)-1].End = duration
+	return scenes
+}
@@ -0,0 +1,44 @@
+package video
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSceneOutput(t *testing.T) {
+	output := []byte(`Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'in.mp4':
+[Parsed_metadata_1 @ 0x5581] frame:0    pts:52224   pts_time:4.08
+[Parsed_metadata_1 @ 0x5581] lavfi.scene_score=0.563210
+[Parsed_metadata_1 @ 0x5581] frame:1    pts:140288  pts_time:10.96
+[Parsed_metadata_1 @ 0x5581] lavfi.scene_score=0.912000
+`)
+
+	cuts, err := parseSceneOutput(output)
+	if err != nil {
+		t.Fatalf("parseSceneOutput failed: %v", err)
+	}
+
+	want := []SceneCut{{Time: 4.08, Score: 0.56321}, {Time: 10.96, Score: 0.912}}
+	if !reflect.DeepEqual(cuts, want) {
+		t.Errorf("parseSceneOutput() = %+v, want %+v", cuts, want)
+	}
+}
+
+func TestScenesFromCuts(t *testing.T) {
+	cuts := []SceneCut{{Time: 0, Score: 1}, {Time: 4, Score: 0.5}, {Time: 9, Score: 0.7}, {Time: 20, Score: 0.9}}
+
+	scenes := ScenesFromCuts(cuts, 12)
+
+	want := []Scene{
+		{Index: 0, Start: 0, End: 4},
+		{Index: 1, Start: 4, End: 9, Score: 0.5},
+		{Index: 2, Start: 9, End: 12, Score: 0.7},
+	}
+	if !reflect.DeepEqual(scenes, want) {
+		t.Errorf("ScenesFromCuts() = %+v, want %+v", scenes, want)
+	}
+
+	if single := ScenesFromCuts(nil, 12); len(single) != 1 || single[0].End != 12 {
+		t.Errorf("Expected a single scene without cuts, got %+v", single)
+	}
+}
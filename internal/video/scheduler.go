@@ -0,0 +1,185 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	MemoryCapRlimit = "rlimit"
+	MemoryCapCgroup = "cgroup"
+
+	// defaultJobDuration is assumed for Retry-After estimates until a job
+	// has finished.
+	defaultJobDuration = 10 * time.Second
+)
+
+var ErrQueueFull = errors.New("encode queue is full")
+
+// QueueFullError is returned instead of running a job when the backlog is at
+// its limit. RetryAfter estimates when a slot will be free.
+type QueueFullError struct {
+	RetryAfter time.Duration
+}
+
+func (e *QueueFullError) Error() string {
+	return ErrQueueFull.Error()
+}
+
+func (e *QueueFullError) Is(target error) bool {
+	return target == ErrQueueFull
+}
+
+// SchedulerOptions bound the ffmpeg processes a processor runs. At most
+// MaxConcurrent run at once and at most MaxQueued more wait for a slot;
+// further jobs are rejected with a QueueFullError. Threads limits the
+// encoder and filter threads of each process and Nice lowers its priority.
+// MemoryLimit caps each process at that many bytes, through an address
+// space rlimit set with prlimit or a transient cgroup created with
+// systemd-run, as selected by MemoryCap. Zero values leave the
+// corresponding setting to ffmpeg and the operating system.
+type SchedulerOptions struct {
+	MaxConcurrent int
+	MaxQueued     int
+	Threads       int
+	Nice          int
+	MemoryLimit   int64
+	MemoryCap     string
+}
+
+// Scheduler queues jobs for a fixed number of slots and starts the commands
+// they run with the configured limits.
+type Scheduler struct {
+	opts  SchedulerOptions
+	slots chan struct{}
+
+	mu      sync.Mutex
+	pending int
+	average time.Duration
+}
+
+func NewScheduler(opts SchedulerOptions) *Scheduler {
+	opts.MaxConcurrent = max(1, opts.MaxConcurrent)
+	opts.MaxQueued = max(0, opts.MaxQueued)
+	if opts.MemoryCap == "" {
+		opts.MemoryCap = MemoryCapRlimit
+	}
+
+	return &Scheduler{
+		opts:  opts,
+		slots: make(chan struct{}, opts.MaxConcurrent),
+	}
+}
+
+// Run waits for a free slot and runs job in it. It fails immediately with a
+// QueueFullError when MaxQueued jobs are already waiting, and gives up
+// waiting when ctx is done.
+func (s *Scheduler) Run(ctx context.Context, job func() error) error {
+	s.mu.Lock()
+	if s.pending >= s.opts.MaxConcurrent+s.opts.MaxQueued {
+		err := &QueueFullError{RetryAfter: s.retryAfterLocked()}
+		s.mu.Unlock()
+		return err
+	}
+	s.pending++
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.pending--
+		s.mu.Unlock()
+	}()
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-s.slots }()
+
+	start := time.Now()
+	err := job()
+	s.record(time.Since(start))
+	return err
+}
+
+// Pending returns the number of jobs running or waiting for a slot.
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// record folds the duration of a finished job into a moving average.
+func (s *Scheduler) record(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.average == 0 {
+		s.average = d
+	} else {
+		s.average = (s.average*4 + d) / 5
+	}
+}
+
+// retryAfterLocked estimates how long the jobs ahead of a new one take to
+// drain, assuming each lasts as long as the average so far.
+func (s *Scheduler) retryAfterLocked() time.Duration {
+	average := s.average
+	if average == 0 {
+		average = defaultJobDuration
+	}
+	waiting := s.pending - s.opts.MaxConcurrent + 1
+	rounds := (waiting + s.opts.MaxConcurrent - 1) / s.opts.MaxConcurrent
+	return max(time.Second, time.Duration(rounds)*average)
+}
+
+// Command returns a command running ffmpeg with args under the configured
+// thread, priority and memory limits. nice, prlimit and systemd-run --scope
+// all exec the command in place rather than forking it, so the kill sent
+// when ctx is cancelled reaches ffmpeg itself.
+func (s *Scheduler) Command(ctx context.Context, ffmpegPath string, args []string) *exec.Cmd {
+	line := s.commandLine(ffmpegPath, args)
+	return exec.CommandContext(ctx, line[0], line[1:]...)
+}
+
+// threadArgs returns the output option that limits encoder threads. Unlike
+// the filter thread limit it belongs to an output, so the processor adds it
+// where it builds the encoding arguments of each command. A nil scheduler
+// sets no limit.
+func (s *Scheduler) threadArgs() []string {
+	if s == nil || s.opts.Threads <= 0 {
+		return nil
+	}
+	return []string{"-threads", strconv.Itoa(s.opts.Threads)}
+}
+
+// commandLine wraps the ffmpeg invocation in the tools that apply the
+// limits. The thread count is given here only as the global filter option;
+// see threadArgs.
+func (s *Scheduler) commandLine(ffmpegPath string, args []string) []string {
+	line := []string{ffmpegPath}
+	if s.opts.Threads > 0 {
+		line = append(line, "-filter_threads", strconv.Itoa(s.opts.Threads))
+	}
+	line = append(line, args...)
+
+	if s.opts.Nice != 0 {
+		line = append([]string{"nice", "-n", strconv.Itoa(s.opts.Nice)}, line...)
+	}
+
+	if s.opts.MemoryLimit > 0 {
+		limit := strconv.FormatInt(s.opts.MemoryLimit, 10)
+		switch s.opts.MemoryCap {
+		case MemoryCapCgroup:
+			line = append([]string{"systemd-run", "--scope", "--quiet", "--collect", "-p", "MemoryMax=" + limit, "--"}, line...)
+		default:
+			line = append([]string{"prlimit", "--as=" + limit, "--"}, line...)
+		}
+	}
+
+	return line
+}
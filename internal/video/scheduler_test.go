@@ -0,0 +1,160 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRun(t *testing.T) {
+	s := NewScheduler(SchedulerOptions{MaxConcurrent: 2, MaxQueued: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	running, peak := 0, 0
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.Run(context.Background(), func() error {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+				started <- struct{}{}
+				<-release
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+		}()
+	}
+
+	<-started
+	<-started
+	for s.Pending() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	err := s.Run(context.Background(), func() error { return nil })
+	var full *QueueFullError
+	if !errors.As(err, &full) || !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Run() with a full queue error = %v, want a QueueFullError", err)
+	}
+	if full.RetryAfter != defaultJobDuration {
+		t.Errorf("RetryAfter = %v, want %v before any job finished", full.RetryAfter, defaultJobDuration)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("%d jobs ran at once, want 2", peak)
+	}
+	if s.Pending() != 0 {
+		t.Errorf("Pending() = %d after all jobs finished", s.Pending())
+	}
+}
+
+func TestSchedulerCommandLine(t *testing.T) {
+	args := []string{"-i", "in.mp4", "-c:v", "libx264", "-y", "out.mp4"}
+
+	tests := []struct {
+		name string
+		opts SchedulerOptions
+		want []string
+	}{
+		{
+			name: "no limits",
+			want: []string{"ffmpeg", "-i", "in.mp4", "-c:v", "libx264", "-y", "out.mp4"},
+		},
+		{
+			name: "threads and nice",
+			opts: SchedulerOptions{Threads: 2, Nice: 10},
+			want: []string{"nice", "-n", "10", "ffmpeg", "-filter_threads", "2", "-i", "in.mp4", "-c:v", "libx264", "-y", "out.mp4"},
+		},
+		{
+			name: "rlimit",
+			opts: SchedulerOptions{MemoryLimit: 1 << 30},
+			want: []string{"prlimit", "--as=1073741824", "--", "ffmpeg", "-i", "in.mp4", "-c:v", "libx264", "-y", "out.mp4"},
+		},
+		{
+			name: "cgroup",
+			opts: SchedulerOptions{MemoryLimit: 1 << 30, MemoryCap: MemoryCapCgroup, Nice: 5},
+			want: []string{"systemd-run", "--scope", "--quiet", "--collect", "-p", "MemoryMax=1073741824", "--",
+				"nice", "-n", "5", "ffmpeg", "-i", "in.mp4", "-c:v", "libx264", "-y", "out.mp4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewScheduler(tt.opts).commandLine("ffmpeg", args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commandLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeArgsThreads(t *testing.T) {
+	ctx := context.Background()
+	defaults := DefaultEncoding.Args()
+
+	if got := NewFFmpegProcessor().encodeArgs(ctx); !reflect.DeepEqual(got, defaults) {
+		t.Errorf("encodeArgs() without scheduler = %v, want %v", got, defaults)
+	}
+
+	p := NewScheduledFFmpegProcessor(NewScheduler(SchedulerOptions{Threads: 2}))
+	want := append(DefaultEncoding.Args(), "-threads", "2")
+	if got := p.encodeArgs(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("encodeArgs() = %v, want %v", got, want)
+	}
+}
+
+// TestSchedulerCommandCancel checks that cancelling a job kills the process
+// itself and not just a wrapper around it: nice, prlimit and systemd-run
+// --scope all exec the command in place. A surviving child would hold the
+// output pipe open and keep CombinedOutput waiting for the whole sleep.
+func TestSchedulerCommandCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    SchedulerOptions
+		wrapper string
+	}{
+		{"nice", SchedulerOptions{Nice: 5}, "nice"},
+		{"rlimit", SchedulerOptions{MemoryLimit: 1 << 30}, "prlimit"},
+		{"cgroup", SchedulerOptions{MemoryLimit: 1 << 30, MemoryCap: MemoryCapCgroup}, "systemd-run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := exec.LookPath(tt.wrapper); err != nil {
+				t.Skipf("%s not found in PATH", tt.wrapper)
+			}
+			s := NewScheduler(tt.opts)
+			if err := s.Command(context.Background(), "true", nil).Run(); err != nil {
+				t.Skipf("%s cannot run commands here: %v", tt.wrapper, err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			s.Command(ctx, "sleep", []string{"30"}).CombinedOutput()
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Expected the cancelled command to stop, ran for %v", elapsed)
+			}
+		})
+	}
+}
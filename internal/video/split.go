@@ -0,0 +1,104 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	MinSegmentDuration = 1.0
+	MaxSegments        = 100
+
+	// segmentSizeMargin leaves room for bitrate peaks when a byte limit is
+	// turned into a segment duration.
+	segmentSizeMargin = 0.9
+)
+
+var ErrInvalidSplit = errors.New("invalid split options")
+
+// SplitOptions select how long the segments of a split are, either directly
+// as a duration in seconds or as the number of bytes a segment may hold.
+type SplitOptions struct {
+	SegmentDuration float64 `json:"segment_duration,omitempty"`
+	MaxBytes        int64   `json:"max_bytes,omitempty"`
+}
+
+// SegmentTime validates the options against the duration and size of the
+// video and returns the target segment duration. A byte limit is converted
+// using the average bitrate of the video, so individual segments may still
+// exceed it when the bitrate varies or keyframes are far apart.
+func (o SplitOptions) SegmentTime(duration float64, size int64) (float64, error) {
+	if (o.SegmentDuration == 0) == (o.MaxBytes == 0) {
+		return 0, fmt.Errorf("%w: set either segment_duration or max_bytes", ErrInvalidSplit)
+	}
+	if o.SegmentDuration < 0 || o.MaxBytes < 0 {
+		return 0, fmt.Errorf("%w: segment_duration and max_bytes must be positive", ErrInvalidSplit)
+	}
+	if duration <= 0 || size <= 0 {
+		return 0, fmt.Errorf("%w: video has no duration", ErrInvalidSplit)
+	}
+
+	segmentTime := o.SegmentDuration
+	if o.MaxBytes != 0 {
+		if o.MaxBytes >= size {
+			return 0, fmt.Errorf("%w: video is already smaller than max_bytes", ErrInvalidSplit)
+		}
+		segmentTime = float64(o.MaxBytes) / (float64(size) / duration) * segmentSizeMargin
+	}
+
+	if segmentTime < MinSegmentDuration {
+		return 0, fmt.Errorf("%w: segments must be at least %.0f second long", ErrInvalidSplit, MinSegmentDuration)
+	}
+	if segmentTime >= duration {
+		return 0, fmt.Errorf("%w: segment_duration must be shorter than the video", ErrInvalidSplit)
+	}
+	if duration/segmentTime > MaxSegments {
+		return 0, fmt.Errorf("%w: at most %d segments can be created", ErrInvalidSplit, MaxSegments)
+	}
+	return segmentTime, nil
+}
+
+// Split cuts a video into segments of about segmentTime seconds, written to
+// outputDir with the extension of the input, and returns their paths in
+// playback order. Streams are copied, so every cut falls on the first
+// keyframe at or after its target time and segments run long rather than
+// starting mid-GOP.
+func (p *FFmpegProcessor) Split(ctx context.Context, inputPath, outputDir string, segmentTime float64) ([]string, error) {
+	ext := filepath.Ext(inputPath)
+	args := splitArgs(inputPath, filepath.Join(outputDir, "segment%03d"+ext), segmentTime)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return nil, fmt.Errorf("failed to split video: %w, output: %s", err, string(output))
+	}
+
+	paths, err := filepath.Glob(filepath.Join(outputDir, "segment*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("failed to split video: no segments were written")
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func splitArgs(inputPath, outputPattern string, segmentTime float64) []string {
+	args := []string{
+		"-i", inputPath,
+		"-map", "0",
+		"-c", "copy",
+		"-f", "segment",
+		"-segment_time", fmt.Sprintf("%.3f", segmentTime),
+		"-reset_timestamps", "1",
+	}
+
+	switch strings.ToLower(filepath.Ext(outputPattern)) {
+	case ".mp4", ".mov", ".m4v":
+		args = append(args, "-segment_format_options", "movflags=+faststart")
+	}
+	return append(args, "-y", outputPattern)
+}
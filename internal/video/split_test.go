@@ -0,0 +1,81 @@
+package video
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSplitOptionsSegmentTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    SplitOptions
+		want    float64
+		wantErr bool
+	}{
+		{"duration", SplitOptions{SegmentDuration: 10}, 10, false},
+		{"bytes", SplitOptions{MaxBytes: 250_000}, 22.5, false},
+		{"nothing selected", SplitOptions{}, 0, true},
+		{"both selected", SplitOptions{SegmentDuration: 10, MaxBytes: 1000}, 0, true},
+		{"negative duration", SplitOptions{SegmentDuration: -5}, 0, true},
+		{"too short", SplitOptions{SegmentDuration: 0.5}, 0, true},
+		{"longer than video", SplitOptions{SegmentDuration: 100}, 0, true},
+		{"already small enough", SplitOptions{MaxBytes: 1_000_000}, 0, true},
+		{"bytes too small", SplitOptions{MaxBytes: 5000}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 100 seconds at 10,000 bytes per second.
+			got, err := tt.opts.SegmentTime(100, 1_000_000)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SegmentTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidSplit) {
+					t.Errorf("SegmentTime() error = %v, want ErrInvalidSplit", err)
+				}
+				return
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("SegmentTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("segment limit", func(t *testing.T) {
+		if _, err := (SplitOptions{SegmentDuration: 1}).SegmentTime(1000, 1_000_000); !errors.Is(err, ErrInvalidSplit) {
+			t.Errorf("SegmentTime() error = %v, want ErrInvalidSplit", err)
+		}
+	})
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{
+			"mp4 segments start fast",
+			"/out/segment%03d.mp4",
+			[]string{"-i", "in.mp4", "-map", "0", "-c", "copy", "-f", "segment", "-segment_time", "12.500",
+				"-reset_timestamps", "1", "-segment_format_options", "movflags=+faststart", "-y", "/out/segment%03d.mp4"},
+		},
+		{
+			"other containers",
+			"/out/segment%03d.mkv",
+			[]string{"-i", "in.mp4", "-map", "0", "-c", "copy", "-f", "segment", "-segment_time", "12.500",
+				"-reset_timestamps", "1", "-y", "/out/segment%03d.mkv"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitArgs("in.mp4", tt.pattern, 12.5); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
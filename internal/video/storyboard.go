@@ -0,0 +1,94 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxSpriteDimension keeps sprite sheets within the JPEG size limit.
+const maxSpriteDimension = 65500
+
+var ErrInvalidStoryboard = errors.New("invalid storyboard options")
+
+// StoryboardOptions describes a sprite sheet holding one thumbnail every
+// Interval seconds, laid out left to right in rows of Columns tiles.
+type StoryboardOptions struct {
+	Interval   float64 `json:"interval"`
+	TileWidth  int     `json:"tile_width"`
+	TileHeight int     `json:"tile_height"`
+	Columns    int     `json:"columns"`
+}
+
+func (o StoryboardOptions) Validate(duration float64) error {
+	if o.Interval < 1 || o.Interval > 60 {
+		return fmt.Errorf("%w: interval must be between 1 and 60 seconds", ErrInvalidStoryboard)
+	}
+	if o.TileWidth < 32 || o.TileWidth > 640 || o.TileHeight < 18 || o.TileHeight > 360 {
+		return fmt.Errorf("%w: tiles must be between 32x18 and 640x360", ErrInvalidStoryboard)
+	}
+	if o.Columns < 1 || o.Columns > 20 {
+		return fmt.Errorf("%w: columns must be between 1 and 20", ErrInvalidStoryboard)
+	}
+	if o.Rows(duration)*o.TileHeight > maxSpriteDimension {
+		return fmt.Errorf("%w: too many tiles, increase the interval", ErrInvalidStoryboard)
+	}
+	return nil
+}
+
+// Tiles returns the number of thumbnails needed to cover duration.
+func (o StoryboardOptions) Tiles(duration float64) int {
+	return int(math.Max(1, math.Ceil(duration/o.Interval)))
+}
+
+// Rows returns the number of sprite rows needed to cover duration.
+func (o StoryboardOptions) Rows(duration float64) int {
+	return (o.Tiles(duration) + o.Columns - 1) / o.Columns
+}
+
+func (p *FFmpegProcessor) GenerateStoryboard(ctx context.Context, inputPath, outputPath string, duration float64, opts StoryboardOptions) error {
+	filter := fmt.Sprintf("fps=1/%g,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+		opts.Interval, opts.TileWidth, opts.TileHeight, opts.TileWidth, opts.TileHeight, opts.Columns, opts.Rows(duration))
+
+	args := []string{
+		"-i", inputPath,
+		"-vf", filter,
+		"-frames:v", "1",
+		"-q:v", "4",
+		"-an",
+		"-y",
+		outputPath,
+	}
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to generate storyboard: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// StoryboardVTT builds a WebVTT track mapping each interval of the video to
+// its tile in the sprite sheet at spriteURL using media fragment coordinates.
+func StoryboardVTT(duration float64, opts StoryboardOptions, spriteURL string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n")
+
+	for i := 0; i < opts.Tiles(duration); i++ {
+		start := float64(i) * opts.Interval
+		end := math.Min(start+opts.Interval, duration)
+		x := (i % opts.Columns) * opts.TileWidth
+		y := (i / opts.Columns) * opts.TileHeight
+
+		fmt.Fprintf(&buf, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			FormatVTTTimestamp(start), FormatVTTTimestamp(end), spriteURL, x, y, opts.TileWidth, opts.TileHeight)
+	}
+
+	return buf.Bytes()
+}
+
+// FormatVTTTimestamp formats seconds as a WebVTT timestamp (HH:MM:SS.mmm).
+func FormatVTTTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
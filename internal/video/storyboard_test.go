@@ -0,0 +1,63 @@
+package video
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStoryboardVTT(t *testing.T) {
+	opts := StoryboardOptions{Interval: 5, TileWidth: 160, TileHeight: 90, Columns: 2}
+
+	vtt := string(StoryboardVTT(12, opts, "storyboard.jpg"))
+
+	want := `WEBVTT
+
+00:00:00.000 --> 00:00:05.000
+storyboard.jpg#xywh=0,0,160,90
+
+00:00:05.000 --> 00:00:10.000
+storyboard.jpg#xywh=160,0,160,90
+
+00:00:10.000 --> 00:00:12.000
+storyboard.jpg#xywh=0,90,160,90
+`
+	if vtt != want {
+		t.Errorf("StoryboardVTT() =\n%s\nwant\n%s", vtt, want)
+	}
+
+	if rows := opts.Rows(12); rows != 2 {
+		t.Errorf("Rows() = %d, want 2", rows)
+	}
+}
+
+func TestStoryboardOptionsValidate(t *testing.T) {
+	valid := StoryboardOptions{Interval: 5, TileWidth: 160, TileHeight: 90, Columns: 5}
+	if err := valid.Validate(25); err != nil {
+		t.Errorf("Validate() unexpected error: %v", err)
+	}
+
+	tooFrequent := valid
+	tooFrequent.Interval = 0.5
+	if err := tooFrequent.Validate(25); err == nil {
+		t.Error("Validate() expected error for sub-second interval")
+	}
+
+	tooTall := StoryboardOptions{Interval: 1, TileWidth: 640, TileHeight: 360, Columns: 1}
+	if err := tooTall.Validate(3600); err == nil || !strings.Contains(err.Error(), "too many tiles") {
+		t.Errorf("Validate() expected sprite size error, got %v", err)
+	}
+}
+
+func TestFormatVTTTimestamp(t *testing.T) {
+	tests := map[float64]string{
+		0:        "00:00:00.000",
+		1.5:      "00:00:01.500",
+		61.25:    "00:01:01.250",
+		3723.004: "01:02:03.004",
+	}
+	for seconds, want := range tests {
+		if got := FormatVTTTimestamp(seconds); got != want {
+			t.Errorf("FormatVTTTimestamp(%v) = %s, want %s", seconds, got, want)
+		}
+	}
+}
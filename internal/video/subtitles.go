@@ -0,0 +1,275 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	SubtitleSRT = "srt"
+	SubtitleVTT = "vtt"
+)
+
+var (
+	ErrInvalidSubtitles     = errors.New("invalid subtitles")
+	ErrUnsupportedContainer = errors.New("container does not support soft subtitles")
+)
+
+// Cue is a single caption shown between Start and End, in seconds.
+type Cue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// ParseSubtitles reads an SRT or WebVTT document, telling them apart by the
+// WEBVTT header. Cues are returned in order of their start time.
+func ParseSubtitles(data []byte) ([]Cue, string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	format := SubtitleSRT
+	if bytes.HasPrefix(data, []byte("WEBVTT")) {
+		format = SubtitleVTT
+	}
+
+	var cues []Cue
+	for i, block := range splitBlocks(data) {
+		if format == SubtitleVTT && (i == 0 || isVTTMetadataBlock(block[0])) {
+			continue
+		}
+
+		timing := 0
+		for timing < len(block) && !strings.Contains(block[timing], "-->") {
+			timing++
+		}
+		if timing == len(block) {
+			return nil, "", fmt.Errorf("%w: block %d has no timing line", ErrInvalidSubtitles, i+1)
+		}
+
+		start, end, err := parseCueTiming(block[timing])
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: block %d: %v", ErrInvalidSubtitles, i+1, err)
+		}
+
+		cues = append(cues, Cue{
+			Start: start,
+			End:   end,
+			Text:  strings.Join(block[timing+1:], "\n"),
+		})
+	}
+
+	if len(cues) == 0 {
+		return nil, "", fmt.Errorf("%w: no cues found", ErrInvalidSubtitles)
+	}
+
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+	return cues, format, nil
+}
+
+// ValidateCues checks that every cue has a positive length and lies within a
+// video of the given duration.
+func ValidateCues(cues []Cue, duration float64) error {
+	for i, cue := range cues {
+		if cue.Start < 0 || cue.End <= cue.Start {
+			return fmt.Errorf("%w: cue %d ends before it starts", ErrInvalidSubtitles, i+1)
+		}
+		if cue.End > duration {
+			return fmt.Errorf("%w: cue %d ends at %s, after the end of the video", ErrInvalidSubtitles, i+1, FormatVTTTimestamp(cue.End))
+		}
+		if strings.TrimSpace(cue.Text) == "" {
+			return fmt.Errorf("%w: cue %d has no text", ErrInvalidSubtitles, i+1)
+		}
+	}
+	return nil
+}
+
+// ShiftCues returns the cues visible between start and end, clipped to that
+// range and moved so that start becomes zero. It keeps captions aligned with
+// a video trimmed to the same range.
+func ShiftCues(cues []Cue, start, end float64) []Cue {
+	var shifted []Cue
+	for _, cue := range cues {
+		if cue.End <= start || cue.Start >= end {
+			continue
+		}
+		shifted = append(shifted, Cue{
+			Start: math.Max(cue.Start, start) - start,
+			End:   math.Min(cue.End, end) - start,
+			Text:  cue.Text,
+		})
+	}
+	return shifted
+}
+
+// FormatSubtitles writes cues as an SRT or WebVTT document.
+func FormatSubtitles(cues []Cue, format string) []byte {
+	var buf bytes.Buffer
+	if format == SubtitleVTT {
+		buf.WriteString("WEBVTT\n")
+	}
+
+	for i, cue := range cues {
+		if i > 0 || format == SubtitleVTT {
+			buf.WriteString("\n")
+		}
+		if format == SubtitleVTT {
+			fmt.Fprintf(&buf, "%s --> %s\n", FormatVTTTimestamp(cue.Start), FormatVTTTimestamp(cue.End))
+		} else {
+			fmt.Fprintf(&buf, "%d\n%s --> %s\n", i+1, formatSRTTimestamp(cue.Start), formatSRTTimestamp(cue.End))
+		}
+		buf.WriteString(cue.Text)
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
+
+// MuxSubtitles copies the streams of a video into a new container with the
+// subtitle file added as a soft track. The subtitle codec follows the output
+// container: mov_text for MP4 and MOV, SubRip for Matroska.
+func (p *FFmpegProcessor) MuxSubtitles(ctx context.Context, inputPath, subtitlePath, outputPath, language string) error {
+	codec, err := subtitleCodec(outputPath)
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"-i", inputPath,
+		"-i", subtitlePath,
+		"-map", "0:v",
+		"-map", "0:a?",
+		"-map", "1:0",
+		"-c", "copy",
+		"-c:s", codec,
+	}
+	if language != "" {
+		args = append(args, "-metadata:s:s:0", "language="+language)
+	}
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to mux subtitles: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// BurnSubtitles renders the subtitle file into the picture of a new video.
+func (p *FFmpegProcessor) BurnSubtitles(ctx context.Context, inputPath, subtitlePath, outputPath string) error {
+	args := []string{
+		"-i", inputPath,
+		"-vf", "subtitles=" + escapeFilterValue(subtitlePath),
+	}
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to burn subtitles: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+func subtitleCodec(outputPath string) (string, error) {
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".mp4", ".mov":
+		return "mov_text", nil
+	case ".mkv":
+		return "srt", nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedContainer, filepath.Ext(outputPath))
+	}
+}
+
+// escapeFilterValue escapes a value for use as a filter option, first for the
+// option parser and then for the filtergraph parser, so that paths containing
+// ':' or quotes do not break the graph.
+func escapeFilterValue(value string) string {
+	option := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(option)
+}
+
+func splitBlocks(data []byte) [][]string {
+	var blocks [][]string
+	var current []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, current)
+	}
+	return blocks
+}
+
+func isVTTMetadataBlock(first string) bool {
+	for _, prefix := range []string{"NOTE", "STYLE", "REGION"} {
+		if first == prefix || strings.HasPrefix(first, prefix+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCueTiming parses "start --> end", ignoring WebVTT cue settings after
+// the end timestamp.
+func parseCueTiming(line string) (float64, float64, error) {
+	parts := strings.SplitN(line, "-->", 2)
+	fields := strings.Fields(parts[1])
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("missing end time")
+	}
+
+	start, err := parseCueTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseCueTimestamp(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseCueTimestamp accepts [hh:]mm:ss followed by a comma (SRT) or a
+// period (WebVTT) and milliseconds.
+func parseCueTimestamp(s string) (float64, error) {
+	clock := strings.Split(strings.Replace(s, ",", ".", 1), ":")
+	if len(clock) < 2 || len(clock) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var seconds float64
+	for i, part := range clock {
+		isLast := i == len(clock)-1
+		if !isLast && strings.Contains(part, ".") {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 || (i > 0 && v >= 60) {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds, nil
+}
+
+func formatSRTTimestamp(seconds float64) string {
+	return strings.Replace(FormatVTTTimestamp(seconds), ".", ",", 1)
+}
@@ -0,0 +1,157 @@
+package video
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSubtitles(t *testing.T) {
+	want := []Cue{
+		{Start: 1, End: 2.5, Text: "Hello"},
+		{Start: 3, End: 4.25, Text: "Two\nlines"},
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		wantFormat string
+	}{
+		{
+			name:       "srt",
+			input:      "1\r\n00:00:01,000 --> 00:00:02,500\r\nHello\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,250\r\nTwo\r\nlines\r\n",
+			wantFormat: SubtitleSRT,
+		},
+		{
+			name:       "vtt with settings and notes",
+			input:      "\xef\xbb\xbfWEBVTT - captions\n\nNOTE written by hand\n\nintro\n00:01.000 --> 00:02.500 align:start\nHello\n\n00:00:03.000 --> 00:00:04.250\nTwo\nlines\n",
+			wantFormat: SubtitleVTT,
+		},
+		{
+			name:       "unordered cues",
+			input:      "2\n00:00:03,000 --> 00:00:04,250\nTwo\nlines\n\n1\n00:00:01,000 --> 00:00:02,500\nHello\n",
+			wantFormat: SubtitleSRT,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues, format, err := ParseSubtitles([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("ParseSubtitles() unexpected error: %v", err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+			if !reflect.DeepEqual(cues, want) {
+				t.Errorf("cues = %+v, want %+v", cues, want)
+			}
+		})
+	}
+
+	for _, input := range []string{
+		"",
+		"WEBVTT\n",
+		"1\nHello\n",
+		"1\n00:00:01,000 --> 00:00:61,000\nHello\n",
+		"1\n00:00:01,000 -->\nHello\n",
+	} {
+		if _, _, err := ParseSubtitles([]byte(input)); !errors.Is(err, ErrInvalidSubtitles) {
+			t.Errorf("ParseSubtitles(%q) error = %v, want ErrInvalidSubtitles", input, err)
+		}
+	}
+}
+
+func TestValidateCues(t *testing.T) {
+	tests := []struct {
+		name    string
+		cue     Cue
+		wantErr bool
+	}{
+		{"valid", Cue{Start: 1, End: 2, Text: "ok"}, false},
+		{"ends at video end", Cue{Start: 9, End: 10, Text: "ok"}, false},
+		{"past video end", Cue{Start: 9, End: 10.5, Text: "late"}, true},
+		{"reversed", Cue{Start: 2, End: 1, Text: "back"}, true},
+		{"empty text", Cue{Start: 1, End: 2, Text: " "}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCues([]Cue{tt.cue}, 10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShiftCues(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 1, Text: "before"},
+		{Start: 1.5, End: 3, Text: "overlaps start"},
+		{Start: 4, End: 5, Text: "inside"},
+		{Start: 7, End: 9, Text: "overlaps end"},
+		{Start: 9, End: 10, Text: "after"},
+	}
+
+	got := ShiftCues(cues, 2, 8)
+	want := []Cue{
+		{Start: 0, End: 1, Text: "overlaps start"},
+		{Start: 2, End: 3, Text: "inside"},
+		{Start: 5, End: 6, Text: "overlaps end"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ShiftCues() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFormatSubtitles(t *testing.T) {
+	cues := []Cue{
+		{Start: 1, End: 2.5, Text: "Hello"},
+		{Start: 3661.004, End: 3662, Text: "Later"},
+	}
+
+	srt := string(FormatSubtitles(cues, SubtitleSRT))
+	wantSRT := "1\n00:00:01,000 --> 00:00:02,500\nHello\n\n2\n01:01:01,004 --> 01:01:02,000\nLater\n"
+	if srt != wantSRT {
+		t.Errorf("SRT =\n%s\nwant\n%s", srt, wantSRT)
+	}
+
+	vtt := string(FormatSubtitles(cues, SubtitleVTT))
+	wantVTT := "WEBVTT\n\n00:00:01.000 --> 00:00:02.500\nHello\n\n01:01:01.004 --> 01:01:02.000\nLater\n"
+	if vtt != wantVTT {
+		t.Errorf("VTT =\n%s\nwant\n%s", vtt, wantVTT)
+	}
+
+	for _, format := range []string{SubtitleSRT, SubtitleVTT} {
+		parsed, _, err := ParseSubtitles(FormatSubtitles(cues, format))
+		if err != nil || !reflect.DeepEqual(parsed, cues) {
+			t.Errorf("%s round trip = %+v, %v; want %+v", format, parsed, err, cues)
+		}
+	}
+}
+
+func TestSubtitleCodec(t *testing.T) {
+	tests := map[string]string{
+		"out.mp4": "mov_text",
+		"out.MOV": "mov_text",
+		"out.mkv": "srt",
+	}
+	for path, want := range tests {
+		if got, err := subtitleCodec(path); err != nil || got != want {
+			t.Errorf("subtitleCodec(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+
+	if _, err := subtitleCodec("out.avi"); !errors.Is(err, ErrUnsupportedContainer) {
+		t.Errorf("subtitleCodec(avi) error = %v, want ErrUnsupportedContainer", err)
+	}
+}
+
+func TestEscapeFilterValue(t *testing.T) {
+	got := escapeFilterValue(`/data/a:b's [1].vtt`)
+	want := `/data/a\\:b\\\'s \[1\].vtt`
+	if got != want {
+		t.Errorf("escapeFilterValue() = %s, want %s", got, want)
+	}
+}
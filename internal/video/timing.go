@@ -0,0 +1,143 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	MinSpeed = 0.25
+	MaxSpeed = 4.0
+
+	MaxLoopCount    = 100
+	MaxLoopDuration = 3600.0
+)
+
+var ErrInvalidTiming = errors.New("invalid timing options")
+
+// ValidateSpeed checks a playback speed factor.
+func ValidateSpeed(factor float64) error {
+	if factor < MinSpeed || factor > MaxSpeed {
+		return fmt.Errorf("%w: speed must be between %.2f and %.0f", ErrInvalidTiming, MinSpeed, MaxSpeed)
+	}
+	return nil
+}
+
+// LoopOptions repeat a clip either Count times in total or until it reaches
+// Duration seconds, cutting the last repetition short. Exactly one must be
+// set.
+type LoopOptions struct {
+	Count    int     `json:"count,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+}
+
+func (o LoopOptions) Validate() error {
+	if (o.Count == 0) == (o.Duration == 0) {
+		return fmt.Errorf("%w: set either count or duration", ErrInvalidTiming)
+	}
+	if o.Count != 0 && (o.Count < 2 || o.Count > MaxLoopCount) {
+		return fmt.Errorf("%w: count must be between 2 and %d", ErrInvalidTiming, MaxLoopCount)
+	}
+	if o.Duration < 0 || o.Duration > MaxLoopDuration {
+		return fmt.Errorf("%w: duration must be positive and at most %.0f seconds", ErrInvalidTiming, MaxLoopDuration)
+	}
+	return nil
+}
+
+// OutputDuration returns the length of the looped clip for a source of the
+// given duration.
+func (o LoopOptions) OutputDuration(sourceDuration float64) float64 {
+	if o.Duration > 0 {
+		return o.Duration
+	}
+	return sourceDuration * float64(o.Count)
+}
+
+// ChangeSpeed plays a video faster or slower. Audio is time-stretched with
+// atempo so that its pitch is unchanged.
+func (p *FFmpegProcessor) ChangeSpeed(ctx context.Context, inputPath, outputPath string, factor float64) error {
+	info, err := p.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to change speed: %w", err)
+	}
+
+	args := timingArgs(inputPath, fmt.Sprintf("setpts=PTS/%g", factor), atempoChain(factor), info.AudioCodec != "")
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to change speed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// Reverse plays a video backwards. The reverse filters buffer the whole clip
+// in memory, which the duration limits keep manageable.
+func (p *FFmpegProcessor) Reverse(ctx context.Context, inputPath, outputPath string) error {
+	info, err := p.GetVideoInfo(ctx, inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to reverse video: %w", err)
+	}
+
+	args := timingArgs(inputPath, "reverse", "areverse", info.AudioCodec != "")
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to reverse video: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// timingArgs applies a video filter and its audio counterpart. ffmpeg
+// rejects an audio filter when the input has no audio stream, as screen
+// captures and converted GIFs often do not, so it is only added if there is
+// one.
+func timingArgs(inputPath, videoFilter, audioFilter string, hasAudio bool) []string {
+	args := []string{"-i", inputPath, "-vf", videoFilter}
+	if hasAudio {
+		args = append(args, "-af", audioFilter)
+	}
+	return args
+}
+
+func (p *FFmpegProcessor) Loop(ctx context.Context, inputPath, outputPath string, opts LoopOptions) error {
+	var args []string
+	if opts.Duration > 0 {
+		args = []string{
+			"-stream_loop", "-1",
+			"-i", inputPath,
+			"-t", fmt.Sprintf("%.3f", opts.Duration),
+		}
+	} else {
+		args = []string{
+			"-stream_loop", fmt.Sprintf("%d", opts.Count-1),
+			"-i", inputPath,
+		}
+	}
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to loop video: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// atempoChain splits a speed factor into atempo stages that each stay within
+// the 0.5 to 2.0 range accepted by every ffmpeg version.
+func atempoChain(factor float64) string {
+	var stages []string
+	for factor > 2 {
+		stages = append(stages, "atempo=2")
+		factor /= 2
+	}
+	for factor < 0.5 {
+		stages = append(stages, "atempo=0.5")
+		factor /= 0.5
+	}
+	stages = append(stages, fmt.Sprintf("atempo=%g", factor))
+	return strings.Join(stages, ",")
+}
@@ -0,0 +1,89 @@
+package video
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAtempoChain(t *testing.T) {
+	tests := []struct {
+		factor float64
+		want   string
+	}{
+		{1.5, "atempo=1.5"},
+		{2, "atempo=2"},
+		{4, "atempo=2,atempo=2"},
+		{3, "atempo=2,atempo=1.5"},
+		{0.5, "atempo=0.5"},
+		{0.25, "atempo=0.5,atempo=0.5"},
+		{0.3, "atempo=0.5,atempo=0.6"},
+	}
+
+	for _, tt := range tests {
+		if got := atempoChain(tt.factor); got != tt.want {
+			t.Errorf("atempoChain(%g) = %q, want %q", tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestTimingArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		hasAudio bool
+		want     string
+	}{
+		{"with audio", true, "-i in.mp4 -vf reverse -af areverse"},
+		{"without audio", false, "-i in.mp4 -vf reverse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(timingArgs("in.mp4", "reverse", "areverse", tt.hasAudio), " "); got != tt.want {
+				t.Errorf("timingArgs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSpeed(t *testing.T) {
+	for _, factor := range []float64{0.25, 1, 4} {
+		if err := ValidateSpeed(factor); err != nil {
+			t.Errorf("ValidateSpeed(%g) unexpected error: %v", factor, err)
+		}
+	}
+	for _, factor := range []float64{0, 0.2, 4.5} {
+		if err := ValidateSpeed(factor); !errors.Is(err, ErrInvalidTiming) {
+			t.Errorf("ValidateSpeed(%g) error = %v, want ErrInvalidTiming", factor, err)
+		}
+	}
+}
+
+func TestLoopOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         LoopOptions
+		wantErr      bool
+		wantDuration float64
+	}{
+		{"count", LoopOptions{Count: 3}, false, 30},
+		{"duration", LoopOptions{Duration: 25}, false, 25},
+		{"neither", LoopOptions{}, true, 0},
+		{"both", LoopOptions{Count: 2, Duration: 5}, true, 0},
+		{"single", LoopOptions{Count: 1}, true, 0},
+		{"negative duration", LoopOptions{Duration: -1}, true, 0},
+		{"too long", LoopOptions{Duration: MaxLoopDuration + 1}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.opts.OutputDuration(10) != tt.wantDuration {
+				t.Errorf("OutputDuration() = %v, want %v", tt.opts.OutputDuration(10), tt.wantDuration)
+			}
+		})
+	}
+}
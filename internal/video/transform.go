@@ -0,0 +1,228 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	FitStretch = "stretch"
+	FitCrop    = "crop"
+	FitPad     = "pad"
+
+	BackgroundBlur = "blur"
+
+	MaxTransformSize = 7680
+)
+
+var (
+	ErrInvalidTransform = errors.New("invalid transform")
+	ErrUnknownPreset    = errors.New("unknown preset")
+
+	colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{6}|[a-zA-Z]+)$`)
+)
+
+// Rect is a region of the picture in pixels, measured from the top left.
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// TransformOptions describe geometric changes applied in order: crop, rotate,
+// flip and finally scale to Width x Height. When both dimensions are given,
+// Fit decides how a picture of a different aspect ratio is made to fit, and
+// Background fills the borders left by FitPad with a colour or a blurred copy
+// of the picture.
+//
+// Coordinates and sizes refer to the picture as displayed, after any rotation
+// recorded in the source metadata has been applied.
+type TransformOptions struct {
+	Crop       *Rect  `json:"crop,omitempty"`
+	Rotate     int    `json:"rotate,omitempty"`
+	FlipH      bool   `json:"flip_horizontal,omitempty"`
+	FlipV      bool   `json:"flip_vertical,omitempty"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	Fit        string `json:"fit,omitempty"`
+	Background string `json:"background,omitempty"`
+}
+
+// TransformPreset is a named output size for a common destination.
+type TransformPreset struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+var TransformPresets = map[string]TransformPreset{
+	"vertical-1080x1920":  {Width: 1080, Height: 1920},
+	"vertical-720x1280":   {Width: 720, Height: 1280},
+	"portrait-1080x1350":  {Width: 1080, Height: 1350},
+	"square-1080x1080":    {Width: 1080, Height: 1080},
+	"landscape-1920x1080": {Width: 1920, Height: 1080},
+	"landscape-1280x720":  {Width: 1280, Height: 720},
+}
+
+// PresetNames returns the names of the transform presets in sorted order.
+func PresetNames() []string {
+	names := make([]string, 0, len(TransformPresets))
+	for name := range TransformPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyPreset sets the output size of the named preset. Presets fit the
+// picture inside the frame over a blurred background unless the options
+// already choose otherwise.
+func (o *TransformOptions) ApplyPreset(name string) error {
+	preset, ok := TransformPresets[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPreset, name)
+	}
+
+	o.Width, o.Height = preset.Width, preset.Height
+	if o.Fit == "" {
+		o.Fit = FitPad
+	}
+	if o.Fit == FitPad && o.Background == "" {
+		o.Background = BackgroundBlur
+	}
+	return nil
+}
+
+// Validate checks the options against the displayed size of the source and
+// fills in the default fit and background.
+func (o *TransformOptions) Validate(sourceWidth, sourceHeight int) error {
+	if o.Crop == nil && o.Rotate == 0 && !o.FlipH && !o.FlipV && o.Width == 0 && o.Height == 0 {
+		return fmt.Errorf("%w: no transformation requested", ErrInvalidTransform)
+	}
+
+	if c := o.Crop; c != nil {
+		if c.X < 0 || c.Y < 0 || c.Width <= 0 || c.Height <= 0 ||
+			c.X+c.Width > sourceWidth || c.Y+c.Height > sourceHeight {
+			return fmt.Errorf("%w: crop must lie within the %dx%d picture", ErrInvalidTransform, sourceWidth, sourceHeight)
+		}
+		if c.Width%2 != 0 || c.Height%2 != 0 {
+			return fmt.Errorf("%w: crop width and height must be even", ErrInvalidTransform)
+		}
+	}
+
+	switch o.Rotate {
+	case 0, 90, 180, 270:
+	default:
+		return fmt.Errorf("%w: rotate must be 0, 90, 180 or 270", ErrInvalidTransform)
+	}
+
+	for _, size := range []int{o.Width, o.Height} {
+		if size != 0 && (size < 16 || size > MaxTransformSize || size%2 != 0) {
+			return fmt.Errorf("%w: width and height must be even and between 16 and %d", ErrInvalidTransform, MaxTransformSize)
+		}
+	}
+
+	if o.Width == 0 || o.Height == 0 {
+		if o.Fit != "" || o.Background != "" {
+			return fmt.Errorf("%w: fit and background require both width and height", ErrInvalidTransform)
+		}
+		return nil
+	}
+
+	switch o.Fit {
+	case "":
+		o.Fit = FitPad
+	case FitStretch, FitCrop, FitPad:
+	default:
+		return fmt.Errorf("%w: fit must be stretch, crop or pad", ErrInvalidTransform)
+	}
+
+	if o.Fit != FitPad {
+		if o.Background != "" {
+			return fmt.Errorf("%w: background only applies to fit pad", ErrInvalidTransform)
+		}
+		return nil
+	}
+	if o.Background == "" {
+		o.Background = "black"
+	}
+	if o.Background != BackgroundBlur && !colorPattern.MatchString(o.Background) {
+		return fmt.Errorf("%w: background must be blur, a colour name or #RRGGBB", ErrInvalidTransform)
+	}
+	return nil
+}
+
+func (p *FFmpegProcessor) Transform(ctx context.Context, inputPath, outputPath string, opts TransformOptions) error {
+	args := []string{
+		"-i", inputPath,
+		"-filter_complex", transformFilter(opts),
+		"-map", "[v]",
+		"-map", "0:a?",
+	}
+	args = append(args, p.encodeArgs(ctx)...)
+	args = append(args, outputArgs(outputPath)...)
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to transform video: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// transformFilter builds the filtergraph for validated options. ffmpeg has
+// already applied the source rotation metadata to the frames it feeds into
+// the graph, so the filters work on the picture as displayed.
+func transformFilter(opts TransformOptions) string {
+	var chain []string
+
+	if c := opts.Crop; c != nil {
+		chain = append(chain, fmt.Sprintf("crop=%d:%d:%d:%d", c.Width, c.Height, c.X, c.Y))
+	}
+
+	switch opts.Rotate {
+	case 90:
+		chain = append(chain, "transpose=clock")
+	case 180:
+		chain = append(chain, "hflip", "vflip")
+	case 270:
+		chain = append(chain, "transpose=cclock")
+	}
+	if opts.FlipH {
+		chain = append(chain, "hflip")
+	}
+	if opts.FlipV {
+		chain = append(chain, "vflip")
+	}
+
+	w, h := opts.Width, opts.Height
+	fitInside := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease:force_divisible_by=2", w, h)
+	fill := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d", w, h, w, h)
+
+	switch {
+	case w == 0 && h == 0:
+	case h == 0:
+		chain = append(chain, fmt.Sprintf("scale=%d:-2", w))
+	case w == 0:
+		chain = append(chain, fmt.Sprintf("scale=-2:%d", h))
+	case opts.Fit == FitStretch:
+		chain = append(chain, fmt.Sprintf("scale=%d:%d", w, h))
+	case opts.Fit == FitCrop:
+		chain = append(chain, fill)
+	case opts.Background == BackgroundBlur:
+		// The picture is fitted inside the frame over a copy of itself that
+		// has been scaled to fill the frame and blurred.
+		chain = append(chain, "split[bg][fg]")
+		return "[0:v]" + strings.Join(chain, ",") +
+			";[bg]" + fill + ",gblur=sigma=30[bg]" +
+			";[fg]" + fitInside + "[fg]" +
+			";[bg][fg]overlay=(W-w)/2:(H-h)/2,setsar=1[v]"
+	default:
+		chain = append(chain, fitInside, fmt.Sprintf("pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=%s", w, h, opts.Background))
+	}
+
+	chain = append(chain, "setsar=1")
+	return "[0:v]" + strings.Join(chain, ",") + "[v]"
+}
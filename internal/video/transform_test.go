@@ -0,0 +1,116 @@
+package video
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransformOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           TransformOptions
+		wantErr        bool
+		wantFit        string
+		wantBackground string
+	}{
+		{name: "crop", opts: TransformOptions{Crop: &Rect{X: 10, Y: 10, Width: 100, Height: 100}}},
+		{name: "rotate", opts: TransformOptions{Rotate: 90}},
+		{name: "width only", opts: TransformOptions{Width: 640}},
+		{name: "default fit", opts: TransformOptions{Width: 640, Height: 640}, wantFit: FitPad, wantBackground: "black"},
+		{name: "hex background", opts: TransformOptions{Width: 640, Height: 640, Background: "#FF00aa"}, wantFit: FitPad, wantBackground: "#FF00aa"},
+		{name: "crop fit", opts: TransformOptions{Width: 640, Height: 640, Fit: FitCrop}, wantFit: FitCrop},
+		{name: "nothing", opts: TransformOptions{}, wantErr: true},
+		{name: "crop outside", opts: TransformOptions{Crop: &Rect{X: 1800, Width: 200, Height: 100}}, wantErr: true},
+		{name: "odd crop", opts: TransformOptions{Crop: &Rect{Width: 101, Height: 100}}, wantErr: true},
+		{name: "bad rotation", opts: TransformOptions{Rotate: 45}, wantErr: true},
+		{name: "odd width", opts: TransformOptions{Width: 641}, wantErr: true},
+		{name: "fit without height", opts: TransformOptions{Width: 640, Fit: FitCrop}, wantErr: true},
+		{name: "background with crop fit", opts: TransformOptions{Width: 640, Height: 640, Fit: FitCrop, Background: "blur"}, wantErr: true},
+		{name: "bad colour", opts: TransformOptions{Width: 640, Height: 640, Background: "red;drop"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			err := opts.Validate(1920, 1080)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidTransform) {
+					t.Errorf("Validate() error = %v, want ErrInvalidTransform", err)
+				}
+				return
+			}
+			if opts.Fit != tt.wantFit || opts.Background != tt.wantBackground {
+				t.Errorf("defaults = %q/%q, want %q/%q", opts.Fit, opts.Background, tt.wantFit, tt.wantBackground)
+			}
+		})
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	var opts TransformOptions
+	if err := opts.ApplyPreset("vertical-1080x1920"); err != nil {
+		t.Fatalf("ApplyPreset() unexpected error: %v", err)
+	}
+	if opts.Width != 1080 || opts.Height != 1920 || opts.Fit != FitPad || opts.Background != BackgroundBlur {
+		t.Errorf("ApplyPreset() = %+v", opts)
+	}
+
+	cropped := TransformOptions{Fit: FitCrop}
+	cropped.ApplyPreset("square-1080x1080")
+	if cropped.Fit != FitCrop || cropped.Background != "" {
+		t.Errorf("ApplyPreset() overrode fit: %+v", cropped)
+	}
+
+	if err := opts.ApplyPreset("cinema"); !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("ApplyPreset(unknown) error = %v, want ErrUnknownPreset", err)
+	}
+}
+
+func TestTransformFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TransformOptions
+		want string
+	}{
+		{
+			name: "crop rotate flip",
+			opts: TransformOptions{Crop: &Rect{X: 8, Y: 4, Width: 640, Height: 360}, Rotate: 90, FlipH: true},
+			want: "[0:v]crop=640:360:8:4,transpose=clock,hflip,setsar=1[v]",
+		},
+		{
+			name: "scale width",
+			opts: TransformOptions{Width: 1280},
+			want: "[0:v]scale=1280:-2,setsar=1[v]",
+		},
+		{
+			name: "fill",
+			opts: TransformOptions{Width: 1080, Height: 1080, Fit: FitCrop},
+			want: "[0:v]scale=1080:1080:force_original_aspect_ratio=increase,crop=1080:1080,setsar=1[v]",
+		},
+		{
+			name: "pad colour",
+			opts: TransformOptions{Rotate: 180, Width: 1080, Height: 1920, Fit: FitPad, Background: "white"},
+			want: "[0:v]hflip,vflip,scale=1080:1920:force_original_aspect_ratio=decrease:force_divisible_by=2," +
+				"pad=1080:1920:(ow-iw)/2:(oh-ih)/2:color=white,setsar=1[v]",
+		},
+		{
+			name: "pad blur",
+			opts: TransformOptions{Width: 1080, Height: 1920, Fit: FitPad, Background: BackgroundBlur},
+			want: "[0:v]split[bg][fg]" +
+				";[bg]scale=1080:1920:force_original_aspect_ratio=increase,crop=1080:1920,gblur=sigma=30[bg]" +
+				";[fg]scale=1080:1920:force_original_aspect_ratio=decrease:force_divisible_by=2[fg]" +
+				";[bg][fg]overlay=(W-w)/2:(H-h)/2,setsar=1[v]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transformFilter(tt.opts); got != tt.want {
+				t.Errorf("transformFilter() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package video
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// PeaksSampleRate is the rate audio is resampled to before peaks are
+// computed. It only needs to resolve the shape of the waveform.
+const PeaksSampleRate = 16000
+
+var ErrInvalidWaveform = errors.New("invalid waveform options")
+
+// WaveformOptions size and colour the waveform image.
+type WaveformOptions struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Color  string `json:"color"`
+}
+
+var DefaultWaveformOptions = WaveformOptions{Width: 1800, Height: 280, Color: "#3b82f6"}
+
+func (o WaveformOptions) Validate() error {
+	if o.Width < 100 || o.Width > 8000 {
+		return fmt.Errorf("%w: width must be between 100 and 8000", ErrInvalidWaveform)
+	}
+	if o.Height < 20 || o.Height > 2000 {
+		return fmt.Errorf("%w: height must be between 20 and 2000", ErrInvalidWaveform)
+	}
+	if !colorPattern.MatchString(o.Color) {
+		return fmt.Errorf("%w: color must be a colour name or #RRGGBB", ErrInvalidWaveform)
+	}
+	return nil
+}
+
+// PeaksOptions control the resolution and sample size of the peaks data.
+type PeaksOptions struct {
+	SamplesPerPixel int `json:"samples_per_pixel"`
+	Bits            int `json:"bits"`
+}
+
+var DefaultPeaksOptions = PeaksOptions{SamplesPerPixel: 256, Bits: 8}
+
+func (o PeaksOptions) Validate() error {
+	if o.SamplesPerPixel < 32 || o.SamplesPerPixel > 16384 {
+		return fmt.Errorf("%w: samples_per_pixel must be between 32 and 16384", ErrInvalidWaveform)
+	}
+	if o.Bits != 8 && o.Bits != 16 {
+		return fmt.Errorf("%w: bits must be 8 or 16", ErrInvalidWaveform)
+	}
+	return nil
+}
+
+// Peaks holds the minimum and maximum sample of every bucket of
+// SamplesPerPixel samples, interleaved in Data. The layout follows the JSON
+// format written by audiowaveform, which wavesurfer.js and peaks.js read.
+type Peaks struct {
+	Version         int   `json:"version"`
+	Channels        int   `json:"channels"`
+	SampleRate      int   `json:"sample_rate"`
+	SamplesPerPixel int   `json:"samples_per_pixel"`
+	Bits            int   `json:"bits"`
+	Length          int   `json:"length"`
+	Data            []int `json:"data"`
+}
+
+func (p *FFmpegProcessor) RenderWaveform(ctx context.Context, inputPath, outputPath string, opts WaveformOptions) error {
+	args := []string{
+		"-i", inputPath,
+		"-filter_complex", fmt.Sprintf("aformat=channel_layouts=mono,showwavespic=s=%dx%d:colors=%s", opts.Width, opts.Height, opts.Color),
+		"-frames:v", "1",
+		"-y", outputPath,
+	}
+
+	if output, err := p.runFFmpeg(ctx, args); err != nil {
+		return fmt.Errorf("failed to render waveform: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// ComputePeaks decodes the audio of a video to raw mono 16-bit samples in
+// scratchPath, which is removed afterwards, and reduces them to peaks.
+func (p *FFmpegProcessor) ComputePeaks(ctx context.Context, inputPath, scratchPath string, opts PeaksOptions) (*Peaks, error) {
+	args := []string{
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", fmt.Sprintf("%d", PeaksSampleRate),
+		"-f", "s16le",
+		"-y", scratchPath,
+	}
+
+	output, err := p.runFFmpeg(ctx, args)
+	defer os.Remove(scratchPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w, output: %s", err, string(output))
+	}
+
+	raw, err := os.ReadFile(scratchPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decoded audio: %w", err)
+	}
+
+	return computePeaks(raw, opts), nil
+}
+
+// computePeaks reduces little-endian signed 16-bit mono samples to peaks.
+// With 8 bits the values are scaled down to the range -128 to 127.
+func computePeaks(raw []byte, opts PeaksOptions) *Peaks {
+	samples := len(raw) / 2
+	peaks := &Peaks{
+		Version:         2,
+		Channels:        1,
+		SampleRate:      PeaksSampleRate,
+		SamplesPerPixel: opts.SamplesPerPixel,
+		Bits:            opts.Bits,
+		Data:            []int{},
+	}
+
+	for start := 0; start < samples; start += opts.SamplesPerPixel {
+		end := start + opts.SamplesPerPixel
+		if end > samples {
+			end = samples
+		}
+
+		min, max := 0, 0
+		for i := start; i < end; i++ {
+			v := int(int16(binary.LittleEndian.Uint16(raw[i*2:])))
+			if i == start || v < min {
+				min = v
+			}
+			if i == start || v > max {
+				max = v
+			}
+		}
+
+		if opts.Bits == 8 {
+			min, max = min>>8, max>>8
+		}
+		peaks.Data = append(peaks.Data, min, max)
+		peaks.Length++
+	}
+
+	return peaks
+}
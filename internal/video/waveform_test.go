@@ -0,0 +1,68 @@
+package video
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestComputePeaks(t *testing.T) {
+	samples := []int16{100, -200, 300, 32767, -32768, 0, 512}
+	raw := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(raw[i*2:], uint16(s))
+	}
+
+	tests := []struct {
+		name string
+		opts PeaksOptions
+		want []int
+	}{
+		{"16 bit", PeaksOptions{SamplesPerPixel: 3, Bits: 16}, []int{-200, 300, -32768, 32767, 512, 512}},
+		{"8 bit", PeaksOptions{SamplesPerPixel: 3, Bits: 8}, []int{-1, 1, -128, 127, 2, 2}},
+		{"single bucket", PeaksOptions{SamplesPerPixel: 32, Bits: 16}, []int{-32768, 32767}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computePeaks(raw, tt.opts)
+			if !reflect.DeepEqual(got.Data, tt.want) {
+				t.Errorf("computePeaks() data = %v, want %v", got.Data, tt.want)
+			}
+			if got.Length != len(tt.want)/2 {
+				t.Errorf("computePeaks() length = %d, want %d", got.Length, len(tt.want)/2)
+			}
+			if got.Version != 2 || got.Channels != 1 || got.SampleRate != PeaksSampleRate || got.Bits != tt.opts.Bits {
+				t.Errorf("computePeaks() header = %+v", got)
+			}
+		})
+	}
+}
+
+func TestWaveformOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{"default image", DefaultWaveformOptions.Validate(), false},
+		{"named color", WaveformOptions{Width: 800, Height: 100, Color: "white"}.Validate(), false},
+		{"narrow image", WaveformOptions{Width: 50, Height: 100, Color: "white"}.Validate(), true},
+		{"bad color", WaveformOptions{Width: 800, Height: 100, Color: "#12"}.Validate(), true},
+		{"default peaks", DefaultPeaksOptions.Validate(), false},
+		{"bad bits", PeaksOptions{SamplesPerPixel: 256, Bits: 12}.Validate(), true},
+		{"tiny buckets", PeaksOptions{SamplesPerPixel: 8, Bits: 8}.Validate(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", tt.err, tt.wantErr)
+			}
+			if tt.err != nil && !errors.Is(tt.err, ErrInvalidWaveform) {
+				t.Errorf("Validate() error = %v, want ErrInvalidWaveform", tt.err)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package e2e
 
 import (
 	"bytes"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,13 +9,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"testing"
 
 	"vidproc-go/internal/api"
 	"vidproc-go/internal/config"
 	"vidproc-go/internal/storage"
+	"vidproc-go/internal/video"
 )
 
 func setupTestEnvironment(t *testing.T) (*httptest.Server, config.Config, func()) {
@@ -48,41 +47,25 @@ func setupTestEnvironment(t *testing.T) (*httptest.Server, config.Config, func()
 		MaxVideoSize:     10 * 1024 * 1024,
 		MaxDuration:      300,
 		MinDuration:      1,
+		Processor:        video.ProcessorFake,
 	}
 
-	db, err := sql.Open("sqlite3", filepath.Join(dbDir, "test.db"))
+	db, err := storage.NewDB(cfg.DBPath)
 	if err != nil {
 		t.Fatalf("Failed to open database: %v", err)
 	}
 
-	_, err = db.Exec(`
-        CREATE TABLE IF NOT EXISTS videos (
-            id TEXT PRIMARY KEY,
-            filename TEXT NOT NULL,
-            size INTEGER NOT NULL,
-            duration INTEGER NOT NULL,
-            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-            status TEXT NOT NULL,
-            error_message TEXT
-        );
-
-        CREATE TABLE IF NOT EXISTS share_links (
-            id TEXT PRIMARY KEY,
-            video_id TEXT NOT NULL,
-            expires_at TIMESTAMP NOT NULL,
-            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-            FOREIGN KEY (video_id) REFERENCES videos(id)
-        );
-    `)
+	processor, err := video.NewProcessor(cfg.Processor, video.SchedulerOptions{})
 	if err != nil {
-		t.Fatalf("Failed to create tables: %v", err)
+		t.Fatalf("Failed to create processor: %v", err)
 	}
 
-	router := api.NewRouter(db, cfg)
+	router := api.NewRouter(db, cfg, processor, storage.NewLocalBlobStore(cfg.VideoStoragePath))
 	server := httptest.NewServer(router.SetupRoutes())
 
 	cleanup := func() {
 		server.Close()
+		db.Close()
 		os.RemoveAll(tmpDir)
 	}
 
@@ -91,24 +74,12 @@ func setupTestEnvironment(t *testing.T) (*httptest.Server, config.Config, func()
 
 func createTestVideo(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
 
-	tmpFile := filepath.Join(os.TempDir(), "test.mp4")
-	cmd := exec.Command("ffmpeg",
-		"-f", "lavfi",
-		"-i", "testsrc=duration=5:size=1280x720:rate=30",
-		"-c:v", "libx264",
-		"-y",
-		tmpFile,
-	)
-
-	if err := cmd.Run(); err != nil {
-		t.Fatalf("Failed to create test video: %v", err)
-	}
-	defer os.Remove(tmpFile)
-
-	videoData, err := os.ReadFile(tmpFile)
-	if err != nil {
-		t.Fatalf("Failed to read test video: %v", err)
-	}
+	videoData := video.FakeMP4(video.VideoInfo{
+		Duration:   5,
+		Width:      1280,
+		Height:     720,
+		AudioCodec: "aac",
+	})
 
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
@@ -126,14 +97,7 @@ func createTestVideo(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
 	return &buf, writer
 }
 
-func checkDependencies(t *testing.T) {
-	if _, err := exec.LookPath("ffmpeg"); err != nil {
-		t.Skip("ffmpeg not found, skipping video processing tests")
-	}
-}
-
 func TestE2EVideoProcessing(t *testing.T) {
-	checkDependencies(t)
 	server, _, cleanup := setupTestEnvironment(t)
 	defer cleanup()
 
@@ -173,8 +137,8 @@ func TestE2EVideoProcessing(t *testing.T) {
 
 		t.Run("TrimVideo", func(t *testing.T) {
 			trimReq := api.TrimRequest{
-				Start: 0,
-				End:   5,
+				Start: 1,
+				End:   4,
 			}
 
 			reqBody, _ := json.Marshal(trimReq)
@@ -192,7 +156,17 @@ func TestE2EVideoProcessing(t *testing.T) {
 			defer resp.Body.Close()
 
 			if resp.StatusCode != http.StatusOK {
-				t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+			}
+
+			var trimResp struct {
+				Data *storage.Video `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&trimResp); err != nil {
+				t.Fatalf("Failed to decode trim response: %v", err)
+			}
+			if trimResp.Data.Duration != 3 {
+				t.Errorf("Expected trimmed duration 3, got %d", trimResp.Data.Duration)
 			}
 		})
 